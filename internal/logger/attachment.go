@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Fields of entries logged by LogWithAttachment
+const (
+	AttachmentKeyField   = "attachment_key"   // Redis key holding the attached blob
+	AttachmentErrorField = "attachment_error" // Why the blob could not be stored
+)
+
+var attachmentTTL atomic.Int64 // How long attached blobs are kept in Redis, a time.Duration; 0 until set
+
+// loadAttachmentConfig reads APPLG_ATTACHMENT_TTL (in seconds) from the environment
+func loadAttachmentConfig() {
+	SetAttachmentTTL(time.Duration(getEnvAsInt("APPLG_ATTACHMENT_TTL", 86400)) * time.Second)
+}
+
+// SetAttachmentTTL sets how long attached blobs are kept in Redis; a
+// non-positive TTL restores the default of a day
+func SetAttachmentTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	attachmentTTL.Store(int64(ttl))
+}
+
+// AttachmentTTL returns how long attached blobs are kept in Redis
+func AttachmentTTL() time.Duration {
+	if ttl := time.Duration(attachmentTTL.Load()); ttl > 0 {
+		return ttl
+	}
+	return 24 * time.Hour
+}
+
+// AttachmentKey returns the key of a blob attached to an entry of identity
+// id under facility (the identity's own when empty), next to its list:
+// applogs:<facility>:<type>:<service>:<instance>:attachment:<attachment ID>
+func AttachmentKey(id Identity, facility string) string {
+	if facility != "" {
+		id.FacilityID = facility
+	}
+	return joinKey(id.key(), "attachment", NewEntryID())
+}
+
+// StoreAttachment writes blob to key through the default instance
+func StoreAttachment(key string, blob []byte) error {
+	return Default().StoreAttachment(key, blob)
+}
+
+// StoreAttachment writes blob to key with SET, expiring after the attachment
+// TTL. Attachments bypass the queue and fallback, so the error is returned
+// to the caller; ErrRedisUnavailable means no Redis client is set.
+func (in *Instance) StoreAttachment(key string, blob []byte) error {
+	rdb := in.redisClient()
+	if rdb == nil {
+		return ErrRedisUnavailable
+	}
+	return rdb.Set(ctx, key, blob, AttachmentTTL()).Err()
+}
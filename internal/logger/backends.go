@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// backend is an additional destination receiving every entry next to Redis
+// or the sink. A backend that fails saves the entries to its own fallback
+// subdirectory, and recovery resends them to that backend only.
+type backend struct {
+	name string
+	sink Sink
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   []backend
+)
+
+// AddBackend registers a destination receiving every entry in addition to
+// Redis or the sink. Its fallback entries are kept in a subdirectory of the
+// fallback path named after it. Adding a name again replaces the backend;
+// a nil sink removes it.
+func AddBackend(name string, s Sink) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	for i, b := range backends {
+		if b.name == name {
+			backends = append(backends[:i:i], backends[i+1:]...)
+			break
+		}
+	}
+	if s != nil {
+		backends = append(backends, backend{name: name, sink: s})
+	}
+}
+
+// registeredBackends returns a snapshot of the additional backends
+func registeredBackends() []backend {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	return append([]backend(nil), backends...)
+}
+
+// backendNames lists the additional backends for diagnostics
+func backendNames() []string {
+	var names []string
+	for _, b := range registeredBackends() {
+		names = append(names, "backend:"+b.name)
+	}
+	return names
+}
+
+// fallbackDir returns the fallback subdirectory of the backend under the
+// fallback path root
+func (b backend) fallbackDir(root string) string {
+	return filepath.Join(root, sanitizeFileComponent(b.name))
+}
+
+// pushToBackends sends entries to every additional backend, saving them to
+// the fallback subdirectory of each backend that fails
+func (in *Instance) pushToBackends(entries []EncodedEntry) {
+	for _, b := range registeredBackends() {
+		err := in.pushBatchToSink(b.sink, entries)
+		if err == nil {
+			continue
+		}
+		in.logger.Warn("Backend unavailable, saving batch to fallback", zlog.String("backend", b.name), zlog.Int("count", len(entries)), zlog.Error(err))
+		for _, entry := range entries {
+			if err := in.writeFallbackTo(b.fallbackDir(in.currentFallbackPath()), entry.Data); err != nil && !errors.Is(err, ErrFallbackFull) {
+				logData, _ := DecodeLogData(entry.Data)
+				lostLog(logData, err)
+			}
+		}
+	}
+}
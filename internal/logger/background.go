@@ -0,0 +1,56 @@
+package logger
+
+import "time"
+
+// startBackground runs loop on its own goroutine until StopBackground
+// closes the channel it is given
+func (in *Instance) startBackground(loop func(stop <-chan struct{})) {
+	in.backgroundMu.Lock()
+	defer in.backgroundMu.Unlock()
+	stop := in.backgroundStop
+	in.backgroundWG.Add(1)
+	go func() {
+		defer in.backgroundWG.Done()
+		loop(stop)
+	}()
+}
+
+// StopBackground stops the background loops of the default instance
+func StopBackground() {
+	if in := defaultInstance.Load(); in != nil {
+		in.StopBackground()
+	}
+}
+
+// StopBackground stops the periodic recovery and cleanup loops and a
+// reconnection to Redis in progress, returning once the loops have exited,
+// after the recovery pass in progress if any. Loops started afterwards run
+// until the next call.
+func (in *Instance) StopBackground() {
+	in.backgroundMu.Lock()
+	close(in.backgroundStop)
+	in.backgroundStop = make(chan struct{})
+	in.backgroundMu.Unlock()
+
+	in.stopReconnect()
+	in.backgroundWG.Wait()
+}
+
+// startCleanupProcess deletes old local log files right away, then every
+// cleanup interval, until StopBackground
+func (in *Instance) startCleanupProcess() {
+	interval := in.cfg.CleanupInterval
+	in.startBackground(func(stop <-chan struct{}) {
+		in.cleanupOldLogs()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				in.cleanupOldLogs()
+			}
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// FallbackBacklog describes the fallback entries of this instance waiting
+// to be recovered
+type FallbackBacklog struct {
+	Files     int           // Fallback files pending recovery
+	Bytes     int64         // Their total size
+	Oldest    time.Time     // Time the oldest pending entry was logged, zero without any
+	OldestAge time.Duration // How long the oldest pending entry has waited, 0 without any
+}
+
+var backlogMaxAge atomic.Int64 // Age of the oldest pending entry above which recovery warns, a time.Duration; 0 disables it
+
+// loadBacklogConfig reads APPLG_BACKLOG_MAX_AGE, in seconds
+func loadBacklogConfig() {
+	SetBacklogMaxAge(time.Duration(getEnvAsInt("APPLG_BACKLOG_MAX_AGE", 0)) * time.Second)
+}
+
+// SetBacklogMaxAge makes every recovery pass warn, and emit
+// EventBacklogDelayed, while the oldest pending fallback entry is older than
+// maxAge, e.g. to alert when delivery is more than 5 minutes behind. 0
+// disables the check.
+func SetBacklogMaxAge(maxAge time.Duration) {
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	backlogMaxAge.Store(int64(maxAge))
+}
+
+// BacklogMaxAge returns the backlog age threshold, 0 when disabled
+func BacklogMaxAge() time.Duration {
+	return time.Duration(backlogMaxAge.Load())
+}
+
+// FallbackStatus returns the fallback backlog of the default instance
+func FallbackStatus() FallbackBacklog {
+	return Default().FallbackStatus()
+}
+
+// FallbackStatus returns the fallback files of this instance pending
+// recovery, with the age of their oldest entry. The time of an entry is its
+// timestamp, read from the first line of each file, or the modification
+// time of a file whose first line has none.
+func (in *Instance) FallbackStatus() FallbackBacklog {
+	var backlog FallbackBacklog
+	dir := in.currentFallbackPath()
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return backlog
+	}
+	for _, file := range files {
+		name := file.Name()
+		if !isFallbackFileName(name) || (!in.ownsFallbackFile(name) && !legacyFallbackFile.MatchString(name)) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue // Recovered meanwhile
+		}
+		backlog.Files++
+		backlog.Bytes += info.Size()
+		if oldest := firstEntryTime(filepath.Join(dir, name), info.ModTime()); backlog.Oldest.IsZero() || oldest.Before(backlog.Oldest) {
+			backlog.Oldest = oldest
+		}
+	}
+	if !backlog.Oldest.IsZero() {
+		backlog.OldestAge = Now().Sub(backlog.Oldest)
+	}
+	return backlog
+}
+
+// firstEntryTime returns the timestamp of the first entry of a fallback
+// file, or modTime when it cannot be read
+func firstEntryTime(path string, modTime time.Time) time.Time {
+	f, err := openLogFile(path)
+	if err != nil {
+		return modTime
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return modTime
+	}
+	logData, err := DecodeLogData(scanner.Bytes())
+	if err != nil {
+		return modTime
+	}
+	value, _ := logData["timestamp"].(string)
+	timestamp, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return modTime
+	}
+	return timestamp
+}
+
+// checkBacklogAge warns when the oldest pending fallback entry is older than
+// the configured threshold
+func (in *Instance) checkBacklogAge() {
+	maxAge := BacklogMaxAge()
+	if maxAge == 0 {
+		return
+	}
+	backlog := in.FallbackStatus()
+	if backlog.OldestAge <= maxAge {
+		return
+	}
+	in.logger.Warn("Fallback backlog is older than the threshold",
+		zlog.Duration("oldest_age", backlog.OldestAge),
+		zlog.Duration("max_age", maxAge),
+		zlog.Int("files", backlog.Files),
+		zlog.Int64("bytes", backlog.Bytes))
+	EmitEvent(Event{Type: EventBacklogDelayed, Count: backlog.Files, File: in.currentFallbackPath()})
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+	"github.com/go-redis/redis/v8"
+)
+
+// Behaviors when a list is above the high-water mark
+const (
+	BackpressureBlock    = "block"    // Wait for the consumer to catch up, then divert to fallback
+	BackpressureFallback = "fallback" // Divert to fallback immediately
+)
+
+// backpressurePoll is the interval between list length checks while blocked
+const backpressurePoll = 50 * time.Millisecond
+
+// ErrListFull is returned by pushes to a list above the high-water mark
+var ErrListFull = errors.New("redis list is above the high-water mark")
+
+// backpressureSettings is the handling of lists above the high-water mark, see SetBackpressure
+type backpressureSettings struct {
+	highWater int           // List length above which pushes are held back; 0 disables
+	mode      string        // BackpressureBlock or BackpressureFallback
+	wait      time.Duration // Longest wait in BackpressureBlock mode
+}
+
+var backpressure atomic.Pointer[backpressureSettings] // Nil until set, which disables backpressure
+
+// loadBackpressureConfig reads APPLG_BACKPRESSURE_HIGH_WATER, APPLG_BACKPRESSURE_MODE
+// and APPLG_BACKPRESSURE_WAIT (in milliseconds)
+func loadBackpressureConfig() {
+	SetBackpressure(getEnvAsInt("APPLG_BACKPRESSURE_HIGH_WATER", 0),
+		os.Getenv("APPLG_BACKPRESSURE_MODE"),
+		time.Duration(getEnvAsInt("APPLG_BACKPRESSURE_WAIT", 1000))*time.Millisecond)
+}
+
+// SetBackpressure holds pushes back while a Redis list is longer than
+// highWater entries (0 disables it). In BackpressureBlock mode the push waits
+// up to wait for the list to shrink; otherwise, or once the wait is over, the
+// entries are diverted to fallback. Unknown modes fall back to BackpressureBlock.
+func SetBackpressure(highWater int, mode string, wait time.Duration) {
+	if mode != BackpressureFallback {
+		mode = BackpressureBlock
+	}
+	if highWater < 0 {
+		highWater = 0
+	}
+	backpressure.Store(&backpressureSettings{highWater: highWater, mode: mode, wait: wait})
+}
+
+// backpressureConfig returns the handling of lists above the high-water mark
+func backpressureConfig() backpressureSettings {
+	if settings := backpressure.Load(); settings != nil {
+		return *settings
+	}
+	return backpressureSettings{mode: BackpressureBlock}
+}
+
+// awaitListCapacity returns ErrListFull if a list entries are pushed to stays
+// above the high-water mark, after waiting for it in BackpressureBlock mode
+func (in *Instance) awaitListCapacity(entries []EncodedEntry) error {
+	settings := backpressureConfig()
+	if settings.highWater <= 0 || len(entries) == 0 {
+		return nil
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if !seen[entry.Key] {
+			seen[entry.Key] = true
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	deadline := time.Now().Add(settings.wait)
+	for {
+		full := in.fullList(keys, settings.highWater)
+		if full == "" {
+			return nil
+		}
+		if settings.mode != BackpressureBlock || !time.Now().Before(deadline) {
+			in.logger.Warn("Redis list above high-water mark, diverting to fallback",
+				zlog.String("key", full), zlog.Int("high_water", settings.highWater))
+			return ErrListFull
+		}
+		time.Sleep(backpressurePoll)
+	}
+}
+
+// fullList returns the first key longer than highWater entries, or "".
+// A failing check returns "" too, leaving the push itself to report the error.
+func (in *Instance) fullList(keys []string, highWater int) string {
+	pipe := in.redisClient().Pipeline()
+	lengths := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		lengths[i] = pipe.LLen(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return ""
+	}
+
+	for i, key := range keys {
+		if lengths[i].Val() > int64(highWater) {
+			return key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+)
+
+var batchConfig atomic.Pointer[config.BatchConfig] // Nil until set, see GetBatchConfig
+
+// loadBatchConfig reads the adaptive batching thresholds and the flush
+// interval, in milliseconds, from the environment
+func loadBatchConfig() {
+	SetBatchConfig(config.BatchConfig{
+		MinSize:       getEnvAsInt("APPLG_BATCH_MIN_SIZE", 1),
+		MaxSize:       getEnvAsInt("APPLG_BATCH_MAX_SIZE", 100),
+		GrowDepth:     getEnvAsInt("APPLG_BATCH_GROW_DEPTH", 10),
+		FlushInterval: time.Duration(getEnvAsInt("APPLG_BATCH_FLUSH_INTERVAL", 0)) * time.Millisecond,
+	})
+}
+
+// GetBatchConfig returns the current adaptive batching thresholds
+func GetBatchConfig() config.BatchConfig {
+	if cfg := batchConfig.Load(); cfg != nil {
+		return *cfg
+	}
+	return config.BatchConfig{MinSize: 1, MaxSize: 1}
+}
+
+// SetBatchConfig overrides the adaptive batching thresholds, correcting invalid values
+func SetBatchConfig(cfg config.BatchConfig) {
+	if cfg.MinSize < 1 {
+		cfg.MinSize = 1
+	}
+	if cfg.MaxSize < cfg.MinSize {
+		cfg.MaxSize = cfg.MinSize
+	}
+	if cfg.FlushInterval < 0 {
+		cfg.FlushInterval = 0
+	}
+	batchConfig.Store(&cfg)
+}
+
+// LogBatchToRedis pushes several log payloads through the default instance
+func LogBatchToRedis(batch []map[string]interface{}) {
+	Default().LogBatchToRedis(batch)
+}
+
+// LogBatchToRedis pushes several log payloads to Redis in a single pipeline,
+// or to the configured sink. If the destination is unavailable the whole
+// batch is saved to fallback; transient errors are retried first and fatal
+// ones drop the batch. See SetClassifyError.
+func (in *Instance) LogBatchToRedis(batch []map[string]interface{}) {
+	in.LogEncodedBatchToRedis(in.encodeBatch(batch))
+}
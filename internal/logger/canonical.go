@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync/atomic"
+)
+
+var canonicalJSON atomic.Bool // Whether every payload is serialized in canonical form
+
+// loadCanonicalConfig reads APPLG_CANONICAL_JSON from the environment
+func loadCanonicalConfig() {
+	canonicalJSON.Store(getEnvAsBool("APPLG_CANONICAL_JSON", false))
+}
+
+// SetCanonicalJSON enables or disables serializing every payload in
+// canonical form; signed payloads always are
+func SetCanonicalJSON(enabled bool) {
+	canonicalJSON.Store(enabled)
+}
+
+// CanonicalJSONEnabled reports whether every payload is serialized in canonical form
+func CanonicalJSONEnabled() bool {
+	return canonicalJSON.Load()
+}
+
+// CanonicalJSON serializes v in canonical form, which only depends on the
+// JSON value and not on the Go types holding it:
+//
+//   - object members are sorted by key, comparing the UTF-8 bytes, at every
+//     depth, including structs, json.RawMessage and values with a custom
+//     MarshalJSON;
+//   - there is no whitespace between tokens;
+//   - strings are escaped as encoding/json does, "<", ">" and "&" included;
+//   - numbers keep the digits encoding/json produced for them.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalize(data)
+}
+
+// canonicalize rewrites serialized JSON in canonical form. Decoding into
+// generic values turns every object into a map, which encoding/json
+// marshals with sorted keys, and json.Number keeps numbers intact.
+func canonicalize(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// ErrorClass tells the push path how to handle a failed push
+type ErrorClass int
+
+const (
+	ErrorUnavailable ErrorClass = iota // Destination is down: save the entries to fallback
+	ErrorTransient                     // Temporary failure: retry the push, then fall back
+	ErrorFatal                         // Entries were rejected: log the error and drop them
+)
+
+// String returns the name of the class
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorUnavailable:
+		return "unavailable"
+	case ErrorTransient:
+		return "transient"
+	default:
+		return "fatal"
+	}
+}
+
+const (
+	transientRetries    = 3                     // Retries of a push failing with ErrorTransient
+	transientRetryDelay = 50 * time.Millisecond // Delay before the first retry, doubled on each attempt
+)
+
+var classifyError atomic.Pointer[func(err error) ErrorClass] // Replaces the built-in classification when set
+
+// SetClassifyError overrides how push errors are classified; nil restores the built-in classifier
+func SetClassifyError(fn func(err error) ErrorClass) {
+	if fn == nil {
+		classifyError.Store(nil)
+		return
+	}
+	classifyError.Store(&fn)
+}
+
+// customClassifier returns the classifier set with SetClassifyError, nil when none is
+func customClassifier() func(err error) ErrorClass {
+	if fn := classifyError.Load(); fn != nil {
+		return *fn
+	}
+	return nil
+}
+
+// DefaultClassifyError is the built-in classifier: network, TLS and
+// authentication failures are ErrorUnavailable and anything else is ErrorFatal.
+// Entries refused for bad credentials or certificates are kept in fallback,
+// and delivered once the configuration is fixed.
+func DefaultClassifyError(err error) ErrorClass {
+	if isRedisUnavailable(err) || isRedisAuthError(err) || isTLSError(err) {
+		return ErrorUnavailable
+	}
+	return ErrorFatal
+}
+
+// isRedisAuthError reports whether Redis refused the connection's credentials
+func isRedisAuthError(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "NOAUTH") || strings.HasPrefix(msg, "WRONGPASS")
+}
+
+// isTLSError reports whether the TLS handshake with Redis failed, because
+// the certificate was not verified or the server does not speak TLS
+func isTLSError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var headerErr tls.RecordHeaderError
+	return errors.As(err, &verifyErr) || errors.As(err, &headerErr)
+}
+
+// classifyPushError classifies a push error with the configured classifier.
+// A sink error is never fatal: the classifier may have it retried, and it
+// counts as unavailable otherwise, so that the entries go to fallback.
+func (in *Instance) classifyPushError(err error) ErrorClass {
+	if in.currentSink() != nil {
+		return classifySinkError(err)
+	}
+	if classify := customClassifier(); classify != nil {
+		return classify(err)
+	}
+	return DefaultClassifyError(err)
+}
+
+// classifySinkError classifies the error of a sink push, ErrorTransient when
+// the classifier says so and ErrorUnavailable otherwise
+func classifySinkError(err error) ErrorClass {
+	if classify := customClassifier(); classify != nil && classify(err) == ErrorTransient {
+		return ErrorTransient
+	}
+	return ErrorUnavailable
+}
+
+// pushWithRetry runs push, retrying it while it fails with a transient error.
+// It returns the class of the last error, which is nil on success.
+func (in *Instance) pushWithRetry(push func() error) (ErrorClass, error) {
+	delay := transientRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := push()
+		sink := in.currentSink()
+		if err == nil {
+			in.observeRedisState("connected", nil)
+			if sink == nil {
+				in.observePushResult(nil)
+			}
+			return ErrorUnavailable, nil
+		}
+		if errors.Is(err, ErrListFull) {
+			return ErrorUnavailable, err // Redis is healthy, the consumer lags
+		}
+
+		class := in.classifyPushError(err)
+		if class != ErrorTransient || attempt == transientRetries {
+			if class != ErrorFatal {
+				in.observeRedisState("unavailable", err)
+				if sink == nil {
+					in.observePushResult(err)
+				}
+			}
+			return class, err
+		}
+		in.logger.Warn("Transient push failure, retrying", zlog.Int("attempt", attempt+1), zlog.Error(err))
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time entries are stamped with
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to Clock, e.g. one returning a fixed time in tests
+type ClockFunc func() time.Time
+
+// Now calls f
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// clockHolder wraps the clock, atomic.Value requiring one concrete type
+type clockHolder struct{ Clock }
+
+var clock atomic.Value // Clock set by SetClock, a clockHolder
+
+// SetClock replaces the clock of the timestamps: those of entries, request
+// and response entries, recovery marks and events, the names of the syslog
+// and fallback files, the age of the local files cleanup deletes and of
+// the entry IDs recovery dedupes against. nil
+// restores the wall clock. Delays, timeouts and rate limits keep the wall
+// clock, and so do the timestamps of console and syslog file lines.
+func SetClock(c Clock) {
+	clock.Store(clockHolder{c})
+}
+
+// Now returns the time of the clock set by SetClock
+func Now() time.Time {
+	if c, ok := clock.Load().(clockHolder); ok && c.Clock != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
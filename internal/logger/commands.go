@@ -0,0 +1,27 @@
+package logger
+
+// RedisCommands returns the Redis commands issued with the current
+// configuration, lowercase, for granting the client a minimal ACL. Entries
+// are always pushed to lists; Redis streams are not used.
+func RedisCommands() []string {
+	commands := []string{"ping", "lpush"} // Connection checks and heartbeats, entry pushes
+	if path, _ := DeadlinePath(); path == DeadlinePathPubSub {
+		commands = append(commands, "publish") // Entries past their deadline
+	}
+	if MaxListLength() > 0 {
+		commands = append(commands, "ltrim") // List length caps
+	}
+	if KeyTTL() > 0 {
+		commands = append(commands, "expire") // List expiry
+	}
+	if backpressureConfig().highWater > 0 {
+		commands = append(commands, "llen") // List length checks
+	}
+	if dedupeConfig().enabled {
+		commands = append(commands, "zadd", "zremrangebyscore", "zscore") // Seen entry IDs
+		if KeyTTL() == 0 {
+			commands = append(commands, "expire")
+		}
+	}
+	return commands
+}
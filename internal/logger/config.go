@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+)
+
+var (
+	headerFormat   atomic.Value // Representation of request headers, a string; see HeaderFormat
+	logQueryParams atomic.Bool  // Whether request logging records the query parameters
+)
+
+// HeaderFormat returns the representation used for request headers
+func HeaderFormat() string {
+	if format, _ := headerFormat.Load().(string); format != "" {
+		return format
+	}
+	return config.HeaderFormatRaw
+}
+
+// SetHeaderFormat selects the representation used for request headers.
+// Unknown formats fall back to config.HeaderFormatRaw.
+func SetHeaderFormat(format string) {
+	switch format {
+	case config.HeaderFormatObject, config.HeaderFormatPrefixed:
+		headerFormat.Store(format)
+	default:
+		headerFormat.Store(config.HeaderFormatRaw)
+	}
+}
+
+// LogQueryParams reports whether request logging records the query parameters
+func LogQueryParams() bool {
+	return logQueryParams.Load()
+}
+
+// SetLogQueryParams enables or disables recording the query parameters of logged requests
+func SetLogQueryParams(enabled bool) {
+	logQueryParams.Store(enabled)
+}
+
+// EffectiveConfig returns the configuration of the default instance
+func EffectiveConfig() config.Config {
+	return Default().EffectiveConfig()
+}
+
+// EffectiveConfig returns the configuration resolved when the instance was
+// initialized, with the process-wide settings in effect.
+// Credentials are not redacted; use Config.Redacted before exposing it.
+func (in *Instance) EffectiveConfig() config.Config {
+	backends := append(backendNames(), "console", "syslog_file", "fallback_file")
+	if in.currentSink() != nil {
+		backends = append([]string{in.sinkName()}, backends...)
+	} else if in.redisClient() != nil {
+		backends = append([]string{"redis"}, backends...)
+	}
+
+	id := in.Identity()
+	reconnectBase, reconnectMax := ReconnectBackoff()
+	syslogGzip, syslogRotateBytes := syslogSettings()
+	deadlinePath, _ := DeadlinePath()
+	dedupe, backpressure, overflow := dedupeConfig(), backpressureConfig(), GetOverflowPolicy()
+	return config.Config{
+		ServiceName:        id.ServiceName,
+		InstanceID:         id.InstanceID,
+		FacilityID:         id.FacilityID,
+		InstanceType:       id.InstanceType,
+		RedisAddr:          in.cfg.RedisAddr,
+		RedisUsername:      in.cfg.RedisUsername,
+		RedisPassword:      in.cfg.RedisPassword,
+		RedisTLS:           in.cfg.RedisTLS || strings.HasPrefix(in.cfg.RedisAddr, "rediss://"),
+		RedisCAFile:        in.cfg.RedisCAFile,
+		RedisTLSInsecure:   in.cfg.RedisTLSInsecure,
+		WaitForRedis:       in.cfg.WaitForRedis,
+		RequireRedis:       in.cfg.RequireRedis,
+		ReconnectBase:      reconnectBase,
+		ReconnectMax:       reconnectMax,
+		FallbackPath:       in.currentFallbackPath(),
+		FallbackMode:       FallbackMode(),
+		CompressFallback:   compressFallback.Load(),
+		FallbackFileBytes:  MaxFallbackFileBytes(),
+		MaxFallbackBytes:   MaxFallbackBytes(),
+		SyslogsPath:        syslogsPath,
+		FallbackResyncTime: in.cfg.FallbackResyncTime,
+		RecoveryRate:       RecoveryRate(),
+		BacklogMaxAge:      BacklogMaxAge(),
+		SyslogKeepTime:     in.cfg.SyslogKeepTime,
+		CleanupInterval:    in.cfg.CleanupInterval,
+		MaxSyslogFiles:     in.cfg.MaxSyslogFiles,
+		SyslogGzip:         syslogGzip,
+		SyslogRotateBytes:  syslogRotateBytes,
+		Level:              Level(),
+		LogSpec:            levelSpecString(),
+		KeyDelimiter:       KeyDelimiter(),
+		MaxListLength:      MaxListLength(),
+		KeyTTL:             KeyTTL(),
+		DeadlinePath:       deadlinePath,
+		DeadlineTarget:     in.deadlineKey(),
+		Backends:           backends,
+		PriorityQueue:      PriorityQueueEnabled(),
+		HeaderFormat:       HeaderFormat(),
+		LogQueryParams:     LogQueryParams(),
+		ConsoleEncoder:     in.cfg.ConsoleEncoder,
+		SerializeOnEnqueue: serializeOnEnqueue.Load(),
+		KeyField:           keyField.Load(),
+		SchemaField:        schemaField.Load(),
+		Signing:            SigningEnabled(),
+		CanonicalJSON:      canonicalJSON.Load(),
+		HeartbeatInterval:  HeartbeatInterval(),
+		StatsInterval:      RuntimeStatsInterval(),
+		RuntimeStats:       RuntimeStats(),
+		DedupeRecovery:     dedupe.enabled,
+		DedupeWindow:       dedupe.window,
+		MarkRecovered:      markRecovered.Load(),
+		SummaryFields:      SummaryFields(),
+		Backpressure: config.BackpressureConfig{
+			HighWater: backpressure.highWater,
+			Mode:      backpressure.mode,
+			Wait:      backpressure.wait,
+		},
+		LoadShedding:      LoadShedding(),
+		SampleTarget:      SampleTarget(),
+		AnnotateContext:   AnnotateContext(),
+		TimerLevel:        TimerLevel(),
+		MaxStringBytes:    MaxStringBytes(),
+		StopPolicy:        StopPolicy(),
+		OverflowPolicy:    overflow.Mode,
+		OverflowTimeout:   overflow.Timeout,
+		FatalMode:         FatalMode(),
+		FatalExitCode:     FatalExitCode(),
+		FatalFlushTimeout: FatalFlushTimeout(),
+		StackTraceOnError: stackTraceOnError.Load(),
+		StackTraceOnFatal: stackTraceOnFatal.Load(),
+		AttachmentTTL:     AttachmentTTL(),
+		RedactKeys:        RedactedKeys(),
+		HeaderAllowlist:   HeaderAllowlist(),
+		HeaderDenylist:    HeaderDenylist(),
+		Batch:             GetBatchConfig(),
+	}
+}
@@ -0,0 +1,20 @@
+package logger
+
+import "sync/atomic"
+
+var annotateContext atomic.Bool // Whether the context-aware methods record the state of their context
+
+// loadContextConfig reads APPLG_ANNOTATE_CONTEXT from the environment
+func loadContextConfig() {
+	annotateContext.Store(getEnvAsBool("APPLG_ANNOTATE_CONTEXT", false))
+}
+
+// AnnotateContext reports whether the context-aware methods record the state of their context
+func AnnotateContext() bool {
+	return annotateContext.Load()
+}
+
+// SetAnnotateContext enables or disables recording the state of the context in the context-aware methods
+func SetAnnotateContext(enabled bool) {
+	annotateContext.Store(enabled)
+}
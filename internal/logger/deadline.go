@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"os"
+	"sync"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// Destinations for entries that missed their delivery deadline
+const (
+	DeadlinePathPubSub = "pubsub" // PUBLISH to a channel, LPUSH to the list of that name without subscribers
+	DeadlinePathList   = "list"   // LPUSH to a dedicated priority list
+)
+
+var (
+	deadlineMu     sync.Mutex
+	deadlinePath   = DeadlinePathPubSub // DeadlinePathPubSub or DeadlinePathList
+	deadlineTarget string               // Channel or list of expired entries; empty for the identity's own
+)
+
+// loadDeadlineConfig reads the priority path used for expired entries
+func loadDeadlineConfig() {
+	SetDeadlinePath(os.Getenv("APPLG_DEADLINE_PATH"), os.Getenv("APPLG_DEADLINE_TARGET"))
+}
+
+// SetDeadlinePath configures where entries still queued past their deadline are sent.
+// An empty target defaults to applogs:priority:<facility>:<type>:<service>:<instance>.
+func SetDeadlinePath(path, target string) {
+	if path != DeadlinePathList {
+		path = DeadlinePathPubSub
+	}
+	deadlineMu.Lock()
+	defer deadlineMu.Unlock()
+	deadlinePath, deadlineTarget = path, target
+}
+
+// DeadlinePath returns where entries past their deadline are sent, and the
+// configured target, empty for the default one of each identity
+func DeadlinePath() (path, target string) {
+	deadlineMu.Lock()
+	defer deadlineMu.Unlock()
+	return deadlinePath, deadlineTarget
+}
+
+// deadlineKey returns the channel or list expired entries of the instance
+// are delivered to
+func (in *Instance) deadlineKey() string {
+	return deadlineKeyFor(in.Identity())
+}
+
+// deadlineKeyFor returns the default priority target of an identity, or the configured target
+func deadlineKeyFor(id Identity) string {
+	if _, target := DeadlinePath(); target != "" {
+		return target
+	}
+	return joinKey(KeyPrefix, "priority", identityKey(id.FacilityID, id.InstanceType, id.ServiceName, id.InstanceID))
+}
+
+// LogToPriorityPath delivers an expired entry through the default instance
+func LogToPriorityPath(logData map[string]interface{}) {
+	Default().LogToPriorityPath(logData)
+}
+
+// LogToPriorityPath delivers an entry that missed its deadline on the priority
+// path instead of the regular queue. With DeadlinePathPubSub it is published
+// to the priority channel; while nobody is subscribed, or a sink replaces
+// Redis, it is pushed to the list of the same name instead, like entries of
+// DeadlinePathList. The push goes through the sink and the additional
+// backends, and to fallback when the destination is unavailable, as
+// regular entries do.
+func (in *Instance) LogToPriorityPath(logData map[string]interface{}) {
+	logData["deadline_expired"] = true
+
+	// Entries keep to the channel of the identity they were logged with,
+	// including another facility
+	key := deadlineKeyFor(identityOf(logData))
+	path, _ := DeadlinePath()
+	if keyField.Load() {
+		logData[KeyField] = key
+	}
+
+	data, err := marshalLogData(logData)
+	if err != nil {
+		in.logger.Error("Failed to marshal log data to JSON", zlog.Error(err))
+		return
+	}
+	id, _ := logData[EntryIDField].(string)
+	batch := []EncodedEntry{{Key: key, ID: id, Data: data}}
+	if path == DeadlinePathPubSub && in.publishExpired(batch[0]) {
+		in.pushToBackends(batch)
+		in.counters.pushed.Add(1)
+		return
+	}
+	in.LogEncodedBatchToRedis(batch)
+}
+
+// publishExpired publishes an expired entry to its priority channel,
+// reporting whether a subscriber received it. Nothing is published through
+// a sink or while Redis is reconnecting; a failed publish is left to the
+// list push, which retries it or falls back.
+func (in *Instance) publishExpired(entry EncodedEntry) bool {
+	rdb := in.redisClient()
+	if rdb == nil || in.currentSink() != nil || in.redisDown.Load() {
+		return false
+	}
+	receivers, err := rdb.Publish(ctx, entry.Key, entry.Data).Result()
+	return err == nil && receivers > 0
+}
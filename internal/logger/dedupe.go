@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+	"github.com/go-redis/redis/v8"
+)
+
+// EntryIDField holds the unique ID assigned to every entry, for consumers to dedupe on
+const EntryIDField = "entry_id"
+
+// dedupeSettings is the deduplication of recovered entries, see SetDedupeRecovery
+type dedupeSettings struct {
+	enabled bool          // Whether recovery skips entries already seen in Redis
+	window  time.Duration // How long pushed entry IDs are remembered
+}
+
+var recoveryDedupe atomic.Pointer[dedupeSettings] // Set at initialization, see loadDedupeConfig
+
+// loadDedupeConfig reads APPLG_DEDUPE_RECOVERY and APPLG_DEDUPE_WINDOW (in seconds)
+func loadDedupeConfig() {
+	SetDedupeRecovery(getEnvAsBool("APPLG_DEDUPE_RECOVERY", false),
+		time.Duration(getEnvAsInt("APPLG_DEDUPE_WINDOW", 600))*time.Second)
+}
+
+// SetDedupeRecovery enables remembering the IDs of pushed entries for window,
+// so that recovery does not push again an entry whose push was processed by
+// Redis but reported as failed (e.g. a lost acknowledgement)
+func SetDedupeRecovery(enabled bool, window time.Duration) {
+	if window <= 0 {
+		window = 10 * time.Minute
+	}
+	recoveryDedupe.Store(&dedupeSettings{enabled: enabled, window: window})
+}
+
+// dedupeConfig returns the deduplication of recovered entries, disabled if never set
+func dedupeConfig() dedupeSettings {
+	if settings := recoveryDedupe.Load(); settings != nil {
+		return *settings
+	}
+	return dedupeSettings{window: 10 * time.Minute}
+}
+
+// NewEntryID returns a random 128-bit entry ID in hex
+func NewEntryID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// seenKey returns the sorted set of the entry IDs pushed to key, scored by
+// the time of their push in Unix milliseconds. Scoring each ID when it is
+// recorded, rather than bucketing the sets by window, keeps the IDs
+// recorded before SetDedupeRecovery changed the window.
+func seenKey(key string) string {
+	return joinKey(KeyPrefix, "seen", strings.TrimPrefix(key, KeyPrefix+KeyDelimiter()))
+}
+
+// queueRecordSeen adds to pipe the recording of the IDs of entries pushed at
+// now, and the removal of the IDs of each set older than window, the set
+// expiring once nothing was pushed to its list for window
+func queueRecordSeen(pipe redis.Pipeliner, entries []EncodedEntry, now time.Time, window time.Duration) {
+	var keys []string
+	recorded := map[string]bool{}
+	for _, entry := range entries {
+		if entry.ID == "" {
+			continue
+		}
+		key := seenKey(entry.Key)
+		pipe.ZAdd(ctx, key, &redis.Z{Score: float64(now.UnixMilli()), Member: entry.ID})
+		if !recorded[key] {
+			keys = append(keys, key)
+			recorded[key] = true
+		}
+	}
+	oldest := "(" + strconv.FormatInt(now.Add(-window).UnixMilli(), 10)
+	for _, key := range keys {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", oldest)
+		pipe.Expire(ctx, key, window)
+	}
+}
+
+// filterSeen drops the logs whose ID was pushed within the dedupe window
+func (in *Instance) filterSeen(logs []map[string]interface{}) []map[string]interface{} {
+	settings := dedupeConfig()
+	if !settings.enabled || in.currentSink() != nil || len(logs) == 0 {
+		return logs
+	}
+
+	pipe := in.redisClient().Pipeline()
+	checks := make([]*redis.FloatCmd, len(logs))
+	for i, logData := range logs {
+		if id, _ := logData[EntryIDField].(string); id != "" {
+			checks[i] = pipe.ZScore(ctx, seenKey(logDataKey(logData)), id)
+		}
+	}
+	_, _ = pipe.Exec(ctx) // An ID never recorded fails its command with redis.Nil
+	for _, check := range checks {
+		if check != nil && check.Err() != nil && check.Err() != redis.Nil {
+			in.logger.Warn("Failed to check recovered entries against seen IDs", zlog.Error(check.Err()))
+			return logs
+		}
+	}
+
+	// An ID is seen when it was recorded within the current window
+	since := float64(Now().Add(-settings.window).UnixMilli())
+	unseen := logs[:0:0]
+	for i, logData := range logs {
+		if checks[i] != nil && checks[i].Err() == nil && checks[i].Val() >= since {
+			continue
+		}
+		unseen = append(unseen, logData)
+	}
+	if skipped := len(logs) - len(unseen); skipped > 0 {
+		in.logger.Info("Skipped recovered entries already delivered to Redis", zlog.Int("count", skipped))
+	}
+	return unseen
+}
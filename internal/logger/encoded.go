@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+	"github.com/go-redis/redis/v8"
+)
+
+// EncodedEntry is a log payload already serialized to JSON, together with
+// the Redis key it is pushed to
+type EncodedEntry struct {
+	Key  string
+	ID   string // Value of EntryIDField, empty if the payload has none
+	Data []byte
+}
+
+// KeyField records in the payload the key an entry was pushed to, when enabled
+const KeyField = "_key"
+
+var (
+	serializeOnEnqueue atomic.Bool // Whether entries are serialized on the caller's goroutine
+	keyField           atomic.Bool // Whether payloads carry KeyField
+)
+
+// loadSerializeConfig reads APPLG_SERIALIZE_ON_ENQUEUE and APPLG_KEY_FIELD from the environment
+func loadSerializeConfig() {
+	serializeOnEnqueue.Store(getEnvAsBool("APPLG_SERIALIZE_ON_ENQUEUE", false))
+	keyField.Store(getEnvAsBool("APPLG_KEY_FIELD", false))
+}
+
+// KeyFieldEnabled reports whether payloads record their destination key in KeyField
+func KeyFieldEnabled() bool {
+	return keyField.Load()
+}
+
+// SetKeyField enables or disables recording the destination key in KeyField
+func SetKeyField(enabled bool) {
+	keyField.Store(enabled)
+}
+
+// SerializeOnEnqueue reports whether entries are serialized when they are
+// queued rather than by the worker
+func SerializeOnEnqueue() bool {
+	return serializeOnEnqueue.Load()
+}
+
+// SetSerializeOnEnqueue enables or disables serializing entries when they are queued
+func SetSerializeOnEnqueue(enabled bool) {
+	serializeOnEnqueue.Store(enabled)
+}
+
+// EncodeLogData serializes a payload built by NewLogData
+func EncodeLogData(logData map[string]interface{}) (EncodedEntry, error) {
+	key := logDataKey(logData)
+	if keyField.Load() {
+		logData[KeyField] = key // Recomputed on recovery, so a replayed entry records its new key
+	}
+	data, err := marshalLogData(logData)
+	if err != nil {
+		return EncodedEntry{}, err
+	}
+	id, _ := logData[EntryIDField].(string)
+	return EncodedEntry{Key: key, ID: id, Data: data}, nil
+}
+
+// DecodeLogData parses a serialized payload, keeping numbers as json.Number
+// so that large integers survive being marshaled again
+func DecodeLogData(data []byte) (map[string]interface{}, error) {
+	var logData map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&logData); err != nil {
+		return nil, err
+	}
+	return logData, nil
+}
+
+// encodeBatch serializes payloads, skipping those that cannot be marshaled
+func (in *Instance) encodeBatch(logs []map[string]interface{}) []EncodedEntry {
+	entries := make([]EncodedEntry, 0, len(logs))
+	for _, logData := range logs {
+		entry, err := EncodeLogData(logData)
+		if err != nil {
+			in.logger.Error("Failed to marshal log data to JSON", zlog.Error(err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// LogEncodedBatchToRedis pushes serialized entries through the default instance
+func LogEncodedBatchToRedis(batch []EncodedEntry) {
+	Default().LogEncodedBatchToRedis(batch)
+}
+
+// LogEncodedBatchToRedis pushes serialized entries like LogBatchToRedis,
+// writing the bytes unchanged to fallback when the destination is unavailable,
+// and without trying Redis while it is being reconnected.
+// Additional backends receive the entries too, each with its own fallback.
+func (in *Instance) LogEncodedBatchToRedis(batch []EncodedEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	defer in.pushToBackends(batch)
+
+	if in.redisDown.Load() && in.currentSink() == nil {
+		for _, entry := range batch { // Reported once, when Redis went down
+			in.logEncodedToFallback(entry)
+		}
+		return
+	}
+	class, err := in.pushWithRetry(func() error { return in.pushEncoded(batch) })
+	if err != nil {
+		if class != ErrorFatal {
+			in.logger.Warn("Redis unavailable, saving batch to fallback", zlog.Int("count", len(batch)), zlog.Error(err))
+			for _, entry := range batch {
+				in.logEncodedToFallback(entry)
+			}
+		} else {
+			in.logger.Error("Failed to push log batch to Redis", zlog.Int("count", len(batch)), zlog.Error(err))
+			EmitEvent(Event{Type: EventLogDropped, Count: len(batch), Reason: "rejected", Err: err})
+		}
+		return
+	}
+	in.counters.pushed.Add(uint64(len(batch)))
+}
+
+// logEncodedToFallback saves a serialized entry locally, as logToFallback does
+func (in *Instance) logEncodedToFallback(entry EncodedEntry) {
+	if err := in.writeFallback(entry.Data); err != nil && !errors.Is(err, ErrFallbackFull) {
+		logData, _ := DecodeLogData(entry.Data)
+		lostLog(logData, err)
+	}
+}
+
+// SaveToFallback writes a serialized entry to the fallback of the default instance
+func SaveToFallback(entry EncodedEntry) {
+	Default().SaveToFallback(entry)
+}
+
+// SaveToFallback writes a serialized entry to fallback without trying the
+// destination, for entries that can no longer go through the queue
+func (in *Instance) SaveToFallback(entry EncodedEntry) {
+	in.logEncodedToFallback(entry)
+}
+
+// errRedisNotSet is returned for a push without a sink or a Redis client,
+// which InitApplogs reports when the address is invalid
+var errRedisNotSet = fmt.Errorf("%w: redis client is not set", ErrRedisUnavailable)
+
+// pushEncoded sends serialized entries to the configured sink, or to Redis
+// when none is set, recording how long the push took, a wait for list
+// capacity aside
+func (in *Instance) pushEncoded(entries []EncodedEntry) error {
+	sink := in.currentSink()
+	if sink == nil {
+		if in.redisClient() == nil {
+			return errRedisNotSet
+		}
+		if err := in.awaitListCapacity(entries); err != nil {
+			return err
+		}
+	}
+	defer observePushLatency(time.Now())
+	if sink != nil {
+		return in.pushBatchToSink(sink, entries)
+	}
+	return in.pushBatchToRedis(entries)
+}
+
+// pushBatchToRedis sends entries in a single pipeline
+func (in *Instance) pushBatchToRedis(entries []EncodedEntry) error {
+	pipe := in.redisClient().Pipeline()
+	pushes := make([]*redis.IntCmd, 0, len(entries))
+
+	for _, entry := range entries {
+		// Append new log to the list
+		pushes = append(pushes, pipe.LPush(ctx, entry.Key, entry.Data))
+	}
+	// Remember the IDs in the same pipeline, so that they are recorded even
+	// when the reply is lost and the entries fall back
+	if dedupe := dedupeConfig(); dedupe.enabled {
+		queueRecordSeen(pipe, entries, Now(), dedupe.window)
+	}
+	upkeep := queueListUpkeep(pipe, entries)
+
+	// Execute the pipeline commands
+	cmds, err := pipe.Exec(ctx)
+	if err == nil {
+		return nil
+	}
+	if err := failedPush(pushes); err != nil {
+		in.logger.Warn("Pipeline execution failed", zlog.Error(err))
+		return err // Avoid redundant per-command errors if the pushes failed
+	}
+
+	// The entries are in Redis; the other commands are retried by the next push
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && upkeep[cmd] {
+			in.logger.Warn("Failed to trim or expire Redis list", zlog.String("cmd", cmd.String()), zlog.Error(cmd.Err()))
+		} else if cmd.Err() != nil {
+			in.logger.Warn("Failed to record entry ID in Redis", zlog.String("cmd", cmd.String()), zlog.Error(cmd.Err()))
+		}
+	}
+	return nil
+}
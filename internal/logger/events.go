@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies an operational event of the client
+type EventType string
+
+const (
+	EventRedisStateChanged EventType = "redis_state_changed" // Redis became reachable or unreachable
+	EventLogDropped        EventType = "log_dropped"         // Entries were discarded
+	EventFallbackWritten   EventType = "fallback_written"    // An entry was saved to the fallback directory
+	EventRecoveryCompleted EventType = "recovery_completed"  // A recovery pass resent fallback entries
+	EventQueueSaturated    EventType = "queue_saturated"     // The log queue filled up
+	EventWorkerPanic       EventType = "worker_panic"        // The log worker panicked and was restarted
+	EventRedisReconnected  EventType = "redis_reconnected"   // Redis answered again after sustained push failures
+	EventBacklogDelayed    EventType = "backlog_delayed"     // The oldest pending fallback entry is older than the threshold
+)
+
+// Event is an operational event of the client, for status displays
+type Event struct {
+	Type   EventType
+	Time   time.Time
+	State  string // EventRedisStateChanged: "connected" or "unavailable"
+	Count  int    // Number of entries concerned; EventRedisReconnected: attempts made
+	Reason string // EventLogDropped: why the entries were discarded
+	File   string // EventFallbackWritten and EventRecoveryCompleted: fallback file; EventBacklogDelayed: fallback directory
+	Err    error  // Error behind the event, if any
+}
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   = map[chan Event]struct{}{}
+	hasSubscriber atomic.Bool // Lets EmitEvent skip all work without subscribers
+)
+
+// Subscribe returns a channel receiving every event from now on, buffered
+// with the given size. Events are dropped for a subscriber whose buffer is
+// full rather than slowing the logger down. Call cancel to unsubscribe.
+func Subscribe(buffer int) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, buffer)
+
+	subscribersMu.Lock()
+	subscribers[ch] = struct{}{}
+	hasSubscriber.Store(true)
+	subscribersMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			defer subscribersMu.Unlock()
+			delete(subscribers, ch)
+			hasSubscriber.Store(len(subscribers) > 0)
+			close(ch)
+		})
+	}
+}
+
+// EmitEvent sends an event to every subscriber without blocking
+func EmitEvent(event Event) {
+	if !hasSubscriber.Load() {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = Now()
+	}
+
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for ch := range subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// observeRedisState records the outcome of a Redis push, emitting
+// EventRedisStateChanged when the state differs from the last one seen
+func (in *Instance) observeRedisState(state string, err error) {
+	if in.currentSink() != nil {
+		return
+	}
+	if previous, _ := in.redisState.Swap(state).(string); previous != state {
+		EmitEvent(Event{Type: EventRedisStateChanged, State: state, Err: err})
+	}
+}
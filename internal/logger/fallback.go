@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// Fallback destinations
+const (
+	FallbackFile   = "file"   // Files in the fallback path, resent by recovery (default)
+	FallbackStderr = "stderr" // NDJSON lines on stderr, prefixed with FallbackLinePrefix
+	FallbackWriter = "writer" // NDJSON lines on a writer set with SetFallbackWriter
+)
+
+// FallbackLinePrefix starts every entry printed to stderr in FallbackStderr
+// mode, so that a collector can tell them from other output
+const FallbackLinePrefix = "APPLOGS_FALLBACK "
+
+// fallbackGzipSuffix is appended to the name of compressed fallback files
+const fallbackGzipSuffix = ".gz"
+
+var (
+	fallbackMu     sync.Mutex
+	fallbackMode   = FallbackFile
+	fallbackWriter io.Writer // Destination in FallbackStderr and FallbackWriter modes
+
+	compressFallback atomic.Bool // Whether fallback files are written gzip-compressed
+)
+
+// loadFallbackConfig reads APPLG_FALLBACK_MODE and APPLG_COMPRESS_FALLBACK
+// from the environment
+func loadFallbackConfig() {
+	if os.Getenv("APPLG_FALLBACK_MODE") == FallbackStderr {
+		setFallbackDestination(FallbackStderr, os.Stderr)
+	} else {
+		setFallbackDestination(FallbackFile, nil)
+	}
+	compressFallback.Store(getEnvAsBool("APPLG_COMPRESS_FALLBACK", false))
+}
+
+// SetCompressFallback writes fallback files gzip-compressed, as .log.gz.
+// Recovery reads compressed and plain files alike, whichever way they were
+// written.
+func SetCompressFallback(enabled bool) {
+	compressFallback.Store(enabled)
+}
+
+// CompressFallback reports whether fallback files are written gzip-compressed
+func CompressFallback() bool {
+	return compressFallback.Load()
+}
+
+// isFallbackFileName reports whether name is a fallback file, plain (.log)
+// or compressed (.log.gz)
+func isFallbackFileName(name string) bool {
+	return strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log"+fallbackGzipSuffix)
+}
+
+// fallbackRecord returns what is appended to a fallback file for data, one
+// or more entries without their final newline: the lines as they are, or a
+// complete gzip member of them for a compressed file. Concatenated members
+// read back as one stream, so every append stands on its own and a file
+// cut short by a crash still reads up to its last complete member.
+func fallbackRecord(data []byte, compressed bool) ([]byte, error) {
+	if !compressed {
+		return append(data, '\n'), nil
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if _, err := gz.Write([]byte{'\n'}); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetFallbackWriter saves fallback entries as NDJSON lines on w instead of
+// files, for environments without a usable local disk. Such entries are not
+// recovered by the client; whatever reads w is responsible for replaying
+// them. Passing nil restores fallback files.
+func SetFallbackWriter(w io.Writer) {
+	if w == nil {
+		setFallbackDestination(FallbackFile, nil)
+		return
+	}
+	setFallbackDestination(FallbackWriter, w)
+}
+
+// FallbackMode returns the fallback destination, one of the Fallback constants
+func FallbackMode() string {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	return fallbackMode
+}
+
+// setFallbackDestination records the fallback mode and its writer
+func setFallbackDestination(mode string, w io.Writer) {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	fallbackMode = mode
+	fallbackWriter = w
+}
+
+// writeFallbackLine writes a serialized entry to the fallback writer,
+// returning false in FallbackFile mode
+func (in *Instance) writeFallbackLine(data []byte) (bool, error) {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	if fallbackMode == FallbackFile {
+		return false, nil
+	}
+
+	line := make([]byte, 0, len(FallbackLinePrefix)+len(data)+1)
+	if fallbackMode == FallbackStderr {
+		line = append(line, FallbackLinePrefix...)
+	}
+	line = append(append(line, data...), '\n')
+	if _, err := fallbackWriter.Write(line); err != nil {
+		in.logger.Error("Failed to write fallback entry", zlog.String("mode", fallbackMode), zlog.Error(err))
+		return true, err
+	}
+	in.counters.fallbackWritten.Add(1)
+	EmitEvent(Event{Type: EventFallbackWritten, Count: 1, File: fallbackMode})
+	return true, nil
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// fallbackFullWarnInterval is the shortest time between two warnings about
+// the fallback budget
+const fallbackFullWarnInterval = time.Minute
+
+// ErrFallbackFull is returned for an entry that does not fit the fallback
+// disk budget even once the oldest fallback files are dropped. The entry is
+// counted in FallbackDroppedTotal.
+var ErrFallbackFull = errors.New("fallback disk budget exhausted")
+
+var (
+	maxFallbackBytes    atomic.Int64 // Budget of the fallback directory, backends included; 0 when unbounded
+	fallbackBudgetEpoch atomic.Int64 // Bumped when the budget is set, for instances to measure their usage again
+)
+
+// loadFallbackBudgetConfig reads APPLG_MAX_FALLBACK_BYTES
+func loadFallbackBudgetConfig() {
+	SetMaxFallbackBytes(int64(getEnvAsInt("APPLG_MAX_FALLBACK_BYTES", 0)))
+}
+
+// SetMaxFallbackBytes bounds the total size of the fallback directory,
+// including the subdirectories of additional backends, at maxBytes. An
+// entry that would exceed it first drops the oldest fallback files of this
+// instance; if it still does not fit, it is refused. Either way the
+// entries are counted in FallbackDroppedTotal and a warning is logged at
+// most once a minute. 0 leaves the directory unbounded.
+func SetMaxFallbackBytes(maxBytes int64) {
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	maxFallbackBytes.Store(maxBytes)
+	fallbackBudgetEpoch.Add(1)
+}
+
+// MaxFallbackBytes returns the fallback disk budget, 0 when unbounded
+func MaxFallbackBytes() int64 {
+	return maxFallbackBytes.Load()
+}
+
+// FallbackDroppedTotal returns the number of entries dropped to keep the
+// fallback directory within its budget
+func FallbackDroppedTotal() uint64 {
+	return fallbackDroppedTotal.Load()
+}
+
+// reserveFallbackBytes makes room for size more bytes in the fallback
+// directory, dropping the oldest fallback files of this instance; a file
+// being written is closed first, the entry then opening a new one. It is
+// called with fallbackFilesMu held, and returns ErrFallbackFull when the
+// entry must be refused.
+func (in *Instance) reserveFallbackBytes(size int64) error {
+	budget := maxFallbackBytes.Load()
+	if budget == 0 {
+		return nil
+	}
+	if epoch := fallbackBudgetEpoch.Load(); in.fallbackUsageEpoch != epoch || in.fallbackUsage+size > budget {
+		in.fallbackUsage, in.fallbackUsageEpoch = in.measureFallbackUsage(), epoch // Recovery may have freed space
+	}
+
+	for _, file := range in.droppableFallbackFiles() {
+		if in.fallbackUsage+size <= budget {
+			break
+		}
+		for dir, current := range in.fallbackFiles {
+			if filepath.Clean(current.path) == filepath.Clean(file.path) {
+				current.close()
+				delete(in.fallbackFiles, dir)
+			}
+		}
+		entries := countFallbackEntries(file.path)
+		if err := os.Remove(file.path); err != nil {
+			continue
+		}
+		in.fallbackUsage -= file.size
+		in.dropFallbackEntries(entries, "Dropped the oldest fallback file to stay within the budget", file.path)
+	}
+
+	if in.fallbackUsage+size > budget {
+		in.dropFallbackEntries(1, "Refused a fallback entry to stay within the budget", "")
+		return ErrFallbackFull
+	}
+	in.fallbackUsage += size
+	return nil
+}
+
+// measureFallbackUsage returns the size of the files in the fallback
+// directory and its subdirectories
+func (in *Instance) measureFallbackUsage() int64 {
+	var usage int64
+	_ = filepath.WalkDir(in.currentFallbackPath(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			usage += info.Size()
+		}
+		return nil
+	})
+	return usage
+}
+
+// droppableFallbackFiles returns the fallback files of this instance, in
+// the fallback directory and its subdirectories, oldest first
+func (in *Instance) droppableFallbackFiles() []fallbackFile {
+	type candidate struct {
+		fallbackFile
+		modTime time.Time
+	}
+	var candidates []candidate
+	_ = filepath.WalkDir(in.currentFallbackPath(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isFallbackFileName(d.Name()) || !in.ownsFallbackFile(d.Name()) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			candidates = append(candidates, candidate{fallbackFile{path: path, size: info.Size()}, info.ModTime()})
+		}
+		return nil
+	})
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].modTime.Before(candidates[j].modTime) })
+
+	files := make([]fallbackFile, len(candidates))
+	for i, c := range candidates {
+		files[i] = c.fallbackFile
+	}
+	return files
+}
+
+// countFallbackEntries counts the entries of a fallback file, 1 at least
+// so that a dropped file is never reported as empty
+func countFallbackEntries(path string) int {
+	f, err := openLogFile(path)
+	if err != nil {
+		return 1
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			count++
+		}
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// dropFallbackEntries counts dropped entries and warns about them, at most
+// once every fallbackFullWarnInterval. It is called with fallbackFilesMu held.
+func (in *Instance) dropFallbackEntries(count int, message, file string) {
+	fallbackDroppedTotal.Add(uint64(count))
+	in.fallbackFullSince += uint64(count)
+	EmitEvent(Event{Type: EventLogDropped, Count: count, Reason: "fallback_full", File: file})
+
+	if time.Since(in.fallbackFullWarned) < fallbackFullWarnInterval {
+		return
+	}
+	in.logger.Warn(message,
+		zlog.Int64("max_bytes", maxFallbackBytes.Load()),
+		zlog.Uint64("dropped", in.fallbackFullSince),
+		zlog.Uint64("dropped_total", fallbackDroppedTotal.Load()))
+	in.fallbackFullWarned, in.fallbackFullSince = time.Now(), 0
+}
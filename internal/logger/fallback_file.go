@@ -0,0 +1,196 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultMaxFallbackFileBytes is the size after which a fallback file is rotated
+const defaultMaxFallbackFileBytes = 16 << 20
+
+var maxFallbackFileBytes atomic.Int64 // Size after which the current fallback file is rotated
+
+// fallbackFile is the file fallback entries of a directory are appended to.
+// It stays open and locked between entries, and is replaced once full, when the
+// instance or the compression changes, and when recovery takes it over.
+type fallbackFile struct {
+	path string
+	file *os.File
+	size int64 // Bytes in the file, as written to disk
+}
+
+var fallbackFileSeq atomic.Int64 // Sequence number of the last fallback file opened by the process
+
+// loadFallbackFileConfig reads APPLG_MAX_FALLBACK_FILE_BYTES and
+// APPLG_MAX_FALLBACK_BYTES
+func loadFallbackFileConfig() {
+	SetMaxFallbackFileBytes(int64(getEnvAsInt("APPLG_MAX_FALLBACK_FILE_BYTES", defaultMaxFallbackFileBytes)))
+	loadFallbackBudgetConfig()
+}
+
+// SetMaxFallbackFileBytes rotates a fallback file once appending an entry
+// would take it past maxBytes, so that recovery reads files of a bounded
+// size. An entry larger than maxBytes gets a file of its own. 0 or less
+// restores the default of 16 MiB.
+func SetMaxFallbackFileBytes(maxBytes int64) {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxFallbackFileBytes
+	}
+	maxFallbackFileBytes.Store(maxBytes)
+}
+
+// MaxFallbackFileBytes returns the size after which a fallback file is rotated
+func MaxFallbackFileBytes() int64 {
+	return maxFallbackFileBytes.Load()
+}
+
+// appendFallbackFile appends record to the current fallback file of dir,
+// opening a new one named after prefix when there is none or it cannot take
+// the record, and returns the path written to. The record is refused with
+// ErrFallbackFull when it does not fit the fallback disk budget.
+func (in *Instance) appendFallbackFile(dir, prefix string, compressed bool, record []byte) (string, error) {
+	in.fallbackFilesMu.Lock()
+	defer in.fallbackFilesMu.Unlock()
+	if err := in.reserveFallbackBytes(int64(len(record))); err != nil {
+		return "", err
+	}
+
+	dir = filepath.Clean(dir)
+	current := in.fallbackFiles[dir]
+	if current != nil && !current.accepts(prefix, compressed, int64(len(record))) {
+		current.close()
+		current = nil
+	}
+	if current == nil {
+		opened, err := openFallbackFile(dir, prefix, compressed)
+		if err != nil {
+			delete(in.fallbackFiles, dir)
+			return opened.path, err
+		}
+		current = opened
+		in.fallbackFiles[dir] = current
+	}
+
+	n, err := current.file.Write(record)
+	current.size += int64(n)
+	if err != nil {
+		current.close() // Reopened by the next entry
+		delete(in.fallbackFiles, dir)
+	}
+	return current.path, err
+}
+
+// openFallbackFile creates a fallback file in dir named after prefix and
+// locks it while it is appended to, so that the recovery of another process
+// or instance sharing the prefix, e.g. the empty instance ID, leaves it
+// alone. A file recovery took between its creation and its lock is
+// replaced by a new one.
+func openFallbackFile(dir, prefix string, compressed bool) (*fallbackFile, error) {
+	for attempt := 0; ; attempt++ {
+		name := fallbackFileName(prefix, Now(), int(fallbackFileSeq.Add(1)))
+		if compressed {
+			name += fallbackGzipSuffix
+		}
+		path := filepath.Join(dir, name)
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return &fallbackFile{path: path}, err
+		}
+		if lockedFileAt(file, path) {
+			return &fallbackFile{path: path, file: file}, nil
+		}
+		_ = file.Close()
+		if attempt == 2 {
+			return &fallbackFile{path: path}, errFallbackFileLocked
+		}
+	}
+}
+
+// errFallbackFileLocked is returned when no new fallback file could be locked
+var errFallbackFileLocked = errors.New("fallback file is locked by another process")
+
+// lockedFileAt locks f, opened from path, reporting whether the lock was
+// taken and path still names f: a file removed or replaced before it was
+// locked was recovered already
+func lockedFileAt(f *os.File, path string) bool {
+	if !tryLockFile(f) {
+		return false
+	}
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	return err == nil && os.SameFile(opened, current)
+}
+
+// accepts reports whether the next record of size bytes, written for
+// prefix, can be appended to f. A file removed meanwhile, by recovery of
+// another process or by hand, is replaced rather than written unlinked.
+func (f *fallbackFile) accepts(prefix string, compressed bool, size int64) bool {
+	if !strings.HasPrefix(filepath.Base(f.path), prefix) || strings.HasSuffix(f.path, fallbackGzipSuffix) != compressed {
+		return false
+	}
+	if f.size > 0 && f.size+size > maxFallbackFileBytes.Load() {
+		return false
+	}
+	_, err := os.Stat(f.path)
+	return err == nil
+}
+
+// close closes the file; it is left on disk for recovery
+func (f *fallbackFile) close() {
+	_ = f.file.Close()
+}
+
+// sealFallbackFile closes the current fallback file of dir, so that
+// recovery can drain and remove it while later entries go to a new one
+func (in *Instance) sealFallbackFile(dir string) {
+	in.fallbackFilesMu.Lock()
+	defer in.fallbackFilesMu.Unlock()
+	dir = filepath.Clean(dir)
+	if current := in.fallbackFiles[dir]; current != nil {
+		current.close()
+		delete(in.fallbackFiles, dir)
+	}
+}
+
+// CloseFallbackFiles closes the fallback files the default instance is
+// appending to; the next entry opens a new one
+func CloseFallbackFiles() {
+	Default().CloseFallbackFiles()
+}
+
+// CloseFallbackFiles closes the fallback files being appended to; the next
+// entry opens a new one
+func (in *Instance) CloseFallbackFiles() {
+	in.fallbackFilesMu.Lock()
+	defer in.fallbackFilesMu.Unlock()
+	for dir, current := range in.fallbackFiles {
+		current.close()
+		delete(in.fallbackFiles, dir)
+	}
+}
+
+// isActiveFallbackFile reports whether path is a fallback file being
+// appended to, which cleanup leaves alone whatever its modification time
+func (in *Instance) isActiveFallbackFile(path string) bool {
+	in.fallbackFilesMu.Lock()
+	defer in.fallbackFilesMu.Unlock()
+	current := in.fallbackFiles[filepath.Clean(filepath.Dir(path))]
+	return current != nil && filepath.Clean(current.path) == filepath.Clean(path)
+}
+
+// fallbackFileName builds fallback_<instance_id>_<pid>_<timestamp>_<seq>.log
+// from the instance prefix, so that processes sharing a logs directory never
+// write to the same file, and files a process opens within the same second
+// do not collide yet sort in order; compressed files add fallbackGzipSuffix
+func fallbackFileName(prefix string, t time.Time, seq int) string {
+	return prefix + strconv.Itoa(os.Getpid()) + "_" + t.Format("20060102150405") + "_" + fmt.Sprintf("%06d", seq) + ".log"
+}
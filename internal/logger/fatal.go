@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Behaviors of fatal entries once logged
+const (
+	FatalExit  = "exit"  // Exit the process with status 1 once the entry is pushed, like Zap
+	FatalPanic = "panic" // Deliver the entry, then panic on the caller's goroutine
+	FatalNone  = "none"  // Log the entry at fatal severity and return
+)
+
+// defaultFatalFlushTimeout bounds the delivery of the entries queued behind
+// a fatal entry before the process exits
+const defaultFatalFlushTimeout = 5 * time.Second
+
+var (
+	fatalMu           sync.Mutex
+	fatalMode         = FatalExit                // What happens once a fatal entry is logged
+	fatalExitCode     = 1                        // Status the process exits with in FatalExit mode
+	fatalFlushTimeout = defaultFatalFlushTimeout // Longest wait for the queue and sinks before exiting
+)
+
+// loadFatalConfig reads APPLG_FATAL_MODE, APPLG_FATAL_EXIT_CODE and
+// APPLG_FATAL_FLUSH_TIMEOUT (in seconds) from the environment
+func loadFatalConfig() {
+	SetFatalMode(os.Getenv("APPLG_FATAL_MODE"))
+	SetFatalExitCode(getEnvAsInt("APPLG_FATAL_EXIT_CODE", 1))
+	SetFatalFlushTimeout(time.Duration(getEnvAsInt("APPLG_FATAL_FLUSH_TIMEOUT", int(defaultFatalFlushTimeout/time.Second))) * time.Second)
+}
+
+// SetFatalMode selects what happens once a fatal entry is logged: FatalExit,
+// FatalPanic or FatalNone. Unknown modes select FatalExit.
+func SetFatalMode(mode string) {
+	if mode != FatalPanic && mode != FatalNone {
+		mode = FatalExit
+	}
+	fatalMu.Lock()
+	defer fatalMu.Unlock()
+	fatalMode = mode
+}
+
+// FatalMode returns what happens once a fatal entry is logged
+func FatalMode() string {
+	fatalMu.Lock()
+	defer fatalMu.Unlock()
+	return fatalMode
+}
+
+// SetFatalExitCode sets the status the process exits with in FatalExit
+// mode. Codes outside 1-255 select 1.
+func SetFatalExitCode(code int) {
+	if code < 1 || code > 255 {
+		code = 1
+	}
+	fatalMu.Lock()
+	defer fatalMu.Unlock()
+	fatalExitCode = code
+}
+
+// FatalExitCode returns the status the process exits with in FatalExit mode
+func FatalExitCode() int {
+	fatalMu.Lock()
+	defer fatalMu.Unlock()
+	return fatalExitCode
+}
+
+// SetFatalFlushTimeout sets how long a fatal entry waits in FatalExit mode
+// for the entries queued behind it and the sinks to be flushed before the
+// process exits. 0 exits once the fatal entry itself is delivered; negative
+// values select 0.
+func SetFatalFlushTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	fatalMu.Lock()
+	defer fatalMu.Unlock()
+	fatalFlushTimeout = timeout
+}
+
+// FatalFlushTimeout returns how long a fatal entry waits for the queue and the sinks before exiting
+func FatalFlushTimeout() time.Duration {
+	fatalMu.Lock()
+	defer fatalMu.Unlock()
+	return fatalFlushTimeout
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// tryLockFile takes an exclusive lock on f without waiting, reporting
+// whether it was taken. The lock is held until f is closed, by this or
+// another process: flock locks of different opens of a file conflict even
+// within a process.
+func tryLockFile(f *os.File) bool {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB) == nil
+}
+
+// lockFallbackPath takes the lock of the fallback file at path for
+// recovery, returning false while another process, or another instance,
+// appends to it or recovers it. unlock releases the lock, which is held
+// while the file is removed or rewritten.
+func lockFallbackPath(path string) (unlock func(), ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	if !lockedFileAt(f, path) {
+		_ = f.Close()
+		return nil, false
+	}
+	return func() { _ = f.Close() }, true
+}
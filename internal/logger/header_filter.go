@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	headerFilterMu sync.RWMutex
+	headerAllow    map[string]struct{} // Lowercase names of the only headers logged; nil logs every header
+	headerDeny     map[string]struct{} // Lowercase names of the headers never logged
+)
+
+// loadHeaderFilterConfig reads APPLG_HEADER_ALLOWLIST and APPLG_HEADER_DENYLIST,
+// comma-separated header names
+func loadHeaderFilterConfig() {
+	SetHeaderAllowlist(strings.Split(os.Getenv("APPLG_HEADER_ALLOWLIST"), ",")...)
+	SetHeaderDenylist(strings.Split(os.Getenv("APPLG_HEADER_DENYLIST"), ",")...)
+}
+
+// SetHeaderAllowlist makes LogRequest log only the named headers, matched
+// case-insensitively. Without names every header is logged.
+func SetHeaderAllowlist(names ...string) {
+	headerFilterMu.Lock()
+	defer headerFilterMu.Unlock()
+	headerAllow = headerNameSet(names)
+}
+
+// SetHeaderDenylist makes LogRequest leave out the named headers, matched
+// case-insensitively, even when they are allowlisted
+func SetHeaderDenylist(names ...string) {
+	headerFilterMu.Lock()
+	defer headerFilterMu.Unlock()
+	headerDeny = headerNameSet(names)
+}
+
+// HeaderAllowlist returns the only headers logged, lowercase and sorted, nil when every header is
+func HeaderAllowlist() []string {
+	headerFilterMu.RLock()
+	defer headerFilterMu.RUnlock()
+	return sortedNames(headerAllow)
+}
+
+// HeaderDenylist returns the headers never logged, lowercase and sorted
+func HeaderDenylist() []string {
+	headerFilterMu.RLock()
+	defer headerFilterMu.RUnlock()
+	return sortedNames(headerDeny)
+}
+
+// FilterHeaders returns the headers LogRequest logs: those of the allowlist
+// when one is set, less those of the denylist. headers is never modified,
+// and is returned as is when no list is set.
+func FilterHeaders(headers map[string][]string) map[string][]string {
+	headerFilterMu.RLock()
+	defer headerFilterMu.RUnlock()
+	if headerAllow == nil && headerDeny == nil {
+		return headers
+	}
+
+	kept := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		lower := strings.ToLower(name)
+		if _, ok := headerAllow[lower]; headerAllow != nil && !ok {
+			continue
+		}
+		if _, ok := headerDeny[lower]; ok {
+			continue
+		}
+		kept[name] = values
+	}
+	return kept
+}
+
+// headerNameSet returns the lowercase set of the non-empty names, nil without any
+func headerNameSet(names []string) map[string]struct{} {
+	var set map[string]struct{}
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			if set == nil {
+				set = map[string]struct{}{}
+			}
+			set[name] = struct{}{}
+		}
+	}
+	return set
+}
+
+// sortedNames returns the names of set, sorted, nil when it is empty
+func sortedNames(set map[string]struct{}) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var heartbeatInterval atomic.Int64 // Period of heartbeat entries, a time.Duration; 0 disables them
+
+// loadHeartbeatConfig reads APPLG_HEARTBEAT_INTERVAL (in seconds) from the environment
+func loadHeartbeatConfig() {
+	SetHeartbeatInterval(time.Duration(getEnvAsInt("APPLG_HEARTBEAT_INTERVAL", 0)) * time.Second)
+}
+
+// HeartbeatInterval returns the period of heartbeat entries, 0 when disabled
+func HeartbeatInterval() time.Duration {
+	return time.Duration(heartbeatInterval.Load())
+}
+
+// SetHeartbeatInterval records the period of heartbeat entries
+func SetHeartbeatInterval(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	heartbeatInterval.Store(int64(interval))
+}
+
+// RedisState probes the log destination of the default instance
+func RedisState() string {
+	return Default().RedisState()
+}
+
+// RedisState probes the log destination for heartbeats: "connected" or
+// "unavailable" for Redis, "sink" when a custom sink is set and "disabled"
+// without any client
+func (in *Instance) RedisState() string {
+	rdb, sink := in.redisClient(), in.currentSink()
+	switch {
+	case sink != nil:
+		return "sink"
+	case rdb == nil:
+		return "disabled"
+	}
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		in.observeRedisState("unavailable", err)
+		return "unavailable"
+	}
+	in.observeRedisState("connected", nil)
+	return "connected"
+}
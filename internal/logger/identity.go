@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+)
+
+// Identity names the service instance entries are logged for, and so the
+// keys they are pushed to
+type Identity struct {
+	ServiceName  string
+	InstanceID   string
+	FacilityID   string
+	InstanceType string
+}
+
+// loadIdentity reads SERVICE_NAME, INSTANCE_ID, FACILITY_ID and INSTANCE_TYPE from the environment
+func loadIdentity() Identity {
+	return Identity{
+		ServiceName:  os.Getenv("SERVICE_NAME"),
+		InstanceID:   os.Getenv("INSTANCE_ID"),
+		FacilityID:   os.Getenv("FACILITY_ID"),
+		InstanceType: os.Getenv("INSTANCE_TYPE"),
+	}
+}
+
+// configIdentity returns the identity fields of a configuration
+func configIdentity(cfg config.Config) Identity {
+	return Identity{
+		ServiceName:  cfg.ServiceName,
+		InstanceID:   cfg.InstanceID,
+		FacilityID:   cfg.FacilityID,
+		InstanceType: cfg.InstanceType,
+	}
+}
+
+// SetIdentity changes the identity of the default instance
+func SetIdentity(id Identity) {
+	Default().SetIdentity(id)
+}
+
+// CurrentIdentity returns the identity of the default instance
+func CurrentIdentity() Identity {
+	return Default().Identity()
+}
+
+// SetIdentity changes the identity stamped on entries logged from now on.
+// Entries already queued keep the identity they were logged with: their key
+// and priority target are built from the identity stored in each entry, and
+// fallback files written under an earlier instance ID are still recovered.
+// The identity is replaced as a whole, so readers never mix two identities.
+func (in *Instance) SetIdentity(id Identity) {
+	in.identity.Store(&id)
+}
+
+// Identity returns the identity stamped on new entries
+func (in *Instance) Identity() Identity {
+	if id := in.identity.Load(); id != nil {
+		return *id
+	}
+	return Identity{}
+}
+
+// identityOf returns the identity stored in a log payload
+func identityOf(logData map[string]interface{}) Identity {
+	id := Identity{}
+	id.ServiceName, _ = logData["service_name"].(string)
+	id.InstanceID, _ = logData["instance_id"].(string)
+	id.FacilityID, _ = logData["facility_id"].(string)
+	id.InstanceType, _ = logData["instance_type"].(string)
+	return id
+}
+
+// key returns the main list key of the identity
+func (id Identity) key() string {
+	return joinKey(KeyPrefix, identityKey(id.FacilityID, id.InstanceType, id.ServiceName, id.InstanceID))
+}
+
+// fallbackPrefix returns the fallback file prefix owned by the instance.
+// The instance ID is escaped, underscores included, so the prefix of one
+// instance can never match the files of another (e.g. "a" vs "a_b", or
+// "a_b" vs "a-b").
+func (id Identity) fallbackPrefix() string {
+	return "fallback_" + escapeFileComponent(id.InstanceID) + "_"
+}
+
+// claimFallbackPrefix records that the instance writes fallback files under prefix
+func (in *Instance) claimFallbackPrefix(prefix string) {
+	in.ownedPrefixesMu.Lock()
+	defer in.ownedPrefixesMu.Unlock()
+	in.ownedPrefixes[prefix] = struct{}{}
+}
+
+// ownsFallbackFile reports whether a fallback file was written by the
+// instance, under its current or an earlier instance ID
+func (in *Instance) ownsFallbackFile(name string) bool {
+	if strings.HasPrefix(name, in.fallbackFilePrefix()) {
+		return true
+	}
+	in.ownedPrefixesMu.Lock()
+	defer in.ownedPrefixesMu.Unlock()
+	for prefix := range in.ownedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+	"github.com/go-redis/redis/v8"
+)
+
+// Instance is the state of one logging client: its logger, Redis client or
+// sink, identity and fallback directory, the health of its connection and
+// its recovery and cleanup loops. Each logger owns one, so that clients for
+// different facilities or Redis servers run side by side in a process.
+// Settings such as batching, levels, redaction or the fallback budget, the
+// additional backends, the shed, sampled and lost totals, the events and the
+// syslog file remain shared by the process.
+type Instance struct {
+	cfg         config.Config // Resolved at initialization
+	logger      *zlog.Logger
+	clientMu    sync.RWMutex // Guards rdb and sink, see redisClient and currentSink
+	rdb         RedisClient
+	ownedClient *redis.Client // Client created at initialization, closed when replaced
+	sink        Sink          // Replaces the Redis list push when set
+	identity    atomic.Pointer[Identity]
+
+	fallbackPath atomic.Value // Fallback directory, a string; see currentFallbackPath
+
+	ownedPrefixesMu sync.Mutex
+	ownedPrefixes   map[string]struct{} // Fallback file prefixes this instance has written under
+
+	// Connection health, see startReconnect
+	pushFailures  atomic.Int32 // Consecutive pushes to Redis that failed as unavailable
+	redisDown     atomic.Bool  // Set while the client waits for Redis to reconnect
+	redisState    atomic.Value // Last observed Redis state, a string
+	reconnectMu   sync.Mutex
+	reconnectStop chan struct{} // Closed to stop the running reconnection loop, nil when none runs
+
+	// Fallback files, see appendFallbackFile, and their budget
+	fallbackFilesMu    sync.Mutex
+	fallbackFiles      map[string]*fallbackFile // Current file of each fallback directory
+	fallbackUsage      int64                    // Bytes in the fallback directory, as last measured plus what was written since
+	fallbackUsageEpoch int64                    // Value of fallbackBudgetEpoch when fallbackUsage was measured, 0 if never
+	fallbackFullWarned time.Time                // Last warning about the budget
+	fallbackFullSince  uint64                   // Entries dropped since that warning
+
+	recoveryPassMu sync.Mutex // Serializes recovery passes
+
+	counters counters // Delivery counters, see Counters
+
+	backgroundMu   sync.Mutex
+	backgroundStop chan struct{}  // Closed by StopBackground, then replaced
+	backgroundWG   sync.WaitGroup // Loops started since the last StopBackground
+
+	initErr error // Failures of the initialization, see InitApplogs
+}
+
+// defaultInstance is the instance the package functions act on: the one
+// initialized last
+var defaultInstance atomic.Pointer[Instance]
+
+// newInstance returns an instance without a logger or client
+func newInstance() *Instance {
+	return &Instance{
+		ownedPrefixes:  map[string]struct{}{},
+		fallbackFiles:  map[string]*fallbackFile{},
+		backgroundStop: make(chan struct{}),
+	}
+}
+
+// NewInstance initializes an instance from cfg, the fields ResolveConfig
+// reads taking precedence over the environment, and starts its recovery and
+// cleanup loops. The process-wide settings are read from the environment
+// by the first instance only, see ReloadSettings. The instance becomes the
+// default one; instances initialized before keep running until stopped.
+// The error is that of InitApplogs: the instance is returned all the same,
+// running without what failed.
+func NewInstance(cfg config.Config) (*Instance, error) {
+	in := newInstance()
+	in.initErr = in.init(cfg, true)
+	return in, in.initErr
+}
+
+// NewInstanceWithSink initializes an instance like NewInstance, logging
+// through s from the start: no Redis client is created, and SetSink(nil)
+// leaves entries going to fallback
+func NewInstanceWithSink(cfg config.Config, s Sink) (*Instance, error) {
+	in := newInstance()
+	in.sink = s
+	in.initErr = in.init(cfg, true)
+	return in, in.initErr
+}
+
+// NewTestInstance initializes an instance like NewInstance without starting
+// the recovery and cleanup loops, so that tests stay hermetic
+func NewTestInstance() (*Instance, error) {
+	in := newInstance()
+	in.initErr = in.init(config.Config{}, false)
+	return in, in.initErr
+}
+
+// NewTestInstanceWithSink initializes a test instance logging through s, as
+// NewInstanceWithSink does
+func NewTestInstanceWithSink(s Sink) (*Instance, error) {
+	in := newInstance()
+	in.sink = s
+	in.initErr = in.init(config.Config{}, false)
+	return in, in.initErr
+}
+
+// Default returns the instance the package functions act on, initializing
+// one from the environment if there is none yet
+func Default() *Instance {
+	if in := defaultInstance.Load(); in != nil {
+		return in
+	}
+	_ = InitApplogs() // The failures are logged, the instance logging as far as it can
+	return defaultInstance.Load()
+}
+
+// defaultLogger returns the logger of the default instance, for the
+// process-wide settings to report invalid values with. Before the first
+// instance has its logger, e.g. while its configuration is resolved, a
+// console logger is returned.
+func defaultLogger() *zlog.Logger {
+	if in := defaultInstance.Load(); in != nil && in.logger != nil {
+		return in.logger
+	}
+	return zlog.New(io.Discard, os.Stdout, zlog.NormalizeEncoder(os.Getenv("APPLG_CONSOLE_ENCODER")))
+}
+
+// replaceDefault stops the default instance, for InitApplogs to replace it
+// as it reinitialized the package state before instances
+func replaceDefault() {
+	if in := defaultInstance.Load(); in != nil {
+		in.Close()
+	}
+}
+
+// Logger returns the logger writing to the console and the syslog file
+func (in *Instance) Logger() *zlog.Logger {
+	return in.logger
+}
+
+// InitError returns the failures of the initialization, nil if there were none
+func (in *Instance) InitError() error {
+	return in.initErr
+}
+
+// Stop stops the recovery and cleanup loops and a reconnection in progress,
+// and closes the fallback files being appended to. The instance can still
+// deliver entries, e.g. those logged during a shutdown.
+func (in *Instance) Stop() {
+	in.StopBackground()
+	in.CloseFallbackFiles()
+}
+
+// Close stops the instance and closes the Redis client it created, for an
+// instance that is no longer used
+func (in *Instance) Close() {
+	in.Stop()
+	in.closeOwnedClient()
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// KeyPrefix starts every Redis key written by the client
+const KeyPrefix = "applogs"
+
+var keyDelimiter atomic.Value // Separator between the segments of Redis keys, a string; see KeyDelimiter
+
+// loadKeyConfig reads APPLG_KEY_DELIMITER from the environment
+func loadKeyConfig() {
+	SetKeyDelimiter(os.Getenv("APPLG_KEY_DELIMITER"))
+}
+
+// KeyDelimiter returns the separator between the segments of Redis keys
+func KeyDelimiter() string {
+	if delimiter, _ := keyDelimiter.Load().(string); delimiter != "" {
+		return delimiter
+	}
+	return ":"
+}
+
+// SetKeyDelimiter sets the separator between the segments of Redis keys.
+// It cannot contain "%", which escapes it in identity fields; an empty or
+// invalid delimiter selects ":".
+func SetKeyDelimiter(delimiter string) {
+	if delimiter == "" || strings.Contains(delimiter, "%") {
+		delimiter = ":"
+	}
+	keyDelimiter.Store(delimiter)
+}
+
+// joinKey joins key segments with the delimiter
+func joinKey(segments ...string) string {
+	return strings.Join(segments, KeyDelimiter())
+}
+
+// identityKey returns the facility:type:service:instance part of a key, with
+// each field escaped so that it always spans exactly one segment
+func identityKey(facility, instanceType, service, instance string) string {
+	return joinKey(escapeKeySegment(facility), escapeKeySegment(instanceType),
+		escapeKeySegment(service), escapeKeySegment(instance))
+}
+
+// escapeKeySegment percent-encodes "%" and the delimiter in an identity
+// field, e.g. "vendor:api" becomes "vendor%3Aapi". Consumers splitting a key
+// on the delimiter get the original field back with url.PathUnescape.
+func escapeKeySegment(value string) string {
+	delimiter := KeyDelimiter()
+	if !strings.Contains(value, delimiter) && !strings.Contains(value, "%") {
+		return value
+	}
+
+	var escaped strings.Builder
+	for i := 0; i < len(value); {
+		switch {
+		case value[i] == '%':
+			escaped.WriteString("%25")
+			i++
+		case strings.HasPrefix(value[i:], delimiter):
+			for j := 0; j < len(delimiter); j++ {
+				fmt.Fprintf(&escaped, "%%%02X", delimiter[j])
+			}
+			i += len(delimiter)
+		default:
+			escaped.WriteByte(value[i])
+			i++
+		}
+	}
+	return escaped.String()
+}
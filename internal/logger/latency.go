@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// pushLatencyBuckets are the upper bounds, in seconds, of the push latency histogram
+var pushLatencyBuckets = [...]float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// LatencyHistogram is a snapshot of the durations of the pushes to Redis or
+// the sink, retries counted one by one
+type LatencyHistogram struct {
+	Count   uint64
+	Sum     time.Duration
+	Buckets map[float64]uint64 // Pushes at most as long as each bound, in seconds, cumulative
+}
+
+// Push latency histogram, safe to update and read while logging
+var pushLatency struct {
+	counts [len(pushLatencyBuckets)]atomic.Uint64 // Per bucket, not cumulative
+	count  atomic.Uint64
+	sum    atomic.Int64 // Nanoseconds
+}
+
+// observePushLatency records a push that began at start
+func observePushLatency(start time.Time) {
+	elapsed := time.Since(start)
+	seconds := elapsed.Seconds()
+	for i, bound := range pushLatencyBuckets {
+		if seconds <= bound {
+			pushLatency.counts[i].Add(1)
+			break
+		}
+	}
+	pushLatency.sum.Add(int64(elapsed))
+	pushLatency.count.Add(1)
+}
+
+// PushLatency returns the histogram of push durations. The fields are read
+// one by one, so a snapshot taken during a push may be off by that push.
+func PushLatency() LatencyHistogram {
+	h := LatencyHistogram{
+		Count:   pushLatency.count.Load(),
+		Sum:     time.Duration(pushLatency.sum.Load()),
+		Buckets: make(map[float64]uint64, len(pushLatencyBuckets)),
+	}
+	var cumulative uint64
+	for i, bound := range pushLatencyBuckets {
+		cumulative += pushLatency.counts[i].Load()
+		h.Buckets[bound] = cumulative
+	}
+	return h
+}
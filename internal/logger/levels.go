@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// levelRanks orders the supported levels by severity
+var levelRanks = map[string]int{
+	"debug": 0,
+	"info":  1,
+	"warn":  2,
+	"error": 3,
+	"fatal": 4,
+}
+
+// LevelRank returns the severity rank of level and whether the level is known
+func LevelRank(level string) (int, bool) {
+	rank, ok := levelRanks[level]
+	return rank, ok
+}
+
+// LevelSpec maps components to their minimum level. The "*" entry applies
+// to components without a more specific entry.
+type LevelSpec map[string]string
+
+// ParseLevelSpec parses a spec such as "auth=debug,db=warn,*=info"
+func ParseLevelSpec(spec string) (LevelSpec, error) {
+	parsed := LevelSpec{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component, level, found := strings.Cut(part, "=")
+		component = strings.TrimSpace(component)
+		level = strings.ToLower(strings.TrimSpace(level))
+		if !found || component == "" {
+			return nil, fmt.Errorf("invalid log spec entry %q: expected component=level", part)
+		}
+		if _, ok := levelRanks[level]; !ok {
+			return nil, fmt.Errorf("invalid log spec entry %q: unknown level %q", part, level)
+		}
+		parsed[component] = level
+	}
+	return parsed, nil
+}
+
+// MinLevel returns the minimum level of a component. Nested components such
+// as "auth.jwt" fall back to their parent ("auth"), then to "*". Without a
+// matching entry every level is enabled.
+func (s LevelSpec) MinLevel(component string) string {
+	for name := component; name != ""; {
+		if level, ok := s[name]; ok {
+			return level
+		}
+		idx := strings.LastIndexByte(name, '.')
+		if idx == -1 {
+			break
+		}
+		name = name[:idx]
+	}
+	if level, ok := s["*"]; ok {
+		return level
+	}
+	return "debug"
+}
+
+// Enabled reports whether a log of level from component passes the spec
+func (s LevelSpec) Enabled(component, level string) bool {
+	rank, ok := levelRanks[level]
+	if !ok {
+		return true
+	}
+	return rank >= levelRanks[s.MinLevel(component)]
+}
+
+var (
+	levelSpec    atomic.Pointer[LevelSpec]
+	levelSpecRaw atomic.Value // Spec string as configured, for diagnostics
+	minLevelRank atomic.Int32 // Rank of the minimum level of every component, see SetLevel
+)
+
+// SetLevel sets the minimum level of the logger, applying to every component
+// on top of the level spec, and to the console and syslog file output
+func SetLevel(level string) error {
+	level = strings.ToLower(strings.TrimSpace(level))
+	rank, ok := levelRanks[level]
+	if !ok {
+		return fmt.Errorf("unknown level %q", level)
+	}
+	minLevelRank.Store(int32(rank))
+	zlog.SetLevel(level)
+	return nil
+}
+
+// Level returns the minimum level of the logger
+func Level() string {
+	rank := int(minLevelRank.Load())
+	for level, r := range levelRanks {
+		if r == rank {
+			return level
+		}
+	}
+	return "debug"
+}
+
+// loadLevelConfig reads the minimum level from LOG_LEVEL, every level
+// passing when it is unset or invalid
+func loadLevelConfig() {
+	level := os.Getenv("LOG_LEVEL")
+	if level == "" {
+		level = "debug"
+	}
+	if err := SetLevel(level); err != nil {
+		defaultLogger().Warn("Invalid LOG_LEVEL. Using debug.", zlog.Error(err))
+		_ = SetLevel("debug")
+	}
+}
+
+// loadLevelSpec reads the per-component level spec from APPLG_LOG_SPEC
+func loadLevelSpec() {
+	if err := SetLevelSpec(os.Getenv("APPLG_LOG_SPEC")); err != nil {
+		defaultLogger().Warn("Invalid APPLG_LOG_SPEC. Ignoring it.", zlog.Error(err))
+		_ = SetLevelSpec("")
+	}
+}
+
+// SetLevelSpec replaces the per-component level spec; an empty spec enables every level
+func SetLevelSpec(spec string) error {
+	parsed, err := ParseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+	levelSpec.Store(&parsed)
+	levelSpecRaw.Store(spec)
+	return nil
+}
+
+// ComponentLevelEnabled reports whether a log of level from component passes
+// the minimum level and the configured spec
+func ComponentLevelEnabled(component, level string) bool {
+	if rank, ok := levelRanks[level]; ok && int32(rank) < minLevelRank.Load() {
+		return false
+	}
+	spec := levelSpec.Load()
+	if spec == nil {
+		return true
+	}
+	return spec.Enabled(component, level)
+}
+
+// levelSpecString returns the configured spec string
+func levelSpecString() string {
+	spec, _ := levelSpecRaw.Load().(string)
+	return spec
+}
@@ -2,230 +2,584 @@ package logger
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-	"fmt"
 
+	"github.com/bashx3r0/scala-applogs-client/config"
 	internalRedis "github.com/bashx3r0/scala-applogs-client/internal/redis"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
 	"github.com/go-redis/redis/v8"
 	"github.com/joho/godotenv"
-	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
+// RedisClient is the Redis command surface used by the client. It is kept to
+// what list mode, attachments and PurgeServiceKeys need; RedisCommands lists
+// the exact commands issued for the current configuration, including those
+// sent through a pipeline.
 type RedisClient interface {
-	LPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
-	Get(ctx context.Context, key string) *redis.StringCmd
-	Ping(ctx context.Context) *redis.StatusCmd
-	Del(ctx context.Context, keys ...string) *redis.IntCmd
-	Pipeline() redis.Pipeliner
+	Ping(ctx context.Context) *redis.StatusCmd                                                         // PING
+	LPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd                        // LPUSH
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd                    // PUBLISH
+	Pipeline() redis.Pipeliner                                                                         // Batches LPUSH, LTRIM, EXPIRE, SADD and SISMEMBER
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd                 // SCAN, by PurgeServiceKeys only
+	Del(ctx context.Context, keys ...string) *redis.IntCmd                                             // DEL, by PurgeServiceKeys only
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd // SET, by LogWithAttachment only
 }
 
 var (
-	logger              *zap.Logger
-	rdb                 RedisClient
 	ctx                 = context.Background()
-	redisAddr           string
-	serviceName         string
-	instanceID          string
-	facilityID          string
-	instanceType        string
-	fallbackPath        string
-	syslogsPath         string
-	fallbackResyncTime  int // Time (in seconds) to attempt fallback log resend
-	syslogKeepTime      int // Time (in hours) to keep syslog records
+	syslogsPath         = filepath.Join("logs", "syslogs")
+	priorityQueue       atomic.Bool // Whether error/fatal logs use a dedicated queue drained first
 	ErrRedisUnavailable = errors.New("redis is unavailable")
+
+	fallbackPathOverride atomic.Value // Fallback path set by the package SetFallbackPath, a string
+	settingsOnce         sync.Once    // Loads the process-wide settings at the first initialization
 )
 
 // Ensure logs directory exists
-func ensureLogDirectory() {
-	if _, err := os.Stat("logs"); os.IsNotExist(err) {
-		_ = os.MkdirAll("logs", 0755)
+func (in *Instance) ensureLogDirectory() error {
+	var errs []error
+	for _, dir := range []string{"logs", in.currentFallbackPath(), syslogsPath} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			errs = append(errs, fmt.Errorf("create log directory %s: %w", dir, err))
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	// Ensure fallback directory exists
-	if fallbackPath == "" {
-		fallbackPath = filepath.Join("logs", "fallback")
-	}
-	if _, err := os.Stat(fallbackPath); os.IsNotExist(err) {
-		_ = os.MkdirAll(fallbackPath, 0755)
-	}
+// Initialize logger and Redis client, replacing the default instance. The
+// error reports a log directory that could not be created, a syslog file
+// that could not be opened, an invalid Redis address or TLS setting and,
+// with APPLG_REQUIRE_REDIS, a Redis server that did not answer;
+// the instance is initialized nonetheless, logging to the console and to
+// fallback as far as it can.
+func InitApplogs() error {
+	replaceDefault()
+	_, err := NewInstance(config.Config{})
+	return err
+}
 
-	// Ensure syslogs directory exists
-	syslogsPath = filepath.Join("logs", "syslogs")
-	if _, err := os.Stat(syslogsPath); os.IsNotExist(err) {
-		_ = os.MkdirAll(syslogsPath, 0755)
+// MustInit initializes like InitApplogs, panicking with its error, for
+// programs that must not start without their log directories, syslog file
+// and a valid Redis address
+func MustInit() {
+	if err := InitApplogs(); err != nil {
+		panic(err)
 	}
 }
 
-// Initialize logger and Redis client
-func InitApplogs() {
-
-	fmt.Println("Initializing applogs...")
+// InitApplogsForTesting initializes like InitApplogs without starting the
+// periodic recovery and cleanup loops, so that tests stay hermetic
+func InitApplogsForTesting() error {
+	replaceDefault()
+	_, err := NewTestInstance()
+	return err
+}
 
-	fmt.Println("Loading environment variables...")
+// InitApplogsWithConfig initializes like InitApplogs, the fields of cfg that
+// ResolveConfig reads taking precedence over the environment
+func InitApplogsWithConfig(cfg config.Config) error {
+	replaceDefault()
+	_, err := NewInstance(cfg)
+	return err
+}
 
+// ResolveConfig fills the identity, Redis address, credentials and TLS
+// settings, fallback path, resync and keep times, cleanup settings, console
+// encoder, startup wait for Redis and Redis requirement of cfg that are zero
+// from the environment, or from the defaults when unset there. The level is
+// only normalized: LOG_LEVEL is process-wide, read with the other settings.
+// Other fields are returned unchanged.
+func ResolveConfig(cfg config.Config) config.Config {
 	_ = godotenv.Load(".env")
-	ensureLogDirectory()
 
-	serviceName = os.Getenv("SERVICE_NAME")
-	instanceID = os.Getenv("INSTANCE_ID")
-	facilityID = os.Getenv("FACILITY_ID")
-	instanceType = os.Getenv("INSTANCE_TYPE")
-	redisAddr = os.Getenv("APPLG_CORE_REDIS")
-
-	fmt.Println(serviceName, instanceID, facilityID, instanceType, redisAddr)
+	id := configIdentity(cfg)
+	env := loadIdentity()
+	setIfEmpty(&id.ServiceName, env.ServiceName)
+	setIfEmpty(&id.InstanceID, env.InstanceID)
+	setIfEmpty(&id.FacilityID, env.FacilityID)
+	setIfEmpty(&id.InstanceType, env.InstanceType)
+	cfg.ServiceName, cfg.InstanceID, cfg.FacilityID, cfg.InstanceType = id.ServiceName, id.InstanceID, id.FacilityID, id.InstanceType
+
+	setIfEmpty(&cfg.RedisAddr, os.Getenv("APPLG_CORE_REDIS"))
+	setIfEmpty(&cfg.RedisUsername, os.Getenv("APPLG_CORE_REDIS_USERNAME"))
+	setIfEmpty(&cfg.RedisPassword, os.Getenv("APPLG_CORE_REDIS_PASSWORD"))
+	setIfEmpty(&cfg.RedisCAFile, os.Getenv("APPLG_CORE_REDIS_CA_FILE"))
+	cfg.RedisTLS = cfg.RedisTLS || getEnvAsBool("APPLG_CORE_REDIS_TLS", false)
+	cfg.RedisTLSInsecure = cfg.RedisTLSInsecure || getEnvAsBool("APPLG_CORE_REDIS_TLS_INSECURE", false)
+	override, _ := fallbackPathOverride.Load().(string)
+	setIfEmpty(&cfg.FallbackPath, override)
+	setIfEmpty(&cfg.FallbackPath, filepath.Join("logs", "fallback"))
+	if cfg.FallbackResyncTime == 0 {
+		// Load fallback resync time (default: 30 seconds)
+		cfg.FallbackResyncTime = time.Duration(getEnvAsInt("FALLBACK_RESYNC_TIME", 30)) * time.Second
+	}
+	if cfg.SyslogKeepTime == 0 {
+		// Load syslog keep time (default: 72 hours)
+		cfg.SyslogKeepTime = time.Duration(getEnvAsInt("SYSLOG_KEEP_TIME", 72)) * time.Hour
+	}
+	if cfg.CleanupInterval == 0 {
+		// Load the cleanup interval (default: 24 hours)
+		cfg.CleanupInterval = time.Duration(getEnvAsInt("APPLG_CLEANUP_INTERVAL", 24*60*60)) * time.Second
+	}
+	if cfg.MaxSyslogFiles == 0 {
+		// Load the syslog file count limit (default: none)
+		cfg.MaxSyslogFiles = getEnvAsInt("APPLG_MAX_SYSLOG_FILES", 0)
+	}
+	setIfEmpty(&cfg.ConsoleEncoder, os.Getenv("APPLG_CONSOLE_ENCODER"))
+	cfg.ConsoleEncoder = zlog.NormalizeEncoder(cfg.ConsoleEncoder)
+	cfg.Level = strings.ToLower(strings.TrimSpace(cfg.Level))
+	if cfg.WaitForRedis == 0 {
+		// Load the startup wait for Redis (default: none, a single ping)
+		cfg.WaitForRedis = time.Duration(getEnvAsInt("APPLG_WAIT_FOR_REDIS", 0)) * time.Second
+	}
+	cfg.RequireRedis = cfg.RequireRedis || getEnvAsBool("APPLG_REQUIRE_REDIS", false)
+	return cfg
+}
 
-	// Load fallback resync time (default: 30 seconds)
-	fallbackResyncTime = getEnvAsInt("FALLBACK_RESYNC_TIME", 30)
+// setIfEmpty sets *field to value when it is empty
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
 
-	// Load syslog keep time (default: 72 hours)
-	syslogKeepTime = getEnvAsInt("SYSLOG_KEEP_TIME", 72)
+// init loads the configuration, optionally starting the background loops,
+// and makes the instance the default one. It returns the failures InitApplogs
+// reports, once it has carried on without what failed.
+func (in *Instance) init(cfg config.Config, background bool) error {
 
-	logFile := generateLogFilePath()
-	writeSyncer := getLogWriter(logFile)
+	fmt.Println("Initializing applogs...")
 
-	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	fmt.Println("Loading environment variables...")
 
-	core := zapcore.NewTee(
-		zapcore.NewCore(encoder, writeSyncer, zapcore.DebugLevel),                // File logging
-		zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), zapcore.DebugLevel), // Console logging
-	)
+	cfg = ResolveConfig(cfg)
+	in.cfg = cfg
+	in.fallbackPath.Store(cfg.FallbackPath)
+	dirErr := in.ensureLogDirectory()
 
-	log := zap.New(core, zap.AddCaller())
-	logger = log
-	zap.ReplaceGlobals(logger) // Replace global logger
+	id := configIdentity(cfg)
+	in.SetIdentity(id)
 
-	logger.Info("Logger initialized successfully",
-		zap.Int("fallback_resync_time", fallbackResyncTime),
-		zap.Int("syslog_keep_time", syslogKeepTime))
+	fmt.Println(id.ServiceName, id.InstanceID, id.FacilityID, id.InstanceType, config.RedactRedisAddr(cfg.RedisAddr))
 
-	rdb = internalRedis.NewRedisClient(redisAddr)
+	settingsOnce.Do(loadSettings)
+	var levelErr error
+	if cfg.Level != "" {
+		levelErr = SetLevel(cfg.Level)
+	}
+	syslog, syslogErr := openSyslogWriter()
+	in.logger = zlog.New(syslog, os.Stdout, cfg.ConsoleEncoder)
+	defaultInstance.Store(in)
+	initErrs := []error{dirErr, syslogErr}
+	for _, err := range initErrs {
+		if err != nil {
+			in.logger.Error("Failed to prepare local log files", zlog.Error(err))
+		}
+	}
+	if levelErr != nil {
+		in.logger.Warn("Invalid level. Keeping the current one.", zlog.String("level", Level()), zlog.Error(levelErr))
+	}
 
-	if rdb != nil {
-		logger.Info("Checking Redis connection")
-		checkRedisConnection()
+	in.logger.Info("Logger initialized successfully",
+		zlog.Int("fallback_resync_time", int(cfg.FallbackResyncTime/time.Second)),
+		zlog.Int("syslog_keep_time", int(cfg.SyslogKeepTime/time.Hour)))
+
+	if in.currentSink() != nil {
+		in.logger.Info("Logging through a sink instead of Redis", zlog.String("sink", in.sinkName()))
+	} else if tlsConfig, err := in.redisTLSConfig(); err != nil {
+		in.logger.Error("Invalid Redis TLS configuration",
+			zlog.String("ca_file", cfg.RedisCAFile),
+			zlog.Error(err))
+		initErrs = append(initErrs, fmt.Errorf("invalid redis TLS configuration: %w", err))
+	} else if client, err := internalRedis.NewRedisClient(cfg.RedisAddr, cfg.RedisUsername, cfg.RedisPassword, tlsConfig); err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL itself would expose the password
+		}
+		in.logger.Error("Invalid Redis address",
+			zlog.String("address", config.RedactRedisAddr(cfg.RedisAddr)),
+			zlog.Error(err))
+		initErrs = append(initErrs, fmt.Errorf("invalid redis address %s: %w", config.RedactRedisAddr(cfg.RedisAddr), err))
 	} else {
-		logger.Error("Failed to initialize Redis client. Redis client is nil.")
+		in.ownedClient = client
+		in.setRedisClient(client)
 	}
 
-	// Start fallback recovery with dynamic interval
-	StartRecoveryProcess(time.Duration(fallbackResyncTime) * time.Second)
-
-	// Start periodic log cleanup
-	go func() {
-		for {
-			time.Sleep(24 * time.Hour) // Run once per day
-			cleanupOldLogs()
+	switch {
+	case in.currentSink() != nil:
+		// Nothing to connect to
+	case in.redisClient() != nil:
+		var redisErr error
+		if cfg.WaitForRedis > 0 {
+			redisErr = in.waitForRedisConnection()
+		} else {
+			in.logger.Info("Checking Redis connection")
+			redisErr = in.checkRedisConnection()
 		}
-	}()
+		if redisErr != nil && cfg.RequireRedis {
+			initErrs = append(initErrs, redisErr)
+		}
+	default:
+		in.logger.Error("Failed to initialize Redis client. Redis client is nil.")
+	}
+
+	if background {
+		// Start fallback recovery with dynamic interval
+		in.StartRecoveryProcess(cfg.FallbackResyncTime)
+
+		// Start periodic log cleanup
+		in.startCleanupProcess()
+	}
+	if err := errors.Join(initErrs...); err != nil {
+		return fmt.Errorf("applogs initialization: %w", err)
+	}
+	return nil
 }
 
-// Logger returns the logger instance
-func Logger() *zap.Logger {
-	if logger == nil {
-		InitApplogs()
+// loadSettings reads the process-wide settings from the environment
+func loadSettings() {
+	loadLevelConfig()
+	loadSyslogConfig()
+	priorityQueue.Store(getEnvAsBool("APPLG_PRIORITY_QUEUE", false))
+	SetHeaderFormat(os.Getenv("APPLG_HEADER_FORMAT"))
+	SetLogQueryParams(getEnvAsBool("APPLG_LOG_QUERY_PARAMS", false))
+	loadBatchConfig()
+	loadDeadlineConfig()
+	loadLevelSpec()
+	loadSerializeConfig()
+	loadSchemaConfig()
+	loadHeartbeatConfig()
+	loadRuntimeStatsConfig()
+	loadDedupeConfig()
+	loadSummaryConfig()
+	loadBackpressureConfig()
+	loadSheddingConfig()
+	loadSamplingConfig()
+	loadContextConfig()
+	loadTimerConfig()
+	loadKeyConfig()
+	loadSigningConfig()
+	loadCanonicalConfig()
+	loadTruncateConfig()
+	loadStopConfig()
+	loadOverflowConfig()
+	loadFatalConfig()
+	loadAttachmentConfig()
+	loadRedactConfig()
+	loadHeaderFilterConfig()
+	loadStackTraceConfig()
+	loadRecoveryRateConfig()
+	loadFallbackConfig()
+	loadFallbackFileConfig()
+	loadReconnectConfig()
+	loadBacklogConfig()
+	loadTrimConfig()
+	SetMarkRecovered(getEnvAsBool("APPLG_MARK_RECOVERED", false))
+}
+
+// ReloadSettings reads the process-wide settings from the environment
+// again, replacing those set at runtime. The first initialization reads
+// them; later ones leave them alone, so that creating a logger neither
+// resets the settings another one runs with nor races with its goroutines.
+func ReloadSettings() {
+	settingsOnce.Do(func() {})
+	loadSettings()
+}
+
+// redisTLSConfig returns the TLS settings of the Redis connection, nil when
+// it is not encrypted. The CA file and verification settings also apply to
+// a rediss:// address.
+func (in *Instance) redisTLSConfig() (*tls.Config, error) {
+	if !in.cfg.RedisTLS && !strings.HasPrefix(in.cfg.RedisAddr, "rediss://") {
+		return nil, nil
+	}
+	if in.cfg.RedisTLSInsecure {
+		in.logger.Warn("Redis TLS certificate verification is disabled")
 	}
-	return logger
+	return internalRedis.TLSConfig(in.cfg.RedisCAFile, in.cfg.RedisTLSInsecure)
 }
 
-// Check Redis connection and log status
-func checkRedisConnection() {
+// Logger returns the logger of the default instance
+func Logger() *zlog.Logger {
+	return Default().logger
+}
+
+// Check Redis connection and log status, returning ErrRedisUnavailable
+// wrapping the ping error when Redis does not answer
+func (in *Instance) checkRedisConnection() error {
+	rdb := in.redisClient()
 	if rdb == nil {
-		logger.Error("Redis client is nil. Skipping Redis connection check.")
-		return
+		in.logger.Error("Redis client is nil. Skipping Redis connection check.")
+		return errRedisNotSet
 	}
 
-	_, err := rdb.(*redis.Client).Ping(ctx).Result()
+	_, err := rdb.Ping(ctx).Result()
+	in.redisState.Store("")
 	if err != nil {
-		logger.Error("Failed to connect to Redis Database",
-			zap.String("address", redisAddr),
-			zap.Error(err))
-	} else {
-		logger.Info("Connected to Redis successfully",
-			zap.String("address", redisAddr))
+		in.observeRedisState("unavailable", err)
+		in.logger.Error("Failed to connect to Redis Database",
+			zlog.String("address", config.RedactRedisAddr(in.cfg.RedisAddr)),
+			zlog.Error(err))
+		in.startReconnect(err)
+		return fmt.Errorf("%w: %v", ErrRedisUnavailable, err)
 	}
+	in.observeRedisState("connected", nil)
+	in.logger.Info("Connected to Redis successfully",
+		zlog.String("address", config.RedactRedisAddr(in.cfg.RedisAddr)))
+	return nil
 }
 
-// General function to handle logging with fallback
-func LogToRedis(level, message string, fields map[string]interface{}) {
+// NewLogData builds the structured payload pushed to Redis for a single log entry
+func NewLogData(level, message string, fields map[string]interface{}) map[string]interface{} {
+	return NewLogDataAs(CurrentIdentity(), level, message, fields)
+}
+
+// NewLogDataAs builds the payload of an entry logged with the given identity,
+// its fields redacted as RedactFields does. The schema version is stamped
+// here rather than when serializing, so that an entry recovered from
+// fallback keeps the version it was written with.
+func NewLogDataAs(id Identity, level, message string, fields map[string]interface{}) map[string]interface{} {
 	logData := map[string]interface{}{
-		"timestamp":     time.Now().UTC(),
+		EntryIDField:    NewEntryID(),
+		"timestamp":     Now().UTC(),
 		"level":         level,
 		"message":       message,
-		"metadata":      fields,
-		"service_name":  serviceName,
-		"instance_id":   instanceID,
-		"facility_id":   facilityID,
-		"instance_type": instanceType,
+		"metadata":      NormalizeFields(RedactFields(fields)),
+		"service_name":  id.ServiceName,
+		"instance_id":   id.InstanceID,
+		"facility_id":   id.FacilityID,
+		"instance_type": id.InstanceType,
+	}
+	if schemaField.Load() {
+		logData[SchemaField] = SchemaVersion
 	}
+	return logData
+}
 
-	key := "applogs:" + facilityID + ":" + instanceType + ":" + serviceName + ":" + instanceID
+// NormalizeFields returns fields, or an empty map when fields is nil, so
+// that the metadata of an entry logged without fields is always an object
+// rather than null, in Redis, fallback and the console alike
+func NormalizeFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return map[string]interface{}{}
+	}
+	return fields
+}
 
-	// Marshal single log entry
-	data, err := json.Marshal(logData)
-	if err != nil {
-		logger.Error("Failed to marshal log data to JSON", zap.Error(err))
-		return
+// General function to handle logging with fallback
+func LogToRedis(level, message string, fields map[string]interface{}) {
+	Default().LogToRedis(level, message, fields)
+}
+
+// LogToRedis pushes one entry logged with the identity of the instance
+func (in *Instance) LogToRedis(level, message string, fields map[string]interface{}) {
+	in.LogBatchToRedis([]map[string]interface{}{NewLogDataAs(in.Identity(), level, message, fields)})
+}
+
+// ImportantField is set to true in the payload of entries retained in the
+// important list, a small stream kept apart from the trimmed firehose
+const ImportantField = "important"
+
+// ImportantKeySegment is appended to redisKey to name the important list
+const ImportantKeySegment = "important"
+
+// SummaryField is set to true in the payload of compact summary entries,
+// which are pushed to the summary list
+const SummaryField = "summary"
+
+// SummaryKeySegment is appended to redisKey to name the summary list
+const SummaryKeySegment = "summary"
+
+// defaultSummaryFields are copied into summary entries unless APPLG_SUMMARY_FIELDS is set
+var defaultSummaryFields = []string{"status_code", "duration_ms", "error"}
+
+var summaryFields atomic.Pointer[[]string] // Fields copied into summary entries, defaultSummaryFields until set
+
+// loadSummaryConfig reads APPLG_SUMMARY_FIELDS, a comma-separated field list
+func loadSummaryConfig() {
+	if value := os.Getenv("APPLG_SUMMARY_FIELDS"); value != "" {
+		SetSummaryFields(strings.Split(value, ",")...)
+	} else {
+		SetSummaryFields(defaultSummaryFields...)
 	}
+}
 
-	// Use LPUSH to append single log entry without overwriting
-	err = rdb.LPush(ctx, key, data).Err()
-	if err != nil {
-		if isRedisUnavailable(err) {
-			logger.Warn("Redis unavailable, saving to fallback", zap.Error(err))
-			logToFallback(logData)
-		} else {
-			logger.Error("Failed to push log to Redis", zap.Error(err))
+// SummaryFields returns the fields copied into summary entries
+func SummaryFields() []string {
+	if fields := summaryFields.Load(); fields != nil {
+		return *fields
+	}
+	return defaultSummaryFields
+}
+
+// SetSummaryFields selects the fields copied into summary entries
+func SetSummaryFields(fields ...string) {
+	selected := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			selected = append(selected, field)
 		}
 	}
+	summaryFields.Store(&selected)
+}
+
+// unavailableMessages are matched in errors that carry no type telling they
+// come from the network, e.g. when a proxy or wrapper flattened them to text
+var unavailableMessages = []string{
+	"connection refused",
+	"connection reset",
+	"i/o timeout",
+	"no route to host",
+	"no such host",
+	"broken pipe",
+	"use of closed network connection",
+	"connection pool timeout",
 }
 
-// Check if Redis is unavailable
+// isRedisUnavailable reports whether err means Redis could not be reached:
+// a network error such as a refused connection, a timeout, an unreachable
+// host or a failed DNS lookup, a connection closed mid-reply, or a closed
+// client. A reply from Redis, redis.Nil included, is never unavailability.
+// The message is only matched as a last resort.
 func isRedisUnavailable(err error) bool {
-	return errors.Is(err, ErrRedisUnavailable) || strings.Contains(err.Error(), "connection refused")
+	if errors.Is(err, ErrRedisUnavailable) || errors.Is(err, redis.ErrClosed) {
+		return true
+	}
+	if errors.Is(err, redis.Nil) {
+		return false
+	}
+	var netErr net.Error // *net.OpError, *net.DNSError and timeouts
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, unavailable := range unavailableMessages {
+		if strings.Contains(msg, unavailable) {
+			return true
+		}
+	}
+	return false
 }
 
 // Fallback mechanism to store logs locally if Redis fails
-func logToFallback(logData map[string]interface{}) {
-	filename := filepath.Join(fallbackPath, "fallback_"+time.Now().Format("20060102150405")+".log")
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+func (in *Instance) logToFallback(logData map[string]interface{}) {
+	data, _ := marshalLogData(logData)
+	if err := in.writeFallback(data); err != nil && !errors.Is(err, ErrFallbackFull) {
+		lostLog(logData, err)
+	}
+}
+
+// writeFallback appends one serialized entry to this instance's fallback file
+func (in *Instance) writeFallback(data []byte) error {
+	return in.writeFallbackTo(in.currentFallbackPath(), data)
+}
+
+// writeFallbackTo appends one serialized entry to this instance's fallback
+// file in dir, or to the fallback writer when one is set
+func (in *Instance) writeFallbackTo(dir string, data []byte) error {
+	if written, err := in.writeFallbackLine(data); written {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		in.logger.Error("Failed to create fallback directory", zlog.Error(err))
+		return err
+	}
+	prefix := in.fallbackFilePrefix()
+	in.claimFallbackPrefix(prefix) // Still recovered if the instance ID changes
+	compressed := compressFallback.Load()
+	record, err := fallbackRecord(data, compressed)
 	if err != nil {
-		logger.Error("Failed to open fallback log file", zap.Error(err))
-		return
+		in.logger.Error("Failed to compress fallback entry", zlog.Error(err))
+		return err
+	}
+	filename, err := in.appendFallbackFile(dir, prefix, compressed, record)
+	if errors.Is(err, ErrFallbackFull) {
+		return err // Counted and reported by the budget
 	}
-	defer file.Close()
+	if err != nil {
+		in.logger.Error("Failed to write fallback log file", zlog.String("file", filename), zlog.Error(err))
+		return err
+	}
+	in.counters.fallbackWritten.Add(1)
+	EmitEvent(Event{Type: EventFallbackWritten, Count: 1, File: filename})
+	return nil
+}
 
-	data, _ := json.Marshal(logData)
-	file.WriteString(string(data) + "\n")
+// fallbackFilePrefix returns the fallback file prefix owned by this instance
+func (in *Instance) fallbackFilePrefix() string {
+	return in.Identity().fallbackPrefix()
+}
+
+// sanitizeFileComponent replaces characters that are unsafe in file names
+func sanitizeFileComponent(value string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, value)
+}
+
+// escapeFileComponent percent-escapes the bytes of value that are unsafe in
+// file names, '_' and '%' included, so that distinct values never share a
+// file name the way they would with sanitizeFileComponent
+func escapeFileComponent(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '.' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
 }
 
 // Generate log file path with datetime for system logs
 func generateLogFilePath() string {
-	currentTime := time.Now().Format("020120061504")
+	currentTime := Now().Format("020120061504")
 	return filepath.Join(syslogsPath, "syslogs_"+currentTime+".log")
 }
 
 // Get log writer to write to log file
-func getLogWriter(logFile string) zapcore.WriteSyncer {
-	file, _ := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	return zapcore.AddSync(file)
+func getLogWriter(logFile string) (io.Writer, error) {
+	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open syslog file %s: %w", logFile, err)
+	}
+	return file, nil
 }
 
-// Cleanup logs older than syslogKeepTime
-func cleanupOldLogs() {
+// Cleanup logs older than the syslog keep time, then syslog files beyond
+// the syslog file limit
+func (in *Instance) cleanupOldLogs() {
+	defer in.trimSyslogFiles()
+
+	fallbackPath := in.currentFallbackPath()
 	logDirs := []string{"logs", fallbackPath, syslogsPath}
-	expiration := time.Now().Add(-time.Duration(syslogKeepTime) * time.Hour)
+	for _, b := range registeredBackends() {
+		logDirs = append(logDirs, b.fallbackDir(fallbackPath))
+	}
+	expiration := Now().Add(-in.cfg.SyslogKeepTime)
 
 	for _, logDir := range logDirs {
 		files, err := os.ReadDir(logDir)
 		if err != nil {
-			logger.Warn("Failed to read log directory for cleanup", zap.String("directory", logDir), zap.Error(err))
+			in.logger.Warn("Failed to read log directory for cleanup", zlog.String("directory", logDir), zlog.Error(err))
 			continue
 		}
 
@@ -234,17 +588,23 @@ func cleanupOldLogs() {
 
 			info, err := os.Stat(filePath)
 			if err != nil {
-				logger.Warn("Failed to fetch log file info", zap.String("file", filePath), zap.Error(err))
+				in.logger.Warn("Failed to fetch log file info", zlog.String("file", filePath), zlog.Error(err))
+				continue
+			}
+			if info.IsDir() { // Backend fallback subdirectories are cleaned up on their own
+				continue
+			}
+			if isActiveSegment(filePath) || in.isActiveFallbackFile(filePath) { // Still being written, however long it has been idle
 				continue
 			}
 
-			// Delete if the log is older than syslogKeepTime
+			// Delete if the log is older than the syslog keep time
 			if info.ModTime().Before(expiration) {
 				err := os.Remove(filePath)
 				if err != nil {
-					logger.Error("Failed to delete old log file", zap.String("file", filePath), zap.Error(err))
+					in.logger.Error("Failed to delete old log file", zlog.String("file", filePath), zlog.Error(err))
 				} else {
-					logger.Info("Deleted old log file", zap.String("file", filePath))
+					in.logger.Info("Deleted old log file", zlog.String("file", filePath))
 				}
 			}
 		}
@@ -259,19 +619,93 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
-		logger.Warn("Invalid integer value for environment variable. Using default value.",
-			zap.String("key", key), zap.String("value", valueStr), zap.Error(err))
+		defaultLogger().Warn("Invalid integer value for environment variable. Using default value.",
+			zlog.String("key", key), zlog.String("value", valueStr), zlog.Error(err))
+		return defaultValue
+	}
+	return value
+}
+
+// Utility function to get environment variable as boolean
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		defaultLogger().Warn("Invalid boolean value for environment variable. Using default value.",
+			zlog.String("key", key), zlog.String("value", valueStr), zlog.Error(err))
 		return defaultValue
 	}
 	return value
 }
 
-// SetFallbackPath allows testing to override the fallback path
+// PriorityQueueEnabled reports whether error/fatal logs use a dedicated priority queue
+func PriorityQueueEnabled() bool {
+	return priorityQueue.Load()
+}
+
+// SetFallbackPath allows testing to override the fallback path of the
+// default instance, and of the instances initialized afterwards without one
 func SetFallbackPath(path string) {
-	fallbackPath = path
+	fallbackPathOverride.Store(path)
+	Default().SetFallbackPath(path)
 }
 
-// SetRedisClient allows testing to inject a mock Redis client
+// SetFallbackPath overrides the fallback path of the instance
+func (in *Instance) SetFallbackPath(path string) {
+	in.fallbackPath.Store(path)
+	in.fallbackFilesMu.Lock()
+	in.fallbackUsageEpoch = 0 // Measured again against the budget
+	in.fallbackFilesMu.Unlock()
+}
+
+// currentFallbackPath returns the fallback directory of the instance, which
+// SetFallbackPath may change while the background loops read it
+func (in *Instance) currentFallbackPath() string {
+	path, _ := in.fallbackPath.Load().(string)
+	if path == "" {
+		return filepath.Join("logs", "fallback")
+	}
+	return path
+}
+
+// SetRedisClient allows testing to inject a mock Redis client in the
+// default instance
 func SetRedisClient(client RedisClient) {
-	rdb = client
+	Default().SetRedisClient(client)
+}
+
+// SetRedisClient replaces the Redis client of the instance. The client
+// created at initialization, if replaced, is closed.
+func (in *Instance) SetRedisClient(client RedisClient) {
+	if client != RedisClient(in.ownedClient) {
+		in.closeOwnedClient()
+	}
+	in.stopReconnect()
+	in.setRedisClient(client)
+}
+
+// redisClient returns the Redis client of the instance, nil when none is
+// set; SetRedisClient may replace it while entries are pushed
+func (in *Instance) redisClient() RedisClient {
+	in.clientMu.RLock()
+	defer in.clientMu.RUnlock()
+	return in.rdb
+}
+
+// setRedisClient replaces the Redis client read by redisClient
+func (in *Instance) setRedisClient(client RedisClient) {
+	in.clientMu.Lock()
+	defer in.clientMu.Unlock()
+	in.rdb = client
+}
+
+// closeOwnedClient closes the client created at initialization, stopping its connection pool
+func (in *Instance) closeOwnedClient() {
+	if in.ownedClient != nil {
+		in.ownedClient.Close()
+		in.ownedClient = nil
+	}
 }
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// LostLogPrefix starts every stderr line written for a lost entry, so that
+// operators and log scrapers can recognize and recover them
+const LostLogPrefix = "APPLOGS_LOST_LOG "
+
+var (
+	lostLogMu     sync.RWMutex
+	lostLogOutput io.Writer = os.Stderr
+	onLostLog     func(logData map[string]interface{})
+)
+
+// SetOnLostLog registers a callback invoked with every entry that reached
+// neither Redis nor the fallback disk
+func SetOnLostLog(fn func(logData map[string]interface{})) {
+	lostLogMu.Lock()
+	defer lostLogMu.Unlock()
+	onLostLog = fn
+}
+
+// SetLostLogOutput allows testing to capture the last-resort output (default os.Stderr)
+func SetLostLogOutput(w io.Writer) {
+	lostLogMu.Lock()
+	defer lostLogMu.Unlock()
+	lostLogOutput = w
+}
+
+// lostLogHandlers returns the last-resort output and the OnLostLog callback
+func lostLogHandlers() (io.Writer, func(logData map[string]interface{})) {
+	lostLogMu.RLock()
+	defer lostLogMu.RUnlock()
+	return lostLogOutput, onLostLog
+}
+
+// lostLog is the last resort for an entry that could not be written anywhere:
+// it prints the entry to stderr, counts it and hands it to the OnLostLog callback
+func lostLog(logData map[string]interface{}, cause error) {
+	logsLostTotal.Add(1)
+	EmitEvent(Event{Type: EventLogDropped, Count: 1, Reason: "lost", Err: cause})
+
+	data, err := json.Marshal(logData)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%q", fmt.Sprint(logData)))
+	}
+	output, callback := lostLogHandlers()
+	fmt.Fprintf(output, "%s%s cause=%q\n", LostLogPrefix, data, cause.Error())
+
+	if callback != nil {
+		callback(logData)
+	}
+}
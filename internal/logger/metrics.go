@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"sync/atomic"
+)
+
+// Internal counters, safe to read while logging
+var (
+	logsLostTotal    atomic.Uint64 // Entries that reached neither Redis nor the fallback disk
+	logsShedTotal    atomic.Uint64 // Low-level entries dropped by load shedding
+	logsSampledTotal atomic.Uint64 // Debug and info entries dropped by adaptive sampling
+
+	workerPanicsTotal    atomic.Uint64 // Panics recovered from the log worker
+	fallbackDroppedTotal atomic.Uint64 // Entries dropped to keep the fallback directory within its budget
+)
+
+// counters are the delivery counters of an instance
+type counters struct {
+	pushed          atomic.Uint64
+	fallbackWritten atomic.Uint64
+	recovered       atomic.Uint64
+	recoveryFailed  atomic.Uint64
+	dropped         atomic.Uint64
+}
+
+// Counters is a snapshot of the delivery counters of an instance, totals
+// since it was initialized
+type Counters struct {
+	Pushed          uint64 // Entries delivered to Redis or the sink, recovered entries aside
+	FallbackWritten uint64 // Entries saved to fallback
+	Recovered       uint64 // Fallback entries resent by recovery
+	RecoveryFailed  uint64 // Fallback entries recovery failed to resend, once per pass
+	Dropped         uint64 // Entries dropped because the queue was full
+}
+
+// Counters returns the delivery counters of the instance, read atomically
+// though not all at once
+func (in *Instance) Counters() Counters {
+	return Counters{
+		Pushed:          in.counters.pushed.Load(),
+		FallbackWritten: in.counters.fallbackWritten.Load(),
+		Recovered:       in.counters.recovered.Load(),
+		RecoveryFailed:  in.counters.recoveryFailed.Load(),
+		Dropped:         in.counters.dropped.Load(),
+	}
+}
+
+// LogsLostTotal returns the number of entries lost because both Redis and the fallback disk failed
+func LogsLostTotal() uint64 {
+	return logsLostTotal.Load()
+}
+
+// LogsShedTotal returns the number of entries dropped by load shedding
+func LogsShedTotal() uint64 {
+	return logsShedTotal.Load()
+}
+
+// LogsSampledTotal returns the number of entries dropped by adaptive sampling
+func LogsSampledTotal() uint64 {
+	return logsSampledTotal.Load()
+}
+
+// WorkerPanicsTotal returns the number of panics recovered from the log worker
+func WorkerPanicsTotal() uint64 {
+	return workerPanicsTotal.Load()
+}
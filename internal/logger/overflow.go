@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Handling of entries logged while the queue is full
+const (
+	OverflowDrop  = "drop"  // Drop debug and info entries, emitting EventLogDropped, and save the others to fallback
+	OverflowBlock = "block" // Wait for room in the queue, then save the entry to fallback if a timeout is set
+)
+
+// OverflowPolicy is what happens to an entry logged while the queue is full.
+// In OverflowBlock mode the caller waits for room in the queue, at most
+// Timeout if it is set, after which the entry is saved to fallback.
+type OverflowPolicy struct {
+	Mode    string
+	Timeout time.Duration // Longest wait in OverflowBlock mode; 0 waits as long as it takes
+}
+
+var overflowPolicy atomic.Pointer[OverflowPolicy] // Nil until set, which selects OverflowDrop
+
+// loadOverflowConfig reads APPLG_OVERFLOW_POLICY and APPLG_OVERFLOW_TIMEOUT
+// (in milliseconds) from the environment
+func loadOverflowConfig() {
+	SetOverflowPolicy(OverflowPolicy{
+		Mode:    os.Getenv("APPLG_OVERFLOW_POLICY"),
+		Timeout: time.Duration(getEnvAsInt("APPLG_OVERFLOW_TIMEOUT", 0)) * time.Millisecond,
+	})
+}
+
+// SetOverflowPolicy selects what happens to entries logged while the queue
+// is full. Unknown modes select OverflowDrop, and negative timeouts 0.
+func SetOverflowPolicy(policy OverflowPolicy) {
+	if policy.Mode != OverflowBlock {
+		policy.Mode = OverflowDrop
+	}
+	if policy.Timeout < 0 {
+		policy.Timeout = 0
+	}
+	overflowPolicy.Store(&policy)
+}
+
+// GetOverflowPolicy returns what happens to entries logged while the queue is full
+func GetOverflowPolicy() OverflowPolicy {
+	if policy := overflowPolicy.Load(); policy != nil {
+		return *policy
+	}
+	return OverflowPolicy{Mode: OverflowDrop}
+}
+
+// OverflowDroppable reports whether an entry of level may be dropped when
+// the queue is full. Only debug and info entries are: warn, error and fatal
+// entries are saved to fallback instead, so that a flood of low-level
+// entries never costs the ones that matter.
+func OverflowDroppable(level string) bool {
+	switch level {
+	case "debug", "info":
+		return true
+	}
+	return false
+}
+
+// CountDropped records entries of the instance dropped because the queue was full
+func (in *Instance) CountDropped(count int) {
+	in.counters.dropped.Add(uint64(count))
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// purgeScanCount is the number of keys requested per SCAN call, and so the
+// size of the DEL batches
+const purgeScanCount = 100
+
+// PurgeServiceKeys deletes the keys of the service of the default instance
+func PurgeServiceKeys() (int, error) {
+	return Default().PurgeServiceKeys()
+}
+
+// PurgeServiceKeys deletes the keys of every instance of this service: the
+// lists, including the important, summary and priority lists, and the seen
+// ID sets. Keys are found with SCAN rather than the blocking KEYS command
+// and deleted in batches. It returns the number of keys deleted.
+func (in *Instance) PurgeServiceKeys() (int, error) {
+	if in.redisClient() == nil {
+		return 0, errors.New("redis client is not set")
+	}
+
+	// One snapshot, so that an identity change cannot mix segments of two identities
+	id := in.Identity()
+	service := globEscape(joinKey(escapeKeySegment(id.FacilityID), escapeKeySegment(id.InstanceType), escapeKeySegment(id.ServiceName)))
+	prefix := globEscape(KeyPrefix)
+	patterns := []string{
+		joinKey(prefix, service, "*"),
+		joinKey(prefix, "priority", service, "*"),
+		joinKey(prefix, "seen", service, "*"),
+	}
+
+	deleted := 0
+	for _, pattern := range patterns {
+		// Deleting while scanning may make a cursor skip keys, so passes are
+		// repeated until one finds nothing left, which also catches keys
+		// written meanwhile
+		for {
+			n, err := in.purgeMatching(pattern)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			if n == 0 {
+				break
+			}
+		}
+	}
+	in.logger.Info("Purged service keys", zlog.String("service", id.ServiceName), zlog.Int("count", deleted))
+	return deleted, nil
+}
+
+// purgeMatching runs one SCAN pass over pattern, deleting each batch of keys found
+func (in *Instance) purgeMatching(pattern string) (int, error) {
+	rdb := in.redisClient()
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, pattern, purgeScanCount).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := rdb.Del(ctx, keys...).Result()
+			deleted += int(n)
+			if err != nil {
+				return deleted, err
+			}
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// globEscape escapes the characters SCAN MATCH patterns treat specially
+func globEscape(s string) string {
+	var escaped strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
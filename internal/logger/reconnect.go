@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// Defaults of the reconnection backoff
+const (
+	defaultReconnectBase = 500 * time.Millisecond // Delay before the first reconnection attempt
+	defaultReconnectMax  = 30 * time.Second       // Longest delay between attempts
+	reconnectFailures    = 3                      // Consecutive failed pushes after which Redis is considered down
+)
+
+var (
+	backoffMu     sync.Mutex
+	reconnectBase = defaultReconnectBase
+	reconnectMax  = defaultReconnectMax
+)
+
+// loadReconnectConfig reads APPLG_RECONNECT_BASE and APPLG_RECONNECT_MAX, in milliseconds
+func loadReconnectConfig() {
+	SetReconnectBackoff(
+		time.Duration(getEnvAsInt("APPLG_RECONNECT_BASE", int(defaultReconnectBase/time.Millisecond)))*time.Millisecond,
+		time.Duration(getEnvAsInt("APPLG_RECONNECT_MAX", int(defaultReconnectMax/time.Millisecond)))*time.Millisecond)
+}
+
+// SetReconnectBackoff sets the delay before the first reconnection attempt
+// and the cap it doubles up to. Values that are not positive select the
+// defaults, and a cap below base is raised to it.
+func SetReconnectBackoff(base, max time.Duration) {
+	if base <= 0 {
+		base = defaultReconnectBase
+	}
+	if max <= 0 {
+		max = defaultReconnectMax
+	}
+	if max < base {
+		max = base
+	}
+	backoffMu.Lock()
+	defer backoffMu.Unlock()
+	reconnectBase, reconnectMax = base, max
+}
+
+// ReconnectBackoff returns the first and longest delays between reconnection attempts
+func ReconnectBackoff() (base, max time.Duration) {
+	backoffMu.Lock()
+	defer backoffMu.Unlock()
+	return reconnectBase, reconnectMax
+}
+
+// IsHealthy reports whether entries of the default instance currently reach
+// their destination
+func IsHealthy() bool {
+	return Default().IsHealthy()
+}
+
+// IsHealthy reports whether entries currently reach their destination: a
+// sink is set, or a Redis client is set and not waiting to reconnect
+func (in *Instance) IsHealthy() bool {
+	if in.currentSink() != nil {
+		return true
+	}
+	return in.redisClient() != nil && !in.redisDown.Load()
+}
+
+// observePushResult counts the consecutive failures of pushes to Redis,
+// starting the reconnection loop once they are sustained
+func (in *Instance) observePushResult(err error) {
+	if err == nil {
+		in.pushFailures.Store(0)
+		return
+	}
+	if in.pushFailures.Add(1) >= reconnectFailures {
+		in.startReconnect(err)
+	}
+}
+
+// startReconnect marks Redis down and starts pinging it in the background
+// with exponential backoff. Until a ping succeeds, batches go straight to
+// fallback, so that entries do not each wait for a failed push and log it.
+func (in *Instance) startReconnect(err error) {
+	in.reconnectMu.Lock()
+	defer in.reconnectMu.Unlock()
+	if in.reconnectStop != nil || in.redisClient() == nil || in.currentSink() != nil {
+		return
+	}
+	in.redisDown.Store(true)
+	in.logger.Warn("Redis unreachable, logging to fallback until it reconnects", zlog.Error(err))
+
+	stop := make(chan struct{})
+	in.reconnectStop = stop
+	base, max := ReconnectBackoff()
+	go in.reconnectLoop(stop, base, max)
+}
+
+// reconnectLoop pings Redis after base, then after delays doubling up to
+// max, until it answers or stop is closed. Each attempt dials a new
+// connection, resolving the address again, so a Redis that came back on a
+// new IP is found.
+func (in *Instance) reconnectLoop(stop chan struct{}, base, max time.Duration) {
+	delay := base
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		client := in.redisClient()
+		if client != nil && client.Ping(ctx).Err() == nil {
+			in.reconnected(stop, attempt)
+			return
+		}
+		if delay *= 2; delay > max {
+			delay = max
+		}
+	}
+}
+
+// reconnected ends the reconnection started with stop, unless it was
+// stopped meanwhile, and reports it once
+func (in *Instance) reconnected(stop chan struct{}, attempts int) {
+	in.reconnectMu.Lock()
+	if in.reconnectStop != stop {
+		in.reconnectMu.Unlock()
+		return
+	}
+	in.reconnectStop = nil
+	in.pushFailures.Store(0)
+	in.redisDown.Store(false)
+	in.reconnectMu.Unlock()
+
+	in.logger.Info("Redis reconnected", zlog.Int("attempts", attempts))
+	in.observeRedisState("connected", nil)
+	EmitEvent(Event{Type: EventRedisReconnected, Count: attempts})
+}
+
+// stopReconnect stops a running reconnection loop and clears the failure
+// count, for a new client or configuration
+func (in *Instance) stopReconnect() {
+	in.reconnectMu.Lock()
+	defer in.reconnectMu.Unlock()
+	if in.reconnectStop != nil {
+		close(in.reconnectStop)
+		in.reconnectStop = nil
+	}
+	in.pushFailures.Store(0)
+	in.redisDown.Store(false)
+}
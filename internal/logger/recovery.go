@@ -2,141 +2,298 @@ package logger
 
 import (
 	"bufio"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"go.uber.org/zap"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
 )
 
 var recoveryRedisClient RedisClient // Abstracted Redis client for recovery
 
+var markRecovered atomic.Bool // Whether recovered entries are tagged with RecoveredField and RecoveredAtField
+
+const (
+	RecoveredField   = "recovered"    // Set to true on entries replayed from fallback
+	RecoveredAtField = "recovered_at" // Time of the replay; "timestamp" keeps the original time
+)
+
+// legacyFallbackFile matches the fallback_YYYYMMDDHHMMSS.log files of versions
+// that did not include the instance in the name
+var legacyFallbackFile = regexp.MustCompile(`^fallback_\d{14}\.log$`)
+
 // SetRecoveryRedisClient allows setting the Redis client for recovery
 func SetRecoveryRedisClient(client RedisClient) {
 	recoveryRedisClient = client
 }
 
-// StartRecoveryProcess initiates periodic fallback recovery
+// StartRecoveryProcess initiates periodic fallback recovery of the default
+// instance, until StopBackground
 func StartRecoveryProcess(interval time.Duration) {
-	go func() {
-		for {
-			time.Sleep(interval)
-			recoverFallbackLogs()
+	Default().StartRecoveryProcess(interval)
+}
+
+// StartRecoveryProcess initiates periodic fallback recovery, until StopBackground
+func (in *Instance) StartRecoveryProcess(interval time.Duration) {
+	in.startBackground(func(stop <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		in.runRecoveryLoop(ticker.C, stop)
+	})
+}
+
+// runRecoveryLoop runs a recovery pass on every tick until stop is closed.
+// The loop holds no timing of its own, a pass being a plain call to
+// recoverFallbackLogs.
+func (in *Instance) runRecoveryLoop(ticks <-chan time.Time, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticks:
+			in.recoverFallbackLogs()
 		}
-	}()
+	}
 }
 
-// recoverFallbackLogs scans fallback logs and resends them to Redis
-func recoverFallbackLogs() {
-	if rdb == nil {
-		logger.Error("Redis client is not set. Skipping recovery.")
-		return
+// SetMarkRecovered enables tagging recovered entries, so that consumers can
+// tell replayed, late-arriving entries from live ones
+func SetMarkRecovered(enabled bool) {
+	markRecovered.Store(enabled)
+}
+
+// RecoverFallbackLogs runs a single recovery pass of the default instance
+func RecoverFallbackLogs() {
+	Default().RecoverFallbackLogs()
+}
+
+// RecoverFallbackLogs runs a single recovery pass immediately, returning once
+// it is over. A periodic pass in progress is waited for first; passes of
+// different instances run concurrently, each draining its own files.
+func (in *Instance) RecoverFallbackLogs() {
+	in.recoverFallbackLogs()
+}
+
+// recoverFallbackLogs scans fallback logs and resends them to Redis, and
+// those of each additional backend to that backend, then checks the age of
+// what is left
+func (in *Instance) recoverFallbackLogs() {
+	in.recoveryPassMu.Lock()
+	defer in.recoveryPassMu.Unlock()
+
+	fallbackPath := in.currentFallbackPath()
+	if in.redisClient() == nil && in.currentSink() == nil {
+		in.logger.Error("Redis client is not set. Skipping recovery.")
+	} else {
+		in.recoverFallbackDir(fallbackPath, nil)
+	}
+
+	for _, b := range registeredBackends() {
+		b := b
+		in.recoverFallbackDir(b.fallbackDir(fallbackPath), &b)
 	}
+	in.checkBacklogAge()
+}
 
-	files, err := os.ReadDir(fallbackPath)
+// recoverFallbackDir resends the fallback files of this instance found in
+// dir, to the backend b or to Redis (or the sink) when b is nil
+func (in *Instance) recoverFallbackDir(dir string, b *backend) {
+	in.sealFallbackFile(dir) // Drained below; later entries go to a new file
+	files, err := os.ReadDir(dir)
 	if err != nil {
-		logger.Error("Failed to scan fallback directory", zap.Error(err))
+		if b == nil || !os.IsNotExist(err) { // A backend directory only exists once the backend failed
+			in.logger.Error("Failed to scan fallback directory", zlog.String("directory", dir), zlog.Error(err))
+		}
 		return
 	}
 
+	// Only files written by this instance are recovered; other instances
+	// sharing the directory drain their own files. Those sharing its
+	// instance ID, in this process or another, are told apart by the lock
+	// of the file they append to.
+
 	for _, file := range files {
-		if filepath.Ext(file.Name()) == ".log" && strings.HasPrefix(file.Name(), "fallback_") {
-			filePath := filepath.Join(fallbackPath, file.Name())
-			f, err := os.Open(filePath)
-			if err != nil {
-				logger.Error("Failed to read fallback log", zap.String("file", filePath), zap.Error(err))
+		name := file.Name()
+		if !isFallbackFileName(name) {
+			continue
+		}
+		if b == nil && legacyFallbackFile.MatchString(name) {
+			claimed, ok := in.claimLegacyFallbackFile(name)
+			if !ok {
 				continue
 			}
-			defer f.Close()
-
-			scanner := bufio.NewScanner(f)
-			var batchLogs []map[string]interface{}
-			corrupt := false
-			redisPushFailed := false
-
-			for scanner.Scan() {
-				line := scanner.Text()
-				if len(line) == 0 {
-					continue
-				}
-
-				var logData map[string]interface{}
-				if err := json.Unmarshal([]byte(line), &logData); err != nil {
-					logger.Error("Invalid JSON in fallback log line",
-						zap.String("file", filePath),
-						zap.String("line", line))
-					corrupt = true
-					continue
-				}
-
-				batchLogs = append(batchLogs, logData)
-			}
-
-			// Push batch logs to Redis
-			if len(batchLogs) > 0 {
-				if err := pushBatchToRedis(batchLogs); err != nil {
-					redisPushFailed = true // Do not log here; it's already logged inside pushBatchToRedis
-				} else {
-					logger.Info("Batch log successfully sent to Redis",
-						zap.String("file", filePath),
-						zap.Int("count", len(batchLogs)))
-				}
-			}
-
-			if err := scanner.Err(); err != nil {
-				logger.Error("Error reading fallback log line by line", zap.Error(err))
-			}
+			name = claimed
+		}
+		if in.ownsFallbackFile(name) {
+			in.recoverFallbackFile(filepath.Join(dir, name), b)
+		}
+	}
+}
 
-			// Handle log file removal or renaming
-			if corrupt {
-				os.Rename(filePath, filePath+".corrupt")
-			} else if !redisPushFailed {
-				os.Remove(filePath) // Remove after successful batch resend
-			}
+// claimLegacyFallbackFile renames a file written before fallback files were
+// named per instance into this instance's prefix, so that one instance only
+// drains it. The entries keep the identity they were logged with.
+func (in *Instance) claimLegacyFallbackFile(name string) (string, bool) {
+	claimed := in.fallbackFilePrefix() + "legacy_" + strings.TrimPrefix(name, "fallback_")
+	dir := in.currentFallbackPath()
+	if err := os.Rename(filepath.Join(dir, name), filepath.Join(dir, claimed)); err != nil {
+		if !os.IsNotExist(err) { // Otherwise already claimed by another instance
+			in.logger.Warn("Failed to claim legacy fallback log", zlog.String("file", name), zlog.Error(err))
 		}
+		return "", false
 	}
+	in.logger.Info("Claimed legacy fallback log", zlog.String("file", name), zlog.String("claimed", claimed))
+	return claimed, true
 }
 
-// pushBatchToRedis sends logs in a single batch operation
-func pushBatchToRedis(logs []map[string]interface{}) error {
-	pipe := rdb.Pipeline()
-	var finalErr error // Track overall pipeline error
+// recoverFallbackFile resends the logs of one fallback file to the backend b,
+// or to Redis when b is nil, removing it once delivered. A file locked by
+// another process or instance, which appends to it or recovers it, is left
+// for it.
+func (in *Instance) recoverFallbackFile(filePath string, b *backend) {
+	unlock, ok := lockFallbackPath(filePath)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	f, err := openLogFile(filePath) // Decompressing .log.gz files
+	if err != nil {
+		in.logger.Error("Failed to read fallback log", zlog.String("file", filePath), zlog.Error(err))
+		return
+	}
+
+	scanner := bufio.NewScanner(f)
+	var batchLogs []map[string]interface{}
+	var invalidLines []string // Kept with the entries to retry, for inspection once set aside
+	corrupt := false
+	redisPushFailed := false
+	pushed := 0
 
-	for _, logData := range logs {
-		key := "applogs:" + logData["facility_id"].(string) + ":" +
-			logData["instance_type"].(string) + ":" +
-			logData["service_name"].(string) + ":" +
-			logData["instance_id"].(string)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			continue
+		}
 
-		// Marshal logData to JSON
-		data, err := json.Marshal(logData)
+		logData, err := DecodeLogData([]byte(line))
 		if err != nil {
-			logger.Error("Failed to marshal log data to JSON", zap.Error(err))
+			in.logger.Error("Invalid JSON in fallback log line",
+				zlog.String("file", filePath),
+				zlog.String("line", line))
+			invalidLines = append(invalidLines, line)
+			corrupt = true
+			continue
+		}
+		if err := checkRecoveredEntry(logData); err != nil {
+			in.logger.Error("Invalid entry in fallback log line",
+				zlog.String("file", filePath),
+				zlog.String("line", line),
+				zlog.Error(err))
+			invalidLines = append(invalidLines, line)
+			corrupt = true
 			continue
 		}
 
-		// Append new log to the list
-		pipe.LPush(ctx, key, data)
+		batchLogs = append(batchLogs, logData)
 	}
 
-	// Execute the pipeline commands
-	cmds, err := pipe.Exec(ctx)
-	if err != nil {
-		logger.Warn("Pipeline execution failed", zap.Error(err))
-		return err // Avoid redundant per-command errors if pipeline failed
+	// Push batch logs to Redis
+	if b == nil {
+		batchLogs = in.filterSeen(batchLogs)
+	}
+	if markRecovered.Load() {
+		recoveredAt := Now().UTC()
+		for _, logData := range batchLogs {
+			logData[RecoveredField] = true
+			logData[RecoveredAtField] = recoveredAt
+		}
+	}
+	if len(batchLogs) > 0 && b != nil {
+		var err error
+		pushed, err = pushRecovered(batchLogs, func(logs []map[string]interface{}) error {
+			return in.pushBatchToSink(b.sink, in.encodeBatch(logs))
+		})
+		if err != nil {
+			redisPushFailed = true // Already logged by the push
+		} else {
+			in.logger.Info("Batch log successfully sent to backend",
+				zlog.String("backend", b.name),
+				zlog.String("file", filePath),
+				zlog.Int("count", len(batchLogs)))
+			EmitEvent(Event{Type: EventRecoveryCompleted, Count: len(batchLogs), File: filePath})
+		}
+	} else if len(batchLogs) > 0 {
+		var err error
+		if pushed, err = pushRecovered(batchLogs, in.pushBatch); err != nil {
+			redisPushFailed = true // Do not log here; it's already logged inside pushBatch
+			in.observeRedisState("unavailable", err)
+		} else {
+			in.logger.Info("Batch log successfully sent to Redis",
+				zlog.String("file", filePath),
+				zlog.Int("count", len(batchLogs)))
+			in.observeRedisState("connected", nil)
+			EmitEvent(Event{Type: EventRecoveryCompleted, Count: len(batchLogs), File: filePath})
+		}
+	}
+	in.counters.recovered.Add(uint64(pushed))
+	if redisPushFailed {
+		in.counters.recoveryFailed.Add(uint64(len(batchLogs) - pushed))
+	}
+
+	if err := scanner.Err(); err != nil {
+		in.logger.Error("Error reading fallback log line by line", zlog.Error(err))
+		if strings.HasSuffix(filePath, fallbackGzipSuffix) {
+			corrupt = true // A damaged member; the entries before it were read
+		}
 	}
 
-	// Only check individual command errors if the pipeline didn't fail
-	for _, cmd := range cmds {
-		if cmd.Err() != nil {
-			logger.Warn("Failed to push individual log to Redis",
-				zap.String("cmd", cmd.String()),
-				zap.Error(cmd.Err()))
-			finalErr = cmd.Err()
+	// Close before removing or renaming, which fails on some platforms otherwise
+	f.Close()
+
+	// Handle log file removal or renaming. A corrupt file is only set aside
+	// once its valid entries are delivered, the next pass retrying them
+	// otherwise.
+	if redisPushFailed {
+		if pushed > 0 {
+			in.rewriteFallbackFile(filePath, batchLogs[pushed:], invalidLines)
+		}
+	} else if corrupt {
+		os.Rename(filePath, filePath+".corrupt")
+	} else {
+		os.Remove(filePath) // Remove after successful batch resend
+	}
+}
+
+// checkRecoveredEntry checks that a payload read from a fallback file, which
+// may have been edited by hand or partially written, is an object carrying
+// the identity fields its key is built from, as strings; empty ones are
+// kept, as the entry was written with them
+func checkRecoveredEntry(logData map[string]interface{}) error {
+	if logData == nil {
+		return errors.New("entry is not a JSON object")
+	}
+	for _, field := range []string{"service_name", "instance_id", "facility_id", "instance_type"} {
+		if _, ok := logData[field].(string); !ok {
+			return fmt.Errorf("%s is missing or not a string", field)
 		}
 	}
+	return nil
+}
 
-	return finalErr
+// logDataKey builds the Redis key from the identity stored in a log payload;
+// important and summary entries go to their own lists
+func logDataKey(logData map[string]interface{}) string {
+	key := identityOf(logData).key()
+	if summary, _ := logData[SummaryField].(bool); summary {
+		key = joinKey(key, SummaryKeySegment)
+	} else if important, _ := logData[ImportantField].(bool); important {
+		key = joinKey(key, ImportantKeySegment)
+	}
+	return key
 }
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// recoveryChunksPerSecond is how many pushes a rate limited recovery spreads
+// each second of its budget over, so that entries trickle in rather than
+// arriving in one burst per second
+const recoveryChunksPerSecond = 10
+
+var (
+	recoveryRate int // Entries recovery pushes per second; 0 is unlimited
+
+	recoveryMu   sync.Mutex
+	recoveryNext time.Time // Earliest time the next recovery push may start
+)
+
+// loadRecoveryRateConfig reads APPLG_RECOVERY_RATE from the environment
+func loadRecoveryRateConfig() {
+	SetRecoveryRate(getEnvAsInt("APPLG_RECOVERY_RATE", 0))
+}
+
+// SetRecoveryRate limits recovery to perSecond entries per second across all
+// files, backends and concurrent passes, so that a backlog saved during an
+// outage drains gradually instead of hitting Redis as it comes back. 0
+// removes the limit.
+func SetRecoveryRate(perSecond int) {
+	if perSecond < 0 {
+		perSecond = 0
+	}
+	recoveryMu.Lock()
+	defer recoveryMu.Unlock()
+	recoveryRate = perSecond
+	recoveryNext = time.Time{}
+}
+
+// RecoveryRate returns the recovery limit in entries per second, 0 when unlimited
+func RecoveryRate() int {
+	recoveryMu.Lock()
+	defer recoveryMu.Unlock()
+	return recoveryRate
+}
+
+// paceRecovery blocks until n more entries can be pushed within the rate
+func paceRecovery(n int) {
+	recoveryMu.Lock()
+	if recoveryRate == 0 {
+		recoveryMu.Unlock()
+		return
+	}
+	start := recoveryNext
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	recoveryNext = start.Add(time.Duration(n) * time.Second / time.Duration(recoveryRate))
+	recoveryMu.Unlock()
+
+	time.Sleep(time.Until(start))
+}
+
+// pushRecovered pushes recovered entries with push, in paced chunks when a
+// rate is set. It returns how many entries were pushed before an error.
+func pushRecovered(logs []map[string]interface{}, push func([]map[string]interface{}) error) (int, error) {
+	size := len(logs)
+	if rate := RecoveryRate(); rate > 0 {
+		size = rate / recoveryChunksPerSecond
+		if size < 1 {
+			size = 1
+		}
+	}
+
+	pushed := 0
+	for pushed < len(logs) {
+		end := pushed + size
+		if end > len(logs) {
+			end = len(logs)
+		}
+		paceRecovery(end - pushed)
+		if err := push(logs[pushed:end]); err != nil {
+			return pushed, err
+		}
+		pushed = end
+	}
+	return pushed, nil
+}
+
+// rewriteFallbackFile replaces a partially recovered file with the entries
+// still to be pushed, so that the next pass does not push the others again,
+// followed by the invalid lines of the file
+func (in *Instance) rewriteFallbackFile(filePath string, logs []map[string]interface{}, invalidLines []string) {
+	var lines []string
+	for _, entry := range in.encodeBatch(logs) {
+		lines = append(lines, string(entry.Data))
+	}
+	lines = append(lines, invalidLines...)
+	record, err := fallbackRecord([]byte(strings.Join(lines, "\n")), strings.HasSuffix(filePath, fallbackGzipSuffix))
+	if err == nil {
+		err = os.WriteFile(filePath, record, 0644)
+	}
+	if err != nil {
+		in.logger.Error("Failed to rewrite partially recovered fallback log", zlog.String("file", filePath), zlog.Error(err))
+	}
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+)
+
+// DefaultRedactKeys are the field names redacted unless more are registered
+var DefaultRedactKeys = []string{"password", "authorization", "cookie", "set-cookie", "token", "secret"}
+
+var (
+	redactMu   sync.RWMutex
+	redactKeys map[string]struct{} // Lowercase field names whose values are replaced by config.RedactedValue
+)
+
+// loadRedactConfig registers the default keys and those listed in
+// APPLG_REDACT_KEYS, comma-separated
+func loadRedactConfig() {
+	redactMu.Lock()
+	redactKeys = map[string]struct{}{}
+	redactMu.Unlock()
+	RedactKeys(DefaultRedactKeys...)
+	if value := os.Getenv("APPLG_REDACT_KEYS"); value != "" {
+		RedactKeys(strings.Split(value, ",")...)
+	}
+}
+
+// RedactKeys adds field names whose values are replaced by
+// config.RedactedValue. Names are matched case-insensitively, at any depth
+// of the fields.
+func RedactKeys(keys ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	if redactKeys == nil {
+		redactKeys = map[string]struct{}{}
+	}
+	for _, key := range keys {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			redactKeys[key] = struct{}{}
+		}
+	}
+}
+
+// RedactedKeys returns the registered field names, sorted
+func RedactedKeys() []string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	keys := make([]string, 0, len(redactKeys))
+	for key := range redactKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isRedacted reports whether the value of a field named key is redacted
+func isRedacted(key string) bool {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	_, ok := redactKeys[strings.ToLower(key)]
+	return ok
+}
+
+// RedactFields returns fields with the values of registered keys replaced by
+// config.RedactedValue, recursing into nested maps and slices. fields is
+// never modified: the maps and slices holding a redacted value are copied,
+// and fields itself is returned when nothing is redacted.
+func RedactFields(fields map[string]interface{}) map[string]interface{} {
+	if redacted, ok := redactMap(fields); ok {
+		return redacted
+	}
+	return fields
+}
+
+// RedactHeaders returns headers with the values of registered names replaced
+// by config.RedactedValue, copying headers only when something is redacted
+func RedactHeaders(headers map[string][]string) map[string][]string {
+	if redacted, ok := redactHeaders(headers); ok {
+		return redacted
+	}
+	return headers
+}
+
+// redactHeaders returns a redacted copy of headers, and false with no copy
+// when no header is redacted
+func redactHeaders(headers map[string][]string) (map[string][]string, bool) {
+	var redacted map[string][]string
+	for name := range headers {
+		if !isRedacted(name) {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(map[string][]string, len(headers))
+			for k, v := range headers {
+				redacted[k] = v
+			}
+		}
+		redacted[name] = []string{config.RedactedValue}
+	}
+	return redacted, redacted != nil
+}
+
+// redactMap returns a redacted copy of fields, and false with no copy when
+// nothing in it is redacted. Values already redacted are left as they are,
+// so that redacting the fields again copies nothing.
+func redactMap(fields map[string]interface{}) (map[string]interface{}, bool) {
+	var copied map[string]interface{}
+	for key, value := range fields {
+		var redacted interface{} = config.RedactedValue
+		ok := value != config.RedactedValue
+		if !isRedacted(key) {
+			redacted, ok = redactValue(value)
+		}
+		if !ok {
+			continue
+		}
+		if copied == nil {
+			copied = make(map[string]interface{}, len(fields))
+			for k, v := range fields {
+				copied[k] = v
+			}
+		}
+		copied[key] = redacted
+	}
+	return copied, copied != nil
+}
+
+// redactValue returns a redacted copy of the maps and slices in v, and false
+// with no copy when nothing in it is redacted
+func redactValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return redactMap(v)
+	case map[string]string:
+		var copied map[string]string
+		for key, val := range v {
+			if val == config.RedactedValue || !isRedacted(key) {
+				continue
+			}
+			if copied == nil {
+				copied = make(map[string]string, len(v))
+				for k, val := range v {
+					copied[k] = val
+				}
+			}
+			copied[key] = config.RedactedValue
+		}
+		return copied, copied != nil
+	case map[string][]string:
+		return redactHeaders(v)
+	case []interface{}:
+		var copied []interface{}
+		for i, value := range v {
+			redacted, ok := redactValue(value)
+			if !ok {
+				continue
+			}
+			if copied == nil {
+				copied = append([]interface{}(nil), v...)
+			}
+			copied[i] = redacted
+		}
+		return copied, copied != nil
+	}
+	return nil, false
+}
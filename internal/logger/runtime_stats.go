@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Groups of statistics carried by runtime stats entries
+const (
+	StatGoroutines = "goroutines" // goroutines
+	StatHeap       = "heap"       // heap_alloc_bytes, heap_sys_bytes, heap_objects
+	StatGC         = "gc"         // gc_count, gc_pause_last_ms, gc_pause_total_ms
+	StatFDs        = "fds"        // open_fds, where the platform exposes /proc/self/fd
+)
+
+// allRuntimeStats are the groups included unless APPLG_RUNTIME_STATS is set
+var allRuntimeStats = []string{StatGoroutines, StatHeap, StatGC, StatFDs}
+
+// runtimeStatsSettings is the period and groups of runtime stats entries, see SetRuntimeStats
+type runtimeStatsSettings struct {
+	interval time.Duration // Period of runtime stats entries; 0 disables them
+	stats    []string      // Groups included in runtime stats entries
+}
+
+var runtimeStats atomic.Pointer[runtimeStatsSettings] // Nil until set, which disables runtime stats
+
+// loadRuntimeStatsConfig reads APPLG_RUNTIME_STATS_INTERVAL (in seconds) and
+// APPLG_RUNTIME_STATS, a comma-separated list of groups, from the environment
+func loadRuntimeStatsConfig() {
+	stats := allRuntimeStats
+	if value := os.Getenv("APPLG_RUNTIME_STATS"); value != "" {
+		stats = strings.Split(value, ",")
+	}
+	SetRuntimeStats(time.Duration(getEnvAsInt("APPLG_RUNTIME_STATS_INTERVAL", 0))*time.Second, stats...)
+}
+
+// SetRuntimeStats records the period of runtime stats entries and the groups
+// they include; unknown groups are ignored, and none selects them all
+func SetRuntimeStats(interval time.Duration, stats ...string) {
+	if interval < 0 {
+		interval = 0
+	}
+	var selected []string
+	for _, stat := range stats {
+		switch stat = strings.TrimSpace(stat); stat {
+		case StatGoroutines, StatHeap, StatGC, StatFDs:
+			selected = append(selected, stat)
+		}
+	}
+	if len(selected) == 0 {
+		selected = allRuntimeStats
+	}
+	runtimeStats.Store(&runtimeStatsSettings{interval: interval, stats: selected})
+}
+
+// RuntimeStatsInterval returns the period of runtime stats entries, 0 when disabled
+func RuntimeStatsInterval() time.Duration {
+	if settings := runtimeStats.Load(); settings != nil {
+		return settings.interval
+	}
+	return 0
+}
+
+// RuntimeStats returns the groups included in runtime stats entries
+func RuntimeStats() []string {
+	if settings := runtimeStats.Load(); settings != nil {
+		return settings.stats
+	}
+	return allRuntimeStats
+}
+
+// CollectRuntimeStats returns the selected statistics of the process as log
+// fields. Memory statistics need runtime.ReadMemStats, which briefly stops
+// the world, so they are only read when a memory group is selected.
+func CollectRuntimeStats() map[string]interface{} {
+	fields := map[string]interface{}{}
+	var mem *runtime.MemStats
+	for _, stat := range RuntimeStats() {
+		if (stat == StatHeap || stat == StatGC) && mem == nil {
+			mem = new(runtime.MemStats)
+			runtime.ReadMemStats(mem)
+		}
+
+		switch stat {
+		case StatGoroutines:
+			fields["goroutines"] = runtime.NumGoroutine()
+		case StatHeap:
+			fields["heap_alloc_bytes"] = mem.HeapAlloc
+			fields["heap_sys_bytes"] = mem.HeapSys
+			fields["heap_objects"] = mem.HeapObjects
+		case StatGC:
+			fields["gc_count"] = mem.NumGC
+			fields["gc_pause_last_ms"] = float64(mem.PauseNs[(mem.NumGC+255)%256]) / float64(time.Millisecond)
+			fields["gc_pause_total_ms"] = float64(mem.PauseTotalNs) / float64(time.Millisecond)
+		case StatFDs:
+			if fds, ok := openFileDescriptors(); ok {
+				fields["open_fds"] = fds
+			}
+		}
+	}
+	return fields
+}
+
+// openFileDescriptors counts the open descriptors of the process, on
+// platforms exposing /proc/self/fd
+func openFileDescriptors() (int, bool) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, false
+	}
+	return len(entries) - 1, true // Leaving out the descriptor reading the directory
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// sampleWindow is the period over which the sampler measures the offered
+// volume and refills its budget, a tenth of a second like recovery chunks
+const sampleWindow = 100 * time.Millisecond
+
+// sampler keeps debug and info entries within a budget of entries per
+// second. The offered rate, measured over windows, sets the share of entries
+// kept, spread evenly; a token bucket caps what a sudden burst gets through
+// before the share has adapted.
+type sampler struct {
+	mu      sync.Mutex
+	target  int       // Entries kept per second; 0 disables sampling
+	start   time.Time // Start of the current window
+	offered int       // Entries offered in the current window
+	rate    float64   // Smoothed rate of offered entries, per second
+	ratio   float64   // Share of entries kept
+	credit  float64   // Accumulated share; an entry is kept each time it reaches 1
+	tokens  float64   // Entries the budget still allows in a burst
+	refill  time.Time // Last time tokens were added
+}
+
+var sampling = sampler{ratio: 1}
+
+// loadSamplingConfig reads APPLG_SAMPLE_TARGET, in entries per second
+func loadSamplingConfig() {
+	SetSampleTarget(getEnvAsInt("APPLG_SAMPLE_TARGET", 0))
+}
+
+// SetSampleTarget samples debug and info entries so that about perSecond of
+// them are kept each second: all of them while the volume is below the
+// target, a shrinking share as it rises above. Warn, error and fatal entries
+// are never sampled. 0 disables sampling.
+func SetSampleTarget(perSecond int) {
+	if perSecond < 0 {
+		perSecond = 0
+	}
+	sampling.mu.Lock()
+	defer sampling.mu.Unlock()
+	sampling.target, sampling.start, sampling.offered, sampling.rate = perSecond, time.Time{}, 0, 0
+	sampling.ratio, sampling.credit, sampling.tokens = 1, 0, sampling.burst(perSecond)
+}
+
+// SampleTarget returns the sampling budget in entries per second, 0 when disabled
+func SampleTarget() int {
+	sampling.mu.Lock()
+	defer sampling.mu.Unlock()
+	return sampling.target
+}
+
+// SampleRatio returns the share of debug and info entries currently kept, 1
+// when sampling is disabled or the volume is within the budget
+func SampleRatio() float64 {
+	sampling.mu.Lock()
+	defer sampling.mu.Unlock()
+	return sampling.ratio
+}
+
+// ShouldSample reports whether an entry of level is dropped by sampling,
+// counting it in LogsSampledTotal if so
+func ShouldSample(level string) bool {
+	if level != "debug" && level != "info" {
+		return false
+	}
+
+	sampling.mu.Lock()
+	keep := sampling.keep(time.Now())
+	sampling.mu.Unlock()
+	if keep {
+		return false
+	}
+
+	logsSampledTotal.Add(1)
+	EmitEvent(Event{Type: EventLogDropped, Count: 1, Reason: "sampled"})
+	return true
+}
+
+// keep records an offered entry and reports whether it fits the budget
+func (s *sampler) keep(now time.Time) bool {
+	if s.target == 0 {
+		return true
+	}
+	if s.start.IsZero() {
+		s.start, s.refill = now, now
+	}
+	if elapsed := now.Sub(s.start); elapsed >= sampleWindow {
+		s.adapt(float64(s.offered) / elapsed.Seconds())
+		s.start, s.offered = now, 0
+	}
+	s.tokens += now.Sub(s.refill).Seconds() * float64(s.target)
+	if burst := s.burst(s.target); s.tokens > burst {
+		s.tokens = burst
+	}
+	s.refill = now
+
+	s.offered++
+	if s.credit += s.ratio; s.credit > 1 {
+		s.credit = 1 // Entries dropped for the burst cap are not made up for later
+	}
+	if s.credit < 1 || s.tokens < 1 {
+		return false
+	}
+	s.credit--
+	s.tokens--
+	return true
+}
+
+// adapt updates the smoothed rate with the one observed over the last
+// window, and derives the share of entries kept from it. A rising rate is
+// averaged so that a single spike does not starve the next window, while a
+// falling one is followed at once, as the burst may have been far above the
+// target.
+func (s *sampler) adapt(observed float64) {
+	if s.rate == 0 || observed < s.rate {
+		s.rate = observed
+	} else {
+		s.rate = (s.rate + observed) / 2
+	}
+	s.ratio = 1
+	if s.rate > float64(s.target) {
+		s.ratio = float64(s.target) / s.rate
+	}
+}
+
+// burst returns the entries a budget of perSecond allows in one window, at least one
+func (s *sampler) burst(perSecond int) float64 {
+	burst := float64(perSecond) * sampleWindow.Seconds()
+	if burst < 1 {
+		burst = 1
+	}
+	return burst
+}
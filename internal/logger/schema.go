@@ -0,0 +1,29 @@
+package logger
+
+import "sync/atomic"
+
+// SchemaField holds the version of the payload format, when enabled
+const SchemaField = "_schema"
+
+// SchemaVersion is the payload format version recorded in SchemaField. It is
+// bumped whenever the layout of entries changes in a way consumers must
+// handle, such as a field renamed, moved or given another type. Adding an
+// optional field does not bump it.
+const SchemaVersion = 1
+
+var schemaField atomic.Bool // Whether payloads carry SchemaField
+
+// loadSchemaConfig reads APPLG_SCHEMA_FIELD from the environment
+func loadSchemaConfig() {
+	schemaField.Store(getEnvAsBool("APPLG_SCHEMA_FIELD", false))
+}
+
+// SetSchemaField enables or disables recording SchemaVersion in SchemaField
+func SetSchemaField(enabled bool) {
+	schemaField.Store(enabled)
+}
+
+// SchemaFieldEnabled reports whether payloads record their format version in SchemaField
+func SchemaFieldEnabled() bool {
+	return schemaField.Load()
+}
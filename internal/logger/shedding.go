@@ -0,0 +1,51 @@
+package logger
+
+import "sync/atomic"
+
+var shedHighWater atomic.Int32 // Queue fill percentage above which debug entries are shed; 0 disables shedding
+
+// loadSheddingConfig reads APPLG_SHED_HIGH_WATER, a percentage of the queue capacity
+func loadSheddingConfig() {
+	SetLoadShedding(getEnvAsInt("APPLG_SHED_HIGH_WATER", 0))
+}
+
+// SetLoadShedding sheds debug entries at enqueue time while the queue is
+// filled above percent of its capacity, and info entries too once it is past
+// halfway between percent and full. Warn, error and fatal entries are always
+// admitted. 0 disables shedding.
+func SetLoadShedding(percent int) {
+	if percent < 0 || percent >= 100 {
+		percent = 0
+	}
+	shedHighWater.Store(int32(percent))
+}
+
+// LoadShedding returns the queue fill percentage above which entries are shed, 0 when disabled
+func LoadShedding() int {
+	return int(shedHighWater.Load())
+}
+
+// ShouldShed reports whether an entry of level is shed with depth entries
+// queued out of capacity, counting it in LogsShedTotal if so
+func ShouldShed(level string, depth, capacity int) bool {
+	highWater := LoadShedding()
+	if highWater == 0 || capacity <= 0 {
+		return false
+	}
+
+	threshold := highWater
+	switch level {
+	case "debug":
+	case "info":
+		threshold += (100 - highWater) / 2
+	default:
+		return false
+	}
+	if depth*100 < threshold*capacity {
+		return false
+	}
+
+	logsShedTotal.Add(1)
+	EmitEvent(Event{Type: EventLogDropped, Count: 1, Reason: "shed"})
+	return true
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync/atomic"
+)
+
+// SigField holds the HMAC-SHA256 of an entry, in hex, when signing is enabled
+const SigField = "_sig"
+
+var signingKey atomic.Pointer[[]byte] // Secret entries are signed with; nil disables signing
+
+// loadSigningConfig reads APPLG_SIGNING_KEY from the environment
+func loadSigningConfig() {
+	SetSigningKey([]byte(os.Getenv("APPLG_SIGNING_KEY")))
+}
+
+// SetSigningKey signs every entry with HMAC-SHA256 under key; an empty key disables signing
+func SetSigningKey(key []byte) {
+	if len(key) == 0 {
+		signingKey.Store(nil)
+		return
+	}
+	key = append([]byte(nil), key...)
+	signingKey.Store(&key)
+}
+
+// SigningEnabled reports whether entries are signed
+func SigningEnabled() bool {
+	return signingKey.Load() != nil
+}
+
+// marshalLogData serializes a payload, signing it when a signing key is set.
+// A signed payload is marshaled in canonical form (see CanonicalJSON), which
+// is the signed form. SigField is then inserted as the first member, so that
+// the signed bytes are the entry with its leading "_sig" member removed.
+func marshalLogData(logData map[string]interface{}) ([]byte, error) {
+	truncateLogData(logData)  // Before signing, so the signature covers the stored strings
+	delete(logData, SigField) // A recovered entry is signed again
+	key := signingKey.Load()
+
+	var data []byte
+	var err error
+	if key != nil || canonicalJSON.Load() {
+		data, err = CanonicalJSON(logData)
+	} else {
+		data, err = json.Marshal(logData)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return data, nil
+	}
+	sig := `{"` + SigField + `":"` + Sign(*key, data) + `"`
+	if len(data) > 2 {
+		sig += ","
+	}
+	return append([]byte(sig), data[1:]...), nil
+}
+
+// Sign returns the hex HMAC-SHA256 of data under key
+func Sign(key, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether a serialized entry carries a valid
+// signature under key, by checking the HMAC of the entry without its
+// leading "_sig" member
+func VerifySignature(key, entry []byte) bool {
+	prefix := []byte(`{"` + SigField + `":"`)
+	if !bytes.HasPrefix(entry, prefix) {
+		return false
+	}
+	rest := entry[len(prefix):]
+	end := bytes.IndexByte(rest, '"')
+	if end < 0 {
+		return false
+	}
+	sig, signed := rest[:end], rest[end+1:]
+	if bytes.HasPrefix(signed, []byte(",")) {
+		signed = signed[1:]
+	}
+	signed = append([]byte("{"), signed...)
+	return hmac.Equal(sig, []byte(Sign(key, signed)))
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+	"github.com/go-redis/redis/v8"
+)
+
+// Sink is a pluggable destination for log entries. Push receives the
+// marshaled entries destined for a key in order; a non-nil error sends
+// the entries to the fallback directory for later recovery.
+type Sink interface {
+	Push(ctx context.Context, key string, entries [][]byte) error
+}
+
+// SinkFlusher is implemented by sinks holding entries back, e.g. to send
+// them in larger batches. Before a fatal entry exits the process, the sink
+// and the additional backends implementing it are flushed.
+type SinkFlusher interface {
+	Flush(ctx context.Context) error
+}
+
+// FlushSinks flushes the sink and the additional backends implementing SinkFlusher
+func (in *Instance) FlushSinks(ctx context.Context) {
+	sinks := []Sink{in.currentSink()}
+	for _, b := range registeredBackends() {
+		sinks = append(sinks, b.sink)
+	}
+	for _, s := range sinks {
+		if flusher, ok := s.(SinkFlusher); ok {
+			if err := flusher.Flush(ctx); err != nil {
+				in.logger.Warn("Sink flush failed", zlog.String("sink", fmt.Sprintf("%T", s)), zlog.Error(err))
+			}
+		}
+	}
+}
+
+// RedisSink is the Redis list destination as a Sink, e.g. to add a second
+// Redis server with AddBackend. Push prepends the entries to the list of
+// the key in one pipeline, trimming and expiring the list as
+// APPLG_MAX_LIST_LENGTH and APPLG_KEY_TTL configure. A logger without a sink
+// pushes to its own client the same way, also holding pushes back for a
+// lagging consumer and recording entry IDs for deduplicated recovery.
+type RedisSink struct {
+	Client RedisClient
+}
+
+// NewRedisSink creates a sink pushing to client
+func NewRedisSink(client RedisClient) *RedisSink {
+	return &RedisSink{Client: client}
+}
+
+// Push prepends entries to the list of key, returning the error of the
+// LPUSH; a list that cannot be trimmed or expired keeps its entries, and
+// the push still succeeds
+func (s *RedisSink) Push(ctx context.Context, key string, entries [][]byte) error {
+	if s.Client == nil {
+		return errRedisNotSet
+	}
+	values := make([]interface{}, len(entries))
+	for i, data := range entries {
+		values[i] = data
+	}
+	pipe := s.Client.Pipeline()
+	push := pipe.LPush(ctx, key, values...)
+	queueListUpkeep(pipe, []EncodedEntry{{Key: key}})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return failedPush([]*redis.IntCmd{push})
+	}
+	return nil
+}
+
+// SetSink sets the sink of the default instance
+func SetSink(s Sink) {
+	Default().SetSink(s)
+}
+
+// SetSink routes live pushes and recovery through the given sink instead of
+// Redis. Passing nil restores the Redis destination.
+func (in *Instance) SetSink(s Sink) {
+	in.clientMu.Lock()
+	defer in.clientMu.Unlock()
+	in.sink = s
+}
+
+// currentSink returns the sink of the instance, nil when entries go to Redis
+func (in *Instance) currentSink() Sink {
+	in.clientMu.RLock()
+	defer in.clientMu.RUnlock()
+	return in.sink
+}
+
+// sinkName describes the configured sink for diagnostics
+func (in *Instance) sinkName() string {
+	return fmt.Sprintf("sink:%T", in.currentSink())
+}
+
+// pushBatchToSink groups entries by key, preserving their order, and pushes each group to s
+func (in *Instance) pushBatchToSink(s Sink, entries []EncodedEntry) error {
+	var keys []string
+	grouped := make(map[string][][]byte)
+
+	for _, entry := range entries {
+		if _, ok := grouped[entry.Key]; !ok {
+			keys = append(keys, entry.Key)
+		}
+		grouped[entry.Key] = append(grouped[entry.Key], entry.Data)
+	}
+
+	for _, key := range keys {
+		if err := s.Push(ctx, key, grouped[key]); err != nil {
+			in.logger.Warn("Sink push failed", zlog.String("key", key), zlog.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// pushBatch sends logs to the configured sink, or to Redis when none is set
+func (in *Instance) pushBatch(logs []map[string]interface{}) error {
+	return in.pushEncoded(in.encodeBatch(logs))
+}
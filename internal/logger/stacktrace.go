@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// StackTraceField is the field holding the stack trace of error and fatal entries
+const StackTraceField = "stacktrace"
+
+// maxStackDepth is the number of frames a stack trace records at most
+const maxStackDepth = 64
+
+var (
+	stackTraceOnError atomic.Bool // Error entries carry a stack trace
+	stackTraceOnFatal atomic.Bool // Fatal entries carry a stack trace
+)
+
+// internalFrames are the prefixes of the functions left out of the top of a
+// stack trace, so that it starts at the call site of the logging method
+var internalFrames = []string{
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs.",
+	"github.com/bashx3r0/scala-applogs-client/internal/",
+	"go.uber.org/zap.",
+	"go.uber.org/zap/",
+}
+
+// loadStackTraceConfig reads APPLG_STACKTRACE_ON_ERROR and APPLG_STACKTRACE_ON_FATAL from the environment
+func loadStackTraceConfig() {
+	SetStackTraceOnError(getEnvAsBool("APPLG_STACKTRACE_ON_ERROR", false))
+	SetStackTraceOnFatal(getEnvAsBool("APPLG_STACKTRACE_ON_FATAL", true))
+}
+
+// SetStackTraceOnError sets whether error entries carry a stack trace
+func SetStackTraceOnError(enabled bool) {
+	stackTraceOnError.Store(enabled)
+}
+
+// SetStackTraceOnFatal sets whether fatal entries carry a stack trace
+func SetStackTraceOnFatal(enabled bool) {
+	stackTraceOnFatal.Store(enabled)
+}
+
+// StackTraceEnabled reports whether entries of level carry a stack trace
+func StackTraceEnabled(level string) bool {
+	switch level {
+	case "error":
+		return stackTraceOnError.Load()
+	case "fatal":
+		return stackTraceOnFatal.Load()
+	}
+	return false
+}
+
+// CallerStack formats the stack of the calling goroutine as Zap does, each
+// function followed by its file and line, starting at the first frame
+// outside the logger
+func CallerStack() string {
+	pcs := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	var b strings.Builder
+	for frame, more := frames.Next(); ; frame, more = frames.Next() {
+		if b.Len() > 0 || !isInternalFrame(frame.Function) {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// isInternalFrame reports whether function belongs to the logger or to Zap
+func isInternalFrame(function string) bool {
+	for _, prefix := range internalFrames {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
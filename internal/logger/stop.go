@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// Handling of entries logged once StopLogger has begun
+const (
+	StopFallback = "fallback" // Save the entry to fallback, recovered by the next run
+	StopDrop     = "drop"     // Discard the entry, emitting EventLogDropped
+)
+
+var stopPolicy atomic.Value // What happens to entries logged while the logger stops, a string; see StopPolicy
+
+// loadStopConfig reads APPLG_STOP_POLICY from the environment
+func loadStopConfig() {
+	SetStopPolicy(os.Getenv("APPLG_STOP_POLICY"))
+}
+
+// SetStopPolicy selects what happens to entries logged once StopLogger has
+// begun: StopFallback or StopDrop. Unknown policies select StopFallback.
+func SetStopPolicy(policy string) {
+	if policy != StopDrop {
+		policy = StopFallback
+	}
+	stopPolicy.Store(policy)
+}
+
+// StopPolicy returns the handling of entries logged while the logger stops
+func StopPolicy() string {
+	if policy, _ := stopPolicy.Load().(string); policy != "" {
+		return policy
+	}
+	return StopFallback
+}
@@ -0,0 +1,338 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// Defaults of the compressed syslog file
+const (
+	defaultSyslogRotateBytes = 64 << 20       // Uncompressed bytes written to a segment before it is rotated
+	syslogFlushInterval      = time.Second    // Longest time compressed entries stay buffered while logging goes on
+	syslogGzipSuffix         = ".log.gz"      // Extension of compressed segments
+	syslogSegmentPrefix      = "syslogs_"     // Prefix of syslog file names, followed by the creation time
+	syslogSegmentTimeFormat  = "020120061504" // Layout of the creation time in syslog file names
+)
+
+var (
+	syslogGzip        bool          // Whether the syslog file is written as rotating gzip segments
+	syslogRotateBytes int64         // Uncompressed size after which a compressed segment is rotated
+	syslogMu          sync.Mutex    // Guards the variables of the syslog file, written to by the logger of every instance
+	syslogWriter      io.Writer     // Writer of the syslog file core, closed when replaced
+	activeSegment     func() string // Path of the segment being written, nil for an uncompressed file
+	syslogFile        string        // Path of the uncompressed file being written, empty for segments
+)
+
+// loadSyslogConfig reads APPLG_SYSLOG_GZIP and APPLG_SYSLOG_ROTATE_BYTES,
+// applied when the syslog file is next opened
+func loadSyslogConfig() {
+	gzipped, rotateBytes := false, int64(defaultSyslogRotateBytes)
+	defer func() {
+		syslogMu.Lock()
+		defer syslogMu.Unlock()
+		syslogGzip, syslogRotateBytes = gzipped, rotateBytes
+	}()
+	if value := os.Getenv("APPLG_SYSLOG_GZIP"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			defaultLogger().Warn("Invalid APPLG_SYSLOG_GZIP. The syslog file is not compressed.", zlog.String("value", value))
+			return
+		}
+		gzipped = enabled
+	}
+	if value := os.Getenv("APPLG_SYSLOG_ROTATE_BYTES"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size <= 0 {
+			defaultLogger().Warn("Invalid APPLG_SYSLOG_ROTATE_BYTES. Using default value.",
+				zlog.String("value", value), zlog.Int64("default", defaultSyslogRotateBytes))
+			return
+		}
+		rotateBytes = size
+	}
+}
+
+// syslogSettings returns whether the syslog file is compressed and the size
+// its segments are rotated at
+func syslogSettings() (gzipped bool, rotateBytes int64) {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+	return syslogGzip, syslogRotateBytes
+}
+
+// openSyslogWriter closes the writer of a previous initialization and opens
+// the syslog file: a plain file appended to, or rotating gzip segments. The
+// syslog file is shared by the process: the returned writer forwards to the
+// one opened last, so the loggers of earlier instances move to it too. When
+// the file cannot be opened, the error is returned and entries only reach
+// the console.
+func openSyslogWriter() (io.Writer, error) {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+	if closer, ok := syslogWriter.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	activeSegment, syslogFile = nil, ""
+	if syslogGzip {
+		w := &gzipSegmentWriter{dir: syslogsPath, rotateBytes: syslogRotateBytes}
+		syslogWriter, activeSegment = w, w.Path
+		return sharedSyslog{}, nil
+	}
+	syslogFile = generateLogFilePath()
+	w, err := getLogWriter(syslogFile)
+	if err != nil {
+		w = io.Discard
+	}
+	syslogWriter = w
+	return sharedSyslog{}, err
+}
+
+// sharedSyslog writes to the syslog file opened last
+type sharedSyslog struct{}
+
+// Write writes p to the current syslog writer
+func (sharedSyslog) Write(p []byte) (int, error) {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+	if syslogWriter == nil {
+		return 0, os.ErrClosed
+	}
+	return syslogWriter.Write(p)
+}
+
+// Sync flushes the current syslog writer, if it buffers
+func (sharedSyslog) Sync() error {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+	if syncer, ok := syslogWriter.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
+// isActiveSegment reports whether path is the compressed segment being
+// written, which cleanup leaves alone whatever its modification time
+func isActiveSegment(path string) bool {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+	return activeSegment != nil && filepath.Clean(activeSegment()) == filepath.Clean(path)
+}
+
+// currentSyslogFile returns the path of the syslog file being written, a
+// compressed segment or the uncompressed file, empty before the first segment
+func currentSyslogFile() string {
+	syslogMu.Lock()
+	defer syslogMu.Unlock()
+	if activeSegment != nil {
+		return activeSegment()
+	}
+	return syslogFile
+}
+
+// trimSyslogFiles deletes the oldest syslog files beyond the syslog file
+// limit of the instance, whatever their age, counting and keeping the one
+// being written
+func (in *Instance) trimSyslogFiles() {
+	maxSyslogFiles := in.cfg.MaxSyslogFiles
+	if maxSyslogFiles == 0 {
+		return
+	}
+	entries, err := os.ReadDir(syslogsPath)
+	if err != nil {
+		return // Already reported by the age-based cleanup
+	}
+	type syslogEntry struct {
+		path    string
+		modTime time.Time
+	}
+	var files []syslogEntry
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), syslogSegmentPrefix) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, syslogEntry{filepath.Join(syslogsPath, entry.Name()), info.ModTime()})
+		}
+	}
+	if len(files) <= maxSyslogFiles {
+		return
+	}
+
+	currentFile := currentSyslogFile()
+	current := func(path string) bool {
+		return currentFile != "" && filepath.Clean(currentFile) == filepath.Clean(path)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if current(files[i].path) != current(files[j].path) {
+			return current(files[i].path) // The file being written comes first, however old
+		}
+		return files[i].modTime.After(files[j].modTime)
+	})
+	for _, file := range files[maxSyslogFiles:] {
+		if current(file.path) {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil {
+			in.logger.Error("Failed to delete old log file", zlog.String("file", file.path), zlog.Error(err))
+		} else {
+			in.logger.Info("Deleted log file beyond the syslog file limit", zlog.String("file", file.path), zlog.Int("max_files", maxSyslogFiles))
+		}
+	}
+}
+
+// gzipSegmentWriter writes the syslog file as a series of gzip segments,
+// each a complete gzip stream named syslogs_<time>.log.gz. Compressed output
+// is buffered and flushed at most every syslogFlushInterval, on Sync and on
+// rotation, so that successive entries share compression blocks. A segment
+// is closed and a new one started once rotateBytes of entries were written
+// to it.
+type gzipSegmentWriter struct {
+	mu          sync.Mutex
+	dir         string
+	rotateBytes int64
+	path        string       // Current segment, empty until the first write
+	file        *os.File     // Current segment file
+	gz          *gzip.Writer // Compressor writing to file
+	written     int64        // Uncompressed bytes written to the current segment
+	flushed     time.Time    // Last time compressed output was flushed to file
+	closed      bool
+}
+
+// Write compresses p into the current segment, rotating it first when full
+func (w *gzipSegmentWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.gz != nil && w.written > 0 && w.written+int64(len(p)) > w.rotateBytes {
+		if err := w.closeSegment(); err != nil {
+			return 0, err
+		}
+	}
+	if w.gz == nil {
+		if err := w.openSegment(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.gz.Write(p)
+	w.written += int64(n)
+	if err == nil && time.Since(w.flushed) >= syslogFlushInterval {
+		err = w.flush()
+	}
+	return n, err
+}
+
+// Sync flushes the buffered compressed output, leaving the segment open
+func (w *gzipSegmentWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.gz == nil {
+		return nil
+	}
+	return w.flush()
+}
+
+// Close finishes the current segment; later writes fail with os.ErrClosed
+func (w *gzipSegmentWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.gz == nil {
+		return nil
+	}
+	return w.closeSegment()
+}
+
+// Path returns the segment being written, empty before the first write
+func (w *gzipSegmentWriter) Path() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.path
+}
+
+// openSegment creates a segment named after the current time. Segments
+// rotated within the same minute get a sequence number, syslogs_<time>_<n>.
+func (w *gzipSegmentWriter) openSegment() error {
+	base := syslogSegmentPrefix + Now().Format(syslogSegmentTimeFormat)
+	path := filepath.Join(w.dir, base+syslogGzipSuffix)
+	for n := 1; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(w.dir, base+"_"+strconv.Itoa(n)+syslogGzipSuffix)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.path, w.file, w.gz = path, file, gzip.NewWriter(file)
+	w.written, w.flushed = 0, time.Now()
+	return nil
+}
+
+// flush writes the buffered compressed output to the segment file
+func (w *gzipSegmentWriter) flush() error {
+	w.flushed = time.Now()
+	return w.gz.Flush()
+}
+
+// closeSegment writes the gzip trailer and closes the segment file
+func (w *gzipSegmentWriter) closeSegment() error {
+	err := w.gz.Close()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file, w.gz = nil, nil
+	return err
+}
+
+// OpenSyslogFile opens a syslog file for reading, decompressing gzip
+// segments. A segment still being written reads up to its last flush; the
+// reader then fails with io.ErrUnexpectedEOF, after the complete entries.
+func OpenSyslogFile(path string) (io.ReadCloser, error) {
+	return openLogFile(path)
+}
+
+// openLogFile opens a local log file, syslog or fallback, decompressing it
+// when its name ends in .gz
+func openLogFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// gzipFile closes both the decompressor and the file it reads
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close closes the decompressor and the file
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if closeErr := g.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
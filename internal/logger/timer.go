@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+var timerLevel atomic.Value // Level of the entries logged by timers, a string
+
+// loadTimerConfig reads APPLG_TIMER_LEVEL from the environment
+func loadTimerConfig() {
+	SetTimerLevel(os.Getenv("APPLG_TIMER_LEVEL"))
+}
+
+// TimerLevel returns the level of the entries logged by timers
+func TimerLevel() string {
+	if level, ok := timerLevel.Load().(string); ok {
+		return level
+	}
+	return "info"
+}
+
+// SetTimerLevel sets the level of the entries logged by timers; unknown levels select info
+func SetTimerLevel(level string) {
+	if _, ok := LevelRank(level); !ok {
+		level = "info"
+	}
+	timerLevel.Store(level)
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	maxListLength atomic.Int64 // Entries kept in each Redis list, the newest ones; 0 disables trimming
+	keyTTL        atomic.Int64 // Expiry of the Redis lists as a time.Duration, refreshed by each push; 0 keeps them forever
+)
+
+// loadTrimConfig reads APPLG_MAX_LIST_LENGTH and APPLG_KEY_TTL (in seconds) from the environment
+func loadTrimConfig() {
+	SetMaxListLength(getEnvAsInt("APPLG_MAX_LIST_LENGTH", 0))
+	SetKeyTTL(time.Duration(getEnvAsInt("APPLG_KEY_TTL", 0)) * time.Second)
+}
+
+// SetMaxListLength caps every list entries are pushed to at n entries: each
+// pipeline trims the lists it pushed to with LTRIM key 0 n-1, once per list,
+// so that the oldest entries are dropped when the consumer stalls instead of
+// Redis running out of memory. 0 disables trimming.
+func SetMaxListLength(n int) {
+	if n < 0 {
+		n = 0
+	}
+	maxListLength.Store(int64(n))
+}
+
+// MaxListLength returns the cap of the Redis lists, 0 when they are not trimmed
+func MaxListLength() int {
+	return int(maxListLength.Load())
+}
+
+// SetKeyTTL makes every list entries are pushed to expire ttl after the last
+// push: each pipeline refreshes the expiry of the lists it pushed to, once
+// per list, so that active lists never expire while those of instances that
+// are gone clean themselves up. 0 keeps lists forever.
+func SetKeyTTL(ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	keyTTL.Store(int64(ttl))
+}
+
+// KeyTTL returns the expiry of the Redis lists, 0 when they do not expire
+func KeyTTL() time.Duration {
+	return time.Duration(keyTTL.Load())
+}
+
+// queueListUpkeep adds to pipe, after the LPUSH of entries, one LTRIM and
+// one EXPIRE for each list they are pushed to, as configured. The commands
+// are returned so that their failure is not taken for a failed push: the
+// entries are in Redis, and the next push retries the upkeep.
+func queueListUpkeep(pipe redis.Pipeliner, entries []EncodedEntry) map[redis.Cmder]bool {
+	maxLength, ttl := MaxListLength(), KeyTTL()
+	if maxLength == 0 && ttl == 0 {
+		return nil
+	}
+	upkeep := map[redis.Cmder]bool{}
+	done := make(map[string]bool, 1)
+	for _, entry := range entries {
+		if done[entry.Key] {
+			continue
+		}
+		done[entry.Key] = true
+		if maxLength > 0 {
+			upkeep[pipe.LTrim(ctx, entry.Key, 0, int64(maxLength-1))] = true
+		}
+		if ttl > 0 {
+			upkeep[pipe.Expire(ctx, entry.Key, ttl)] = true
+		}
+	}
+	return upkeep
+}
+
+// failedPush returns the error of the first of pushes that failed. Exec
+// returns the error of the first failed command of the pipeline, which may
+// be an upkeep or ID command coming after the LPUSH that stored the
+// entries, so the pushes are checked one by one instead.
+func failedPush(pushes []*redis.IntCmd) error {
+	for _, push := range pushes {
+		if err := push.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
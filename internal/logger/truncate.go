@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Ellipsis marks a string shortened to the length limit
+const Ellipsis = "…"
+
+var maxStringBytes atomic.Int64 // Length limit in bytes of the strings of a payload; 0 disables it
+
+// loadTruncateConfig reads APPLG_MAX_STRING_BYTES from the environment
+func loadTruncateConfig() {
+	SetMaxStringBytes(getEnvAsInt("APPLG_MAX_STRING_BYTES", 0))
+}
+
+// SetMaxStringBytes limits the message and every string value of the
+// metadata to n bytes, Ellipsis included. Longer strings are cut at a rune
+// boundary, so multi-byte text never ends up as invalid UTF-8. 0 disables
+// the limit.
+func SetMaxStringBytes(n int) {
+	if n < 0 {
+		n = 0
+	}
+	maxStringBytes.Store(int64(n))
+}
+
+// MaxStringBytes returns the length limit of payload strings, 0 when disabled
+func MaxStringBytes() int {
+	return int(maxStringBytes.Load())
+}
+
+// TruncateString shortens s to at most max bytes, ending with Ellipsis. The
+// cut is moved back to the start of a rune so that no rune is split. When
+// max cannot even hold Ellipsis, the string is cut without one.
+func TruncateString(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	suffix := Ellipsis
+	if max < len(suffix) {
+		suffix = ""
+	}
+	cut := max - len(suffix)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + suffix
+}
+
+// truncateLogData applies the length limit to the message and metadata of a
+// payload. The metadata is copied rather than changed, as it may still be
+// held by the caller.
+func truncateLogData(logData map[string]interface{}) {
+	max := MaxStringBytes()
+	if max == 0 {
+		return
+	}
+	for _, key := range []string{"message", "metadata"} {
+		if value, ok := logData[key]; ok {
+			logData[key] = truncateValue(value, max)
+		}
+	}
+}
+
+// truncateValue returns value with its strings shortened to max bytes
+func truncateValue(value interface{}, max int) interface{} {
+	switch v := value.(type) {
+	case string:
+		return TruncateString(v, max)
+	case map[string]interface{}:
+		if v == nil {
+			return value
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, nested := range v {
+			copied[key] = truncateValue(nested, max)
+		}
+		return copied
+	case []interface{}:
+		if v == nil {
+			return value
+		}
+		copied := make([]interface{}, len(v))
+		for i, nested := range v {
+			copied[i] = truncateValue(nested, max)
+		}
+		return copied
+	default:
+		return value
+	}
+}
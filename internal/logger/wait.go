@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// Delays between the pings of WaitForRedis, doubling from the first to the last
+const (
+	waitForRedisMinDelay = 100 * time.Millisecond
+	waitForRedisMaxDelay = time.Second
+)
+
+// WaitForRedis waits for the Redis server of the default instance
+func WaitForRedis(timeout time.Duration) error {
+	return Default().WaitForRedis(timeout)
+}
+
+// WaitForRedis pings Redis until it answers or timeout elapses, retrying
+// with a delay doubling from 100ms to a second. It returns nil once Redis is
+// connected, and ErrRedisUnavailable wrapping the last ping error otherwise,
+// entries being saved to fallback until Redis comes up.
+func (in *Instance) WaitForRedis(timeout time.Duration) error {
+	rdb := in.redisClient()
+	if rdb == nil {
+		return errRedisNotSet
+	}
+
+	deadline := time.Now().Add(timeout)
+	delay := waitForRedisMinDelay
+	for {
+		pingCtx, cancel := context.WithDeadline(ctx, deadline)
+		_, err := rdb.Ping(pingCtx).Result()
+		cancel()
+		if err == nil {
+			in.stopReconnect()
+			in.redisState.Store("")
+			in.observeRedisState("connected", nil)
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			in.redisState.Store("")
+			in.observeRedisState("unavailable", err)
+			return fmt.Errorf("%w: %v", ErrRedisUnavailable, err)
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+		time.Sleep(delay)
+		if delay *= 2; delay > waitForRedisMaxDelay {
+			delay = waitForRedisMaxDelay
+		}
+	}
+}
+
+// waitForRedisConnection runs WaitForRedis for the configured time during
+// initialization, logging and returning the outcome like checkRedisConnection
+func (in *Instance) waitForRedisConnection() error {
+	in.logger.Info("Waiting for Redis", zlog.Duration("timeout", in.cfg.WaitForRedis))
+	if err := in.WaitForRedis(in.cfg.WaitForRedis); err != nil {
+		in.logger.Error("Redis did not become available, logging to fallback",
+			zlog.String("address", config.RedactRedisAddr(in.cfg.RedisAddr)),
+			zlog.Error(err))
+		in.startReconnect(err)
+		return err
+	}
+	in.logger.Info("Connected to Redis successfully",
+		zlog.String("address", config.RedactRedisAddr(in.cfg.RedisAddr)))
+	return nil
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// WorkerPanicMessage is the message of the error entry recording a panic of the log worker
+const WorkerPanicMessage = "Log worker panicked"
+
+// ReportWorkerPanic records a panic recovered from the log worker before it
+// is restarted: the panic is counted in WorkerPanicsTotal, printed with its
+// stack to the last-resort output (stderr), emitted as EventWorkerPanic, and
+// saved to fallback as an error entry, so that it reaches Redis once the
+// worker delivers again
+func ReportWorkerPanic(value interface{}, stack []byte) {
+	Default().ReportWorkerPanic(value, stack)
+}
+
+// ReportWorkerPanic records a panic of the log worker of the instance, see
+// the package function
+func (in *Instance) ReportWorkerPanic(value interface{}, stack []byte) {
+	workerPanicsTotal.Add(1)
+	err := fmt.Errorf("log worker panicked: %v", value)
+	EmitEvent(Event{Type: EventWorkerPanic, Count: 1, Err: err})
+	output, _ := lostLogHandlers()
+	fmt.Fprintf(output, "applogs: %v\n%s\n", err, stack)
+
+	payload, encodeErr := EncodeLogData(NewLogDataAs(in.Identity(), "error", WorkerPanicMessage, map[string]interface{}{
+		"panic": fmt.Sprint(value),
+		"stack": string(stack),
+	}))
+	if encodeErr != nil {
+		in.logger.Error("Failed to marshal log data to JSON", zlog.Error(encodeErr))
+		return
+	}
+	in.SaveToFallback(payload)
+}
@@ -0,0 +1,155 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Console encoders selectable through APPLG_CONSOLE_ENCODER
+const (
+	EncoderJSON    = "json"
+	EncoderConsole = "console"
+	EncoderLogfmt  = "logfmt"
+)
+
+// NormalizeEncoder returns encoder if it is supported, otherwise EncoderJSON
+func NormalizeEncoder(encoder string) string {
+	switch encoder {
+	case EncoderConsole, EncoderLogfmt:
+		return encoder
+	}
+	return EncoderJSON
+}
+
+// metadataKey holds the user fields map; it is flattened without a prefix
+const metadataKey = "metadata"
+
+// JSONToLogfmt converts a single JSON object line into a logfmt line, keeping
+// the key order. The metadata object is flattened into plain key=value pairs,
+// other nested objects use dotted keys, and arrays are written as JSON.
+func JSONToLogfmt(line []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("logfmt: expected a JSON object")
+	}
+
+	var out bytes.Buffer
+	if err := writeLogfmtObject(&out, dec, ""); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// writeLogfmtObject writes the remaining members of an object whose opening brace was consumed
+func writeLogfmtObject(out *bytes.Buffer, dec *json.Decoder, prefix string) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+
+		// The metadata object is flattened without a prefix
+		if prefix == "" && key == metadataKey && isJSONObject(value) {
+			key = ""
+		} else {
+			key = prefix + key
+		}
+		if err := writeLogfmtValue(out, key, value); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token() // closing brace
+	return err
+}
+
+// writeLogfmtValue writes key=value, recursing into objects
+func writeLogfmtValue(out *bytes.Buffer, key string, value json.RawMessage) error {
+	trimmed := bytes.TrimSpace(value)
+	if isJSONObject(trimmed) {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.UseNumber()
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		prefix := ""
+		if key != "" {
+			prefix = key + "."
+		}
+		return writeLogfmtObject(out, dec, prefix)
+	}
+
+	var text string
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		if err := json.Unmarshal(trimmed, &text); err != nil {
+			return err
+		}
+	} else {
+		text = string(trimmed) // numbers, booleans, null and arrays
+	}
+
+	if out.Len() > 0 {
+		out.WriteByte(' ')
+	}
+	out.WriteString(logfmtKey(key))
+	out.WriteByte('=')
+	out.WriteString(logfmtQuote(text))
+	return nil
+}
+
+// isJSONObject reports whether a raw JSON value is an object
+func isJSONObject(value json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(value)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// logfmtKey replaces characters that would make a key ambiguous
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtQuote quotes values that are empty or contain spaces, quotes, '=' or control characters
+func logfmtQuote(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
+
+// logfmtWriter converts JSON lines written by the minimal logger to logfmt
+type logfmtWriter struct {
+	w io.Writer
+}
+
+func (l logfmtWriter) Write(p []byte) (int, error) {
+	line, err := JSONToLogfmt(p)
+	if err != nil {
+		return l.w.Write(p)
+	}
+	if _, err := l.w.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
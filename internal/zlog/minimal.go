@@ -0,0 +1,175 @@
+//go:build applogs_minimal
+
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Implementation names the logger backing the console and file output
+const Implementation = "minimal"
+
+// Logger is a lightweight JSON logger mirroring the subset of Zap's API used
+// by the client. Entries use Zap's production keys: level, ts, caller, msg.
+type Logger struct {
+	mu      sync.Mutex
+	writers []io.Writer
+}
+
+// Field is a key/value pair attached to an entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// levelRanks orders the levels by severity, for SetLevel
+var levelRanks = map[string]int32{"debug": 0, "info": 1, "warn": 2, "error": 3, "fatal": 4}
+
+// minRank is the rank of the minimum level written, shared by every logger
+var minRank atomic.Int32
+
+// SetLevel sets the minimum level written to the console and syslog file,
+// one of debug, info, warn, error and fatal
+func SetLevel(name string) {
+	if rank, ok := levelRanks[strings.ToLower(name)]; ok {
+		minRank.Store(rank)
+	}
+}
+
+// New builds a logger writing JSON entries to the syslog file and to the
+// console. The console supports EncoderJSON and EncoderLogfmt; EncoderConsole
+// is not available in minimal mode and falls back to JSON.
+func New(file, console io.Writer, consoleEncoder string) *Logger {
+	if NormalizeEncoder(consoleEncoder) == EncoderLogfmt {
+		console = logfmtWriter{console}
+	}
+	return &Logger{writers: []io.Writer{file, console}}
+}
+
+// Debug logs a message at debug level
+func (l *Logger) Debug(msg string, fields ...Field) { l.write("debug", msg, fields) }
+
+// Info logs a message at info level
+func (l *Logger) Info(msg string, fields ...Field) { l.write("info", msg, fields) }
+
+// Warn logs a message at warn level
+func (l *Logger) Warn(msg string, fields ...Field) { l.write("warn", msg, fields) }
+
+// Error logs a message at error level
+func (l *Logger) Error(msg string, fields ...Field) { l.write("error", msg, fields) }
+
+// Fatal logs a message at fatal level and exits the process, like Zap
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.write("fatal", msg, fields)
+	os.Exit(1)
+}
+
+// WriteFatal writes an entry at fatal level without exiting the process
+func WriteFatal(l *Logger, msg string, fields ...Field) { l.write("fatal", msg, fields) }
+
+// Sync flushes writers that support it
+func (l *Logger) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range l.writers {
+		if syncer, ok := w.(interface{ Sync() error }); ok {
+			_ = syncer.Sync()
+		}
+	}
+	return nil
+}
+
+// write encodes a single entry and writes it to every writer
+func (l *Logger) write(level, msg string, fields []Field) {
+	if levelRanks[level] < minRank.Load() {
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"level":`)
+	writeJSON(&buf, level)
+	buf.WriteString(`,"ts":`)
+	buf.WriteString(strconv.FormatFloat(float64(time.Now().UnixNano())/1e9, 'f', -1, 64))
+	if _, file, line, ok := runtime.Caller(2); ok {
+		buf.WriteString(`,"caller":`)
+		writeJSON(&buf, shortCaller(file)+":"+strconv.Itoa(line))
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSON(&buf, msg)
+	for _, field := range fields {
+		buf.WriteByte(',')
+		writeJSON(&buf, field.Key)
+		buf.WriteByte(':')
+		writeJSON(&buf, field.Value)
+	}
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range l.writers {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// shortCaller trims a file path to its last directory and file name, like Zap's short caller encoder
+func shortCaller(file string) string {
+	idx := strings.LastIndexByte(file, '/')
+	if idx == -1 {
+		return file
+	}
+	if idx = strings.LastIndexByte(file[:idx], '/'); idx == -1 {
+		return file
+	}
+	return file[idx+1:]
+}
+
+// writeJSON appends the JSON encoding of v, falling back to its string form
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%+v", v))
+	}
+	buf.Write(data)
+}
+
+// String constructs a field with a string value
+func String(key, value string) Field { return Field{key, value} }
+
+// Int constructs a field with an int value
+func Int(key string, value int) Field { return Field{key, value} }
+
+// Int64 constructs a field with an int64 value
+func Int64(key string, value int64) Field { return Field{key, value} }
+
+// Uint64 constructs a field with a uint64 value
+func Uint64(key string, value uint64) Field { return Field{key, value} }
+
+// Bool constructs a field with a bool value
+func Bool(key string, value bool) Field { return Field{key, value} }
+
+// Duration constructs a field with a time.Duration value, encoded in seconds like Zap
+func Duration(key string, value time.Duration) Field { return Field{key, value.Seconds()} }
+
+// Error constructs an "error" field from err
+func Error(err error) Field {
+	if err == nil {
+		return Field{"error", nil}
+	}
+	return Field{"error", err.Error()}
+}
+
+// Any constructs a field with an arbitrary value
+func Any(key string, value interface{}) Field { return Field{key, value} }
+
+// RawJSON constructs a field with a value already encoded as JSON, written
+// as it is rather than as a string
+func RawJSON(key string, value json.RawMessage) Field { return Field{key, value} }
@@ -0,0 +1,121 @@
+//go:build !applogs_minimal
+
+package zlog
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Implementation names the logger backing the console and file output
+const Implementation = "zap"
+
+// Logger writes structured entries to the console and syslog file
+type Logger = zap.Logger
+
+// Field is a typed key/value pair attached to an entry
+type Field = zap.Field
+
+// level is the minimum level of the console and syslog file cores, shared by
+// every logger New builds so that SetLevel applies at once
+var level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+// SetLevel sets the minimum level written to the console and syslog file,
+// one of debug, info, warn, error and fatal
+func SetLevel(name string) {
+	_ = level.UnmarshalText([]byte(name))
+}
+
+// noExit is a fatal hook writing the entry without exiting
+type noExit struct{}
+
+// OnWrite does nothing, leaving the process running
+func (noExit) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
+
+// WriteFatal writes an entry at fatal level without exiting the process
+func WriteFatal(l *Logger, msg string, fields ...Field) {
+	l.WithOptions(zap.WithFatalHook(noExit{}), zap.AddCallerSkip(1)).Fatal(msg, fields...)
+}
+
+// New builds a logger writing entries at the level set by SetLevel (debug by
+// default) and above to both the syslog file (always JSON) and the console
+// (using consoleEncoder). Zap's global logger is left alone, for other users
+// of Zap in the process.
+func New(file, console io.Writer, consoleEncoder string) *Logger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoder := zapcore.NewJSONEncoder(encoderConfig)
+
+	core := zapcore.NewTee(
+		zapcore.NewCore(encoder, zapcore.AddSync(file), level),                                             // File logging
+		zapcore.NewCore(newConsoleEncoder(consoleEncoder, encoderConfig), zapcore.AddSync(console), level), // Console logging
+	)
+
+	return zap.New(core, zap.AddCaller())
+}
+
+// newConsoleEncoder builds the encoder used by the console core
+func newConsoleEncoder(name string, cfg zapcore.EncoderConfig) zapcore.Encoder {
+	switch NormalizeEncoder(name) {
+	case EncoderConsole:
+		return zapcore.NewConsoleEncoder(cfg)
+	case EncoderLogfmt:
+		return logfmtEncoder{zapcore.NewJSONEncoder(cfg)}
+	}
+	return zapcore.NewJSONEncoder(cfg)
+}
+
+// logfmtEncoder renders entries with the JSON encoder and converts them to logfmt
+type logfmtEncoder struct {
+	zapcore.Encoder
+}
+
+func (e logfmtEncoder) Clone() zapcore.Encoder {
+	return logfmtEncoder{e.Encoder.Clone()}
+}
+
+func (e logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	line, err := JSONToLogfmt(buf.Bytes())
+	if err != nil {
+		return buf, nil // Keep the JSON line rather than losing the entry
+	}
+	buf.Reset()
+	_, _ = buf.Write(line)
+	return buf, nil
+}
+
+// String constructs a field with a string value
+func String(key, value string) Field { return zap.String(key, value) }
+
+// Int constructs a field with an int value
+func Int(key string, value int) Field { return zap.Int(key, value) }
+
+// Int64 constructs a field with an int64 value
+func Int64(key string, value int64) Field { return zap.Int64(key, value) }
+
+// Uint64 constructs a field with a uint64 value
+func Uint64(key string, value uint64) Field { return zap.Uint64(key, value) }
+
+// Bool constructs a field with a bool value
+func Bool(key string, value bool) Field { return zap.Bool(key, value) }
+
+// Duration constructs a field with a time.Duration value
+func Duration(key string, value time.Duration) Field { return zap.Duration(key, value) }
+
+// Error constructs an "error" field from err
+func Error(err error) Field { return zap.Error(err) }
+
+// Any constructs a field with an arbitrary value
+func Any(key string, value interface{}) Field { return zap.Any(key, value) }
+
+// RawJSON constructs a field with a value already encoded as JSON, written
+// as it is rather than as a string
+func RawJSON(key string, value json.RawMessage) Field { return zap.Reflect(key, value) }
@@ -0,0 +1,6 @@
+// Package zlog is the structured logger used for the console and syslog file
+// output. By default it is a thin alias of Uber Zap; building with the
+// applogs_minimal tag replaces it with a lightweight JSON encoder so that
+// embedded users do not link Zap at all. Redis, fallback and recovery are
+// unaffected by the choice.
+package zlog
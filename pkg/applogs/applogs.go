@@ -1,81 +1,1342 @@
 package applogs
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bashx3r0/scala-applogs-client/config"
 	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
 	"github.com/go-redis/redis/v8" // Importing redis package
-	"go.uber.org/zap"
 )
 
+// Config is the resolved configuration of the logger
+type Config = config.Config
+
+// overflowPoll is the interval at which a caller blocked by a full queue
+// retries queuing its entry
+const overflowPoll = time.Millisecond
+
+// DeadlineField is the reserved field holding a "deliver by" deadline, either a
+// time.Time or a time.Duration relative to the log call. Entries still queued
+// past their deadline are delivered on the configured priority path instead.
+const DeadlineField = "_deadline"
+
+// ImportantField is the reserved field marking an entry as important when
+// set to true. Important entries are pushed to a separate list (the usual key
+// followed by ":important") so that trimming the main list never evicts them.
+const ImportantField = "_important"
+
+// EntryIDField holds the unique ID of every pushed entry, for consumers to dedupe on
+const EntryIDField = logger.EntryIDField
+
+// KeyField holds the Redis key an entry was pushed to, see SetKeyField
+const KeyField = logger.KeyField
+
+// SchemaField holds the payload format version of an entry, see SetSchemaField
+const SchemaField = logger.SchemaField
+
+// SchemaVersion is the current payload format version, recorded in
+// SchemaField. It is bumped when the layout of entries changes in a way
+// consumers must handle; adding an optional field does not bump it.
+const SchemaVersion = logger.SchemaVersion
+
+// SigField holds the HMAC-SHA256 signature of an entry, see SetSigningKey
+const SigField = logger.SigField
+
+// VerifySignature reports whether a serialized entry, as read from Redis,
+// carries a valid signature under key
+func VerifySignature(key, entry []byte) bool {
+	return logger.VerifySignature(key, entry)
+}
+
+// OpenSyslogFile opens a local syslog file for reading, decompressing the
+// .log.gz segments written when APPLG_SYSLOG_GZIP is set
+func OpenSyslogFile(path string) (io.ReadCloser, error) {
+	return logger.OpenSyslogFile(path)
+}
+
+// Identity names the service instance entries are logged for
+type Identity = logger.Identity
+
+// FallbackBacklog describes the fallback entries waiting to be recovered
+type FallbackBacklog = logger.FallbackBacklog
+
+// Clock tells the time entries are stamped with, see SetClock
+type Clock = logger.Clock
+
+// ClockFunc adapts a function to Clock, e.g. one returning a fixed time in tests
+type ClockFunc = logger.ClockFunc
+
+// Sink is a pluggable destination replacing the Redis list push.
+// Any error returned by Push sends the entries to the fallback directory.
+type Sink = logger.Sink
+
+// RedisSink is the Redis list destination as a Sink, e.g. for a second Redis
+// server added with AddBackend
+type RedisSink = logger.RedisSink
+
+// Behaviors of SetBackpressure when a list is above the high-water mark
+const (
+	BackpressureBlock    = logger.BackpressureBlock    // Wait for the consumer, then divert to fallback
+	BackpressureFallback = logger.BackpressureFallback // Divert to fallback immediately
+)
+
+// OverflowPolicy is what happens to an entry logged while the queue is full, see SetOverflowPolicy
+type OverflowPolicy = logger.OverflowPolicy
+
+// Overflow policies without a timeout
+var (
+	OverflowDrop  = OverflowPolicy{Mode: logger.OverflowDrop}  // Drop debug and info entries, save the others to fallback
+	OverflowBlock = OverflowPolicy{Mode: logger.OverflowBlock} // Wait for room in the queue as long as it takes
+)
+
+// BlockWithTimeout is the overflow policy waiting at most timeout for room
+// in the queue, then saving the entry to fallback
+func BlockWithTimeout(timeout time.Duration) OverflowPolicy {
+	return OverflowPolicy{Mode: logger.OverflowBlock, Timeout: timeout}
+}
+
+// Handling of entries logged once StopLogger has begun, see SetStopPolicy
+const (
+	StopFallback = logger.StopFallback // Save the entry to fallback
+	StopDrop     = logger.StopDrop     // Discard the entry
+)
+
+// Behaviors of fatal entries, see SetFatalMode
+const (
+	FatalExit  = logger.FatalExit  // Exit with status 1 once the entry is pushed
+	FatalPanic = logger.FatalPanic // Deliver the entry, then panic on the caller's goroutine
+	FatalNone  = logger.FatalNone  // Log the entry and return
+)
+
+// ErrorClass tells the push path how to handle a failed push, see SetClassifyError
+type ErrorClass = logger.ErrorClass
+
+const (
+	ErrorUnavailable = logger.ErrorUnavailable // Save the entries to fallback
+	ErrorTransient   = logger.ErrorTransient   // Retry the push a few times, then fall back
+	ErrorFatal       = logger.ErrorFatal       // Log the error and drop the entries
+)
+
+// DefaultClassifyError is the built-in classifier: network errors (refused
+// connections, timeouts, DNS failures, closed connections), TLS and
+// authentication failures are ErrorUnavailable and anything else is ErrorFatal
+func DefaultClassifyError(err error) ErrorClass {
+	return logger.DefaultClassifyError(err)
+}
+
 // logEntry represents a single log entry for asynchronous processing
 type logEntry struct {
-	level   string
-	message string
-	fields  map[string]interface{}
+	level     string
+	message   string
+	component string   // Set by loggers created with Named
+	facility  string   // Set by loggers created with WithFacility
+	identity  Identity // Captured when the entry is logged, so that a later SetIdentity does not move it
+	fields    map[string]interface{}
+	deadline  time.Time    // Zero when the entry has no delivery deadline
+	important bool         // Pushed to the important list, see ImportantField
+	summary   bool         // Compact entry pushed to the summary list, see LogSummary
+	claimed   *atomic.Bool // Set once the worker or the deadline watcher takes the entry
+
+	// Set instead of fields when the entry was serialized at enqueue time
+	encoded  *logger.EncodedEntry
+	metadata json.RawMessage
+
+	// Set on the marker queued by Flush, closed once the entries before it are out
+	flushed chan struct{}
+}
+
+// encode serializes the entry so that the queue no longer references the
+// caller's fields map, which may then be mutated or released freely
+func (e *logEntry) encode() error {
+	metadata, err := json.Marshal(e.fields)
+	if err != nil {
+		return err
+	}
+	logData := newLogData(*e)
+	logData["metadata"] = json.RawMessage(metadata)
+	encoded, err := logger.EncodeLogData(logData)
+	if err != nil {
+		return err
+	}
+
+	e.encoded = &encoded
+	e.metadata = metadata
+	e.fields = nil
+	return nil
+}
+
+// claim marks the entry as taken, returning false if it was already delivered elsewhere
+func (e logEntry) claim() bool {
+	return e.claimed == nil || e.claimed.CompareAndSwap(false, true)
 }
 
-// Applogs client structure
+// Applogs client structure. Most setters, such as SetLevel, SetSigningKey or
+// AddBackend, change settings shared by the process, and so every logger;
+// those of the identity, Redis client, sink and fallback path are its own.
 type Applogs struct {
-	logQueue chan logEntry // Buffered channel for asynchronous logging
+	queue     Queue        // Entries waiting for the worker; nil for synchronous and nop loggers
+	nop       bool         // Discards every entry, see NewNopLogger
+	sync      bool         // Delivers entries on the caller's goroutine, see NewTestLogger
+	throttle  *throttler   // Occurrence counts for WarnThrottled
+	component string       // Component name set by Named
+	facility  string       // Facility override set by WithFacility
+	heartbeat *heartbeat   // Periodic heartbeat, see SetHeartbeatInterval
+	stats     *heartbeat   // Periodic runtime stats, see SetRuntimeStats
+	saturated *atomic.Bool // Set while the queue overflows, to emit EventQueueSaturated once
+	stop      *stopGate    // Shared by every view of the logger, see StopLogger
+
+	// Identity, Redis client, fallback directory and background loops of the
+	// logger, shared by its views; nil for nop loggers
+	core *logger.Instance
+
+	// Base fields added to every entry, set by WithFields and never modified
+	// once set
+	fields map[string]interface{}
+}
+
+// stopGate keeps concurrent logging calls from sending to a queue StopLogger
+// is closing. Entries are queued under the read lock; StopLogger sets closing
+// under the write lock, after which no call reaches the queue.
+type stopGate struct {
+	mu       sync.RWMutex
+	closing  atomic.Bool
+	stopping chan struct{} // Closed when StopLogger begins, releasing Consume
+	done     chan struct{} // Closed once the worker has flushed the last queued entry
+}
+
+// ErrStopTimeout is returned by StopLoggerWithTimeout when the queue is not
+// drained in time
+var ErrStopTimeout = errors.New("log queue not drained before the stop timeout")
+
+// ErrRedisUnavailable is returned by WaitForRedis when Redis does not answer
+// in time, and by NewLoggerWithConfig with Config.RequireRedis
+var ErrRedisUnavailable = logger.ErrRedisUnavailable
+
+// DefaultQueueSize is the queue capacity of NewLoggerWithConfig when Config.QueueSize is 0
+const DefaultQueueSize = 100
+
+// NewLoggerWithConfig initializes a logger from cfg rather than from the
+// environment alone. The identity, Redis address, credentials and TLS
+// settings, startup wait for Redis and whether Redis is required, fallback
+// path, fallback resync time, syslog keep time, cleanup settings, console
+// encoder, level and queue size are taken from cfg, and the fields left zero
+// are read from the environment as NewLogger does. The resolved
+// configuration is validated, and nothing is initialized when it is invalid,
+// including when another field of cfg, a setting shared by the process, is
+// set. A log directory that cannot be created, a syslog file that cannot be
+// opened, an invalid Redis address or, with RequireRedis, ErrRedisUnavailable
+// once WaitForRedis has elapsed is returned as an error too, along with the
+// logger: like
+// NewLogger's, it runs without what failed, InitError reporting the same
+// error, and it is the default one, so stop it when not carrying on.
+//
+// Like every logger, the returned one has its own identity, Redis
+// connection and fallback directory, so loggers for different services,
+// facilities or Redis servers can run side by side. Settings such as
+// batching, levels and redaction remain shared by the process, read from
+// the environment by the first logger created; see ReloadSettings.
+func NewLoggerWithConfig(cfg Config) (*Applogs, error) {
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	cfg = logger.ResolveConfig(cfg)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	core, err := logger.NewInstance(cfg)
+	queue := NewChannelQueue(cfg.QueueSize)
+	if logger.PriorityQueueEnabled() {
+		queue = NewPriorityQueue(cfg.QueueSize)
+	}
+	return newLoggerWithQueue(core, queue), err
+}
+
+// ReloadSettings reads the settings shared by the process, such as
+// batching, list trimming and redaction, from the environment again,
+// replacing those set at runtime. Only the first logger created reads them,
+// so that creating another one leaves the settings in effect alone.
+func ReloadSettings() {
+	logger.ReloadSettings()
 }
 
-// NewLogger initializes the logger and sets up the log queue
+// NewLogger initializes the logger and sets up the log queue: a buffered
+// channel of queueSize entries, or a priority queue with APPLG_PRIORITY_QUEUE.
+// The logger runs without what could not be initialized, InitError
+// reporting it.
 func NewLogger(queueSize int) *Applogs {
-	logger.InitApplogs()
+	core, _ := logger.NewInstance(Config{})
+	if logger.PriorityQueueEnabled() {
+		return newLoggerWithQueue(core, NewPriorityQueue(queueSize))
+	}
+	return newLoggerWithQueue(core, NewChannelQueue(queueSize))
+}
+
+// MustNewLogger initializes the logger like NewLogger, panicking with
+// InitError when something could not be initialized, for programs that must
+// not start without their log directories, syslog file and a valid Redis
+// address
+func MustNewLogger(queueSize int) *Applogs {
+	log := NewLogger(queueSize)
+	if err := log.InitError(); err != nil {
+		panic(err)
+	}
+	return log
+}
+
+// NewLoggerWithQueue initializes the logger with a custom queue, e.g. a
+// persistent or disk-backed one, instead of the default channel. See Queue
+// for the contract the implementation must follow.
+func NewLoggerWithQueue(queue Queue) *Applogs {
+	core, _ := logger.NewInstance(Config{})
+	return newLoggerWithQueue(core, queue)
+}
+
+// NewLoggerWithSink initializes the logger like NewLogger, logging through
+// sink instead of Redis from the start: no Redis client is created, so an
+// environment without Redis logs nothing about a missing connection.
+func NewLoggerWithSink(queueSize int, sink Sink) *Applogs {
+	core, _ := logger.NewInstanceWithSink(Config{}, sink)
+	if logger.PriorityQueueEnabled() {
+		return newLoggerWithQueue(core, NewPriorityQueue(queueSize))
+	}
+	return newLoggerWithQueue(core, NewChannelQueue(queueSize))
+}
+
+// NewRedisSink creates a sink pushing to client the way the logger pushes
+// to its own Redis client, without backpressure or deduplicated recovery
+func NewRedisSink(client *redis.Client) *RedisSink {
+	if client == nil {
+		return &RedisSink{} // Pushes fail, as they do without a Redis client
+	}
+	return logger.NewRedisSink(client)
+}
+
+// newLoggerWithQueue starts the worker and heartbeat of a logger reading queue
+func newLoggerWithQueue(core *logger.Instance, queue Queue) *Applogs {
 	applogs := &Applogs{
-		logQueue: make(chan logEntry, queueSize), // Buffered log queue
+		core:      core,
+		queue:     queue,
+		throttle:  newThrottler(),
+		heartbeat: &heartbeat{},
+		stats:     &heartbeat{},
+		saturated: new(atomic.Bool),
+		stop:      &stopGate{stopping: make(chan struct{}), done: make(chan struct{})},
 	}
 	go applogs.processLogs() // Start log processing in a separate goroutine
+	applogs.SetHeartbeatInterval(logger.HeartbeatInterval())
+	applogs.SetRuntimeStats(logger.RuntimeStatsInterval(), logger.RuntimeStats()...)
 	return applogs
 }
 
+// NewTestLogger initializes a logger for unit tests: entries are delivered
+// synchronously on the caller's goroutine, and neither the worker nor the
+// periodic recovery and cleanup loops are started, so no goroutine outlives
+// the test. Recovery can still be run explicitly.
+func NewTestLogger() *Applogs {
+	core, _ := logger.NewTestInstance()
+	return newTestLogger(core)
+}
+
+// newTestLogger creates a logger delivering entries to core on the caller's goroutine
+func newTestLogger(core *logger.Instance) *Applogs {
+	return &Applogs{
+		core:      core,
+		sync:      true,
+		throttle:  newThrottler(),
+		heartbeat: &heartbeat{},
+		stats:     &heartbeat{},
+	}
+}
+
+// InitError returns what failed when the logger was initialized: a log
+// directory that could not be created, a syslog file that could not be
+// opened, or an invalid Redis address or TLS setting. The logger then logs
+// to the console, and to fallback when Redis is missing, as far as it can.
+func (a *Applogs) InitError() error {
+	return a.instance().InitError()
+}
+
+// RecoverFallbackLogs runs a single fallback recovery pass on the caller's
+// goroutine, returning once this instance's fallback files have been resent
+// to Redis, the sink and the additional backends. Combined with
+// NewTestLogger, it tests recovery without waiting for the periodic pass.
+func (a *Applogs) RecoverFallbackLogs() {
+	a.instance().RecoverFallbackLogs()
+}
+
 // SetFallbackPath allows the fallback path to be set dynamically for testing
 func (a *Applogs) SetFallbackPath(path string) {
-	logger.SetFallbackPath(path)
+	a.instance().SetFallbackPath(path)
 }
 
 // SetRedisClient allows a mock Redis client to be injected for testing
 func (a *Applogs) SetRedisClient(mockClient *redis.Client) {
-	logger.SetRedisClient(mockClient)
+	a.instance().SetRedisClient(mockClient)
+}
+
+// WaitForRedis pings Redis until it answers or timeout elapses, returning
+// ErrRedisUnavailable when it does not, for startups that would rather fail
+// than log to fallback. Config.WaitForRedis (APPLG_WAIT_FOR_REDIS) waits the
+// same way during initialization, then carries on in fallback mode.
+func (a *Applogs) WaitForRedis(timeout time.Duration) error {
+	return a.instance().WaitForRedis(timeout)
+}
+
+// IsHealthy reports whether entries currently reach Redis (or the sink),
+// false while the client is reconnecting and logging to fallback
+func (a *Applogs) IsHealthy() bool {
+	return a.instance().IsHealthy()
+}
+
+// SetReconnectBackoff sets the delay before the first reconnection ping once
+// pushes keep failing, doubled after each attempt up to max
+func (a *Applogs) SetReconnectBackoff(base, max time.Duration) {
+	logger.SetReconnectBackoff(base, max)
+}
+
+// FallbackStatus returns the fallback files of this instance pending
+// recovery, their size and the age of their oldest entry, the delay of log
+// delivery to alert on
+func (a *Applogs) FallbackStatus() FallbackBacklog {
+	return a.instance().FallbackStatus()
+}
+
+// SetBacklogMaxAge makes recovery passes warn and emit EventBacklogDelayed
+// while the oldest pending fallback entry is older than maxAge; 0 disables it
+func (a *Applogs) SetBacklogMaxAge(maxAge time.Duration) {
+	logger.SetBacklogMaxAge(maxAge)
+}
+
+// SetFallbackWriter saves the entries that cannot be delivered as NDJSON
+// lines on w instead of fallback files, for hosts without a usable disk.
+// They are then not recovered by the client. nil restores fallback files.
+func (a *Applogs) SetFallbackWriter(w io.Writer) {
+	logger.SetFallbackWriter(w)
+}
+
+// SetCompressFallback writes fallback files gzip-compressed, as .log.gz;
+// recovery reads both compressed and plain files
+func (a *Applogs) SetCompressFallback(enabled bool) {
+	logger.SetCompressFallback(enabled)
+}
+
+// SetMaxFallbackFileBytes rotates a fallback file once it would grow past
+// maxBytes, so that recovery reads bounded files; 0 restores 16 MiB
+func (a *Applogs) SetMaxFallbackFileBytes(maxBytes int64) {
+	logger.SetMaxFallbackFileBytes(maxBytes)
+}
+
+// SetMaxFallbackBytes bounds the fallback directory at maxBytes, dropping
+// the oldest fallback files, then refusing entries, past it; 0 leaves it
+// unbounded
+func (a *Applogs) SetMaxFallbackBytes(maxBytes int64) {
+	logger.SetMaxFallbackBytes(maxBytes)
+}
+
+// FallbackDroppedTotal returns the number of entries dropped to keep the
+// fallback directory within its budget
+func (a *Applogs) FallbackDroppedTotal() uint64 {
+	return logger.FallbackDroppedTotal()
+}
+
+// SetClock replaces the clock of the timestamps, for tests asserting exact
+// times or aging local files for cleanup: those of entries, request and
+// response entries, recovery marks and events, the names of the syslog and
+// fallback files, and the age cleanup compares with the keep time. nil
+// restores the wall clock. Delays and timeouts, and the timestamps of
+// console and syslog file lines, keep the wall clock. The clock is shared
+// by the process.
+func (a *Applogs) SetClock(c Clock) {
+	logger.SetClock(c)
+}
+
+// SetSink routes logs through a custom sink instead of Redis; nil restores Redis
+func (a *Applogs) SetSink(s Sink) {
+	a.instance().SetSink(s)
+}
+
+// SetSchemaField records SchemaVersion in every payload under SchemaField, so
+// that consumers can branch on the format of each entry while producers of
+// different versions coexist
+func (a *Applogs) SetSchemaField(enabled bool) {
+	logger.SetSchemaField(enabled)
+}
+
+// SetKeyField records in every payload the key it is pushed to, under
+// KeyField, for consumers reading from several lists
+func (a *Applogs) SetKeyField(enabled bool) {
+	logger.SetKeyField(enabled)
+}
+
+// SetSigningKey signs every entry with HMAC-SHA256 under key, in SigField,
+// so that consumers can detect entries altered after they were written.
+// An empty key disables signing. The key is shared by the process, so it
+// signs the entries of every logger.
+func (a *Applogs) SetSigningKey(key []byte) {
+	logger.SetSigningKey(key)
+}
+
+// SetCanonicalJSON serializes every payload in canonical form, keys sorted at
+// every depth whatever Go type holds them, so that equal entries are equal
+// bytes, e.g. for golden tests. Signed payloads always are canonical.
+func (a *Applogs) SetCanonicalJSON(enabled bool) {
+	logger.SetCanonicalJSON(enabled)
+}
+
+// CanonicalJSON serializes v in the canonical form used for signed entries:
+// object keys sorted by their UTF-8 bytes at every depth and no whitespace
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	return logger.CanonicalJSON(v)
+}
+
+// AddBackend sends every entry to s as well as to Redis or the sink. A
+// failing backend saves its entries to a subdirectory of the fallback path
+// named after it, and recovery resends them to that backend only. Adding a
+// name again replaces the backend; a nil sink removes it. Backends are
+// shared by the process: every logger sends its entries to them.
+func (a *Applogs) AddBackend(name string, s Sink) {
+	logger.AddBackend(name, s)
+}
+
+// SetHeaderFormat selects how LogRequest records headers: HeaderFormatRaw,
+// HeaderFormatObject or HeaderFormatPrefixed
+func (a *Applogs) SetHeaderFormat(format string) {
+	logger.SetHeaderFormat(format)
+}
+
+// SetLogQueryParams makes LogRequest record the query parameters of the URL
+// in QueryField, as ValuesFields converts them. Sensitive parameters are
+// redacted there and in the logged URL.
+func (a *Applogs) SetLogQueryParams(enabled bool) {
+	logger.SetLogQueryParams(enabled)
+}
+
+// SetClassifyError overrides how push errors are classified, for Redis
+// proxies that report a down backend with their own error text. A custom
+// classifier can delegate to DefaultClassifyError; nil restores the default.
+func (a *Applogs) SetClassifyError(fn func(err error) ErrorClass) {
+	logger.SetClassifyError(fn)
+}
+
+// SetDedupeRecovery makes pushes remember entry IDs for window, and recovery
+// skip entries already delivered: a push processed by Redis whose reply was
+// lost is saved to fallback too, and would otherwise be delivered twice.
+// It applies to Redis only, not to custom sinks.
+func (a *Applogs) SetDedupeRecovery(enabled bool, window time.Duration) {
+	logger.SetDedupeRecovery(enabled, window)
+}
+
+// SetRecoveryRate limits recovery to perSecond entries per second, so that a
+// backlog saved during an outage drains gradually instead of flooding Redis
+// as it comes back. 0 removes the limit.
+func (a *Applogs) SetRecoveryRate(perSecond int) {
+	logger.SetRecoveryRate(perSecond)
+}
+
+// SetMarkRecovered tags entries replayed from fallback with "recovered": true
+// and "recovered_at", their "timestamp" still being the original time
+func (a *Applogs) SetMarkRecovered(enabled bool) {
+	logger.SetMarkRecovered(enabled)
+}
+
+// SetBackpressure holds pushes back while a Redis list is longer than
+// highWater entries, 0 disabling it. With BackpressureBlock the push waits up
+// to wait for the consumer to catch up, stalling the worker so the queue
+// absorbs the pressure; with BackpressureFallback, or once the wait is over,
+// the entries are saved to fallback and recovered later.
+func (a *Applogs) SetBackpressure(highWater int, mode string, wait time.Duration) {
+	logger.SetBackpressure(highWater, mode, wait)
+}
+
+// SetMaxListLength keeps only the newest n entries of each Redis list,
+// trimming it with LTRIM once per list and batch; 0 leaves lists unbounded.
+// Unlike backpressure, entries the consumer has not read yet are dropped.
+func (a *Applogs) SetMaxListLength(n int) {
+	logger.SetMaxListLength(n)
+}
+
+// SetKeyTTL makes each Redis list expire ttl after the last push to it, so
+// that the lists of instances that are gone clean themselves up; 0 keeps
+// lists forever
+func (a *Applogs) SetKeyTTL(ttl time.Duration) {
+	logger.SetKeyTTL(ttl)
+}
+
+// OnLostLog registers a callback invoked with every entry that could be written
+// to neither Redis nor the fallback disk. Such entries are also printed to
+// stderr and counted in LogsLostTotal. The callback is shared by the process,
+// so it receives the entries every logger lost.
+func (a *Applogs) OnLostLog(fn func(entry map[string]interface{})) {
+	logger.SetOnLostLog(fn)
+}
+
+// LogsLostTotal returns the number of entries lost because both Redis and the fallback disk failed
+func (a *Applogs) LogsLostTotal() uint64 {
+	return logger.LogsLostTotal()
+}
+
+// SetLoadShedding sheds debug entries while the queue is filled above percent
+// of its capacity, and info entries too once it is past halfway between
+// percent and full, so that warnings and errors keep getting through under
+// load. 0 disables shedding.
+func (a *Applogs) SetLoadShedding(percent int) {
+	logger.SetLoadShedding(percent)
+}
+
+// SetMaxStringBytes limits the message and every string of the fields to n
+// bytes. Longer strings are cut at a rune boundary and end with an ellipsis.
+// 0 disables the limit.
+func (a *Applogs) SetMaxStringBytes(n int) {
+	logger.SetMaxStringBytes(n)
+}
+
+// SetStopPolicy selects what happens to entries logged once StopLogger has
+// begun: StopFallback saves them to fallback for the next run to recover,
+// StopDrop discards them
+func (a *Applogs) SetStopPolicy(policy string) {
+	logger.SetStopPolicy(policy)
+}
+
+// SetFatalMode selects what happens once a fatal entry is logged. FatalExit,
+// the default, exits the process once the entry is pushed and the entries
+// queued behind it are flushed. FatalPanic
+// delivers the entry before the call returns, then panics with its message,
+// so that deferred functions and a top-level recover run. FatalNone logs
+// the entry at fatal severity without terminating anything, for libraries
+// embedding the client in a host process.
+func (a *Applogs) SetFatalMode(mode string) {
+	logger.SetFatalMode(mode)
+}
+
+// SetFatalExitCode sets the status the process exits with in FatalExit
+// mode, 1 by default, like APPLG_FATAL_EXIT_CODE
+func (a *Applogs) SetFatalExitCode(code int) {
+	logger.SetFatalExitCode(code)
+}
+
+// SetFatalFlushTimeout sets how long a fatal entry waits in FatalExit mode
+// for the entries queued behind it to be delivered and for the sinks to be
+// flushed before the process exits, 5 seconds by default, like
+// APPLG_FATAL_FLUSH_TIMEOUT. The fatal entry itself is always delivered
+// first, to Redis or to fallback.
+func (a *Applogs) SetFatalFlushTimeout(timeout time.Duration) {
+	logger.SetFatalFlushTimeout(timeout)
+}
+
+// SetStackTraceOnError sets whether error entries carry the stack trace of
+// their call site in a "stacktrace" field, like APPLG_STACKTRACE_ON_ERROR.
+// It is off by default.
+func (a *Applogs) SetStackTraceOnError(enabled bool) {
+	logger.SetStackTraceOnError(enabled)
+}
+
+// SetStackTraceOnFatal sets whether fatal entries carry the stack trace of
+// their call site, like APPLG_STACKTRACE_ON_FATAL. It is on by default.
+func (a *Applogs) SetStackTraceOnFatal(enabled bool) {
+	logger.SetStackTraceOnFatal(enabled)
+}
+
+// LogsShedTotal returns the number of entries dropped by load shedding
+func (a *Applogs) LogsShedTotal() uint64 {
+	return logger.LogsShedTotal()
+}
+
+// LogsDroppedTotal returns the number of debug and info entries of the
+// logger and its views dropped because the queue was full, under
+// OverflowDrop. Under OverflowBlock entries are not dropped: they wait, then
+// go to fallback once the timeout is over.
+func (a *Applogs) LogsDroppedTotal() uint64 {
+	return a.instance().Counters().Dropped
+}
+
+// SetOverflowPolicy selects what happens to an entry logged while the queue
+// is full, like APPLG_OVERFLOW_POLICY and APPLG_OVERFLOW_TIMEOUT: OverflowDrop,
+// the default, drops debug and info entries and saves warn, error and fatal
+// entries to fallback; OverflowBlock makes the caller wait for room in the
+// queue, whatever the level; BlockWithTimeout(d) waits at most d, then saves
+// the entry to fallback. A caller waiting when StopLogger begins takes the
+// stop policy. The policy is shared by the process and applies to the queue
+// of every logger.
+func (a *Applogs) SetOverflowPolicy(policy OverflowPolicy) {
+	logger.SetOverflowPolicy(policy)
+}
+
+// SetSampleTarget keeps the volume of debug and info entries near perSecond
+// entries per second: everything is logged while traffic stays below it, and
+// a growing share is dropped as it rises above. Warnings and errors are never
+// sampled. 0 disables sampling.
+func (a *Applogs) SetSampleTarget(perSecond int) {
+	logger.SetSampleTarget(perSecond)
+}
+
+// SampleRatio returns the share of debug and info entries currently kept by
+// sampling, 1 when everything is logged
+func (a *Applogs) SampleRatio() float64 {
+	return logger.SampleRatio()
+}
+
+// LogsSampledTotal returns the number of entries dropped by adaptive sampling
+func (a *Applogs) LogsSampledTotal() uint64 {
+	return logger.LogsSampledTotal()
+}
+
+// WorkerPanicsTotal returns the number of panics recovered from the log
+// worker, which restarts after each one
+func (a *Applogs) WorkerPanicsTotal() uint64 {
+	return logger.WorkerPanicsTotal()
+}
+
+// Named returns a child logger tagging its entries with a component name.
+// Nested names are joined with dots ("auth" then "jwt" gives "auth.jwt"),
+// and the child honors the per-component levels of APPLG_LOG_SPEC. The
+// child shares the queue and configuration of its parent.
+func (a *Applogs) Named(component string) *Applogs {
+	child := *a
+	if child.component != "" {
+		child.component += "." + component
+	} else {
+		child.component = component
+	}
+	return &child
+}
+
+// SetIdentity changes the service identity stamped on entries logged from
+// now on by the logger and its views. Queued entries and fallback files keep
+// the identity they were logged with, so they still land on their original
+// key.
+func (a *Applogs) SetIdentity(id Identity) {
+	a.instance().SetIdentity(id)
+}
+
+// WithFacility returns a child logger writing its entries under another
+// facility (tenant), i.e. to applogs:<facility>:<type>:<service>:<instance>.
+// The facility is stored in each entry, so fallback files and recovery keep
+// it. The child shares the queue and configuration of its parent.
+func (a *Applogs) WithFacility(facilityID string) *Applogs {
+	child := *a
+	child.facility = facilityID
+	return &child
+}
+
+// WithFields returns a child logger adding fields to each of its entries,
+// for metadata shared by a request or job such as a request ID or tenant.
+// Fields given to a logging call take precedence over base fields of the
+// same key, and the fields of nested children are merged the same way. The
+// map is copied, so it can be reused after the call. The child shares the
+// queue and configuration of its parent.
+func (a *Applogs) WithFields(fields map[string]interface{}) *Applogs {
+	child := *a
+	child.fields = mergeFields(a.fields, fields)
+	if len(a.fields) == 0 && len(fields) > 0 {
+		child.fields = mergeFields(fields, nil) // Copied, as the caller keeps the map
+	}
+	return &child
+}
+
+// WithSync returns a view of the logger writing each entry to Redis, or to
+// fallback, before the logging call returns, for critical sections such as
+// shutdown or a transaction that must not proceed before its logs are
+// durable. Entries already queued by the parent are not waited for. The view
+// shares the configuration of its parent.
+func (a *Applogs) WithSync() *Applogs {
+	view := *a
+	view.sync = true
+	return &view
+}
+
+// RedactKeys registers field names whose values are replaced by "***" in
+// every entry, in Redis, fallback and the console and syslog file alike.
+// Names are matched case-insensitively in nested maps and slices too, and
+// against the request headers of LogRequest. password, authorization,
+// cookie, set-cookie, token and secret are registered by default, and
+// APPLG_REDACT_KEYS adds a comma-separated list at initialization.
+func (a *Applogs) RedactKeys(keys ...string) {
+	logger.RedactKeys(keys...)
+}
+
+// SetHeaderAllowlist makes LogRequest log only the named request headers,
+// like APPLG_HEADER_ALLOWLIST; without names every header is logged. Names
+// are matched case-insensitively, and the values of redacted names stay
+// masked when they are allowlisted.
+func (a *Applogs) SetHeaderAllowlist(names ...string) {
+	logger.SetHeaderAllowlist(names...)
+}
+
+// SetHeaderDenylist makes LogRequest leave out the named request headers,
+// like APPLG_HEADER_DENYLIST, even when they are allowlisted. Names are
+// matched case-insensitively.
+func (a *Applogs) SetHeaderDenylist(names ...string) {
+	logger.SetHeaderDenylist(names...)
+}
+
+// SetLevel sets the minimum level at runtime, e.g. "warn" to suppress debug
+// and info entries in production. Entries below it are dropped before they
+// are queued, so they reach neither Redis nor the console and syslog file.
+// It applies to every component on top of the level spec. The level is
+// shared by the process: setting it through any logger sets it for all of
+// them, and creating a logger changes it only when Config.Level is set.
+func (a *Applogs) SetLevel(level string) error {
+	return logger.SetLevel(level)
+}
+
+// Level returns the minimum level of the process, set by LOG_LEVEL or SetLevel
+func (a *Applogs) Level() string {
+	return logger.Level()
+}
+
+// SetLogSpec replaces the per-component level spec of the process at
+// runtime, e.g. "auth=debug,db=warn,*=info"
+func (a *Applogs) SetLogSpec(spec string) error {
+	return logger.SetLevelSpec(spec)
+}
+
+// PurgeServiceKeys deletes the Redis keys of every instance of this service,
+// for decommissioning or test teardown, and returns how many were deleted.
+// Keys are found with SCAN, so Redis is not blocked.
+func (a *Applogs) PurgeServiceKeys() (deleted int, err error) {
+	return a.instance().PurgeServiceKeys()
+}
+
+// RedisCommands returns the Redis commands the logger issues with its current
+// configuration, e.g. to allow exactly those in a Redis ACL
+func (a *Applogs) RedisCommands() []string {
+	return logger.RedisCommands()
+}
+
+// EffectiveConfig returns the configuration the logger is actually running
+// with, with credentials redacted, for diagnosing misconfiguration
+func (a *Applogs) EffectiveConfig() Config {
+	cfg := a.instance().EffectiveConfig().Redacted()
+	id := a.currentIdentity()
+	cfg.ServiceName, cfg.InstanceID, cfg.FacilityID, cfg.InstanceType = id.ServiceName, id.InstanceID, id.FacilityID, id.InstanceType
+	cfg.QueueSize = a.queueCapacity()
+	return cfg
 }
 
 // logAsync queues a log entry for asynchronous processing
 func (a *Applogs) logAsync(level, message string, fields map[string]interface{}) {
-	entry := logEntry{level: level, message: message, fields: fields}
-	select {
-	case a.logQueue <- entry:
+	if !a.admits(level) {
+		return
+	}
+	a.submit(a.newEntry(level, message, fields))
+}
+
+// submit queues an entry logged by a caller. In FatalPanic mode a fatal
+// entry is delivered on the caller's goroutine instead, so that it is not
+// lost if the panic is not recovered, and the call then panics with its
+// message.
+func (a *Applogs) submit(entry logEntry) {
+	if entry.level != "fatal" || logger.FatalMode() != logger.FatalPanic {
+		a.enqueue(entry)
+		return
+	}
+	a.WithSync().enqueue(entry)
+	panic(entry.message)
+}
+
+// admits reports whether an entry of level passes level filtering, load
+// shedding and sampling
+func (a *Applogs) admits(level string) bool {
+	if a.nop || !logger.ComponentLevelEnabled(a.component, level) {
+		return false
+	}
+	return !logger.ShouldShed(level, a.queueDepth(), a.queueCapacity()) && !logger.ShouldSample(level)
+}
+
+// newEntry returns an entry carrying the component and facility of the
+// logger, its fields merged with the base fields and redacted, empty rather
+// than nil without fields. Error and fatal entries get the stack trace of
+// their call site if enabled, unless the fields hold one.
+func (a *Applogs) newEntry(level, message string, fields map[string]interface{}) logEntry {
+	if logger.StackTraceEnabled(level) {
+		fields = mergeFields(map[string]interface{}{logger.StackTraceField: logger.CallerStack()}, fields)
+	}
+	return logEntry{level: level, message: message, component: a.component, facility: a.facility,
+		identity: a.currentIdentity(), fields: logger.NormalizeFields(logger.RedactFields(mergeFields(a.fields, fields)))}
+}
+
+// mergeFields returns a copy of base with fields added, fields winning on
+// conflicting keys. Without base fields, fields is returned as it is.
+func mergeFields(base, fields map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 {
+		return fields
+	}
+	merged := make(map[string]interface{}, len(base)+len(fields))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return merged
+}
+
+// currentIdentity returns the identity stamped on the entries of the logger
+func (a *Applogs) currentIdentity() Identity {
+	return a.instance().Identity()
+}
+
+// instance returns the state the logger delivers with, that of the default
+// instance for nop loggers
+func (a *Applogs) instance() *logger.Instance {
+	if a.core == nil {
+		return logger.Default()
+	}
+	return a.core
+}
+
+// enqueue queues an entry without applying level filtering
+func (a *Applogs) enqueue(entry logEntry) {
+	entry.fields = expandErrors(entry.fields)
+	if deadline, ok := extractDeadline(entry.fields); ok {
+		entry.fields = withoutField(entry.fields, DeadlineField)
+		entry.deadline = deadline
+		entry.claimed = new(atomic.Bool)
+	}
+	if important, ok := entry.fields[ImportantField]; ok {
+		entry.fields = withoutField(entry.fields, ImportantField)
+		entry.important = important == true
+	}
+	if logger.SerializeOnEnqueue() {
+		if err := entry.encode(); err != nil {
+			a.core.Logger().Warn("Failed to serialize log entry, dropping log", zlog.String("level", entry.level), zlog.String("message", entry.message), zlog.Error(err))
+			logger.EmitEvent(logger.Event{Type: logger.EventLogDropped, Count: 1, Reason: "unserializable", Err: err})
+			return
+		}
+	}
+	if a.sync {
+		a.flushBatch([]logEntry{entry})
+		return
+	}
+
+	// Once stopping has begun, the entry takes the stop policy's path
+	queued, stopped := a.offer(entry)
+	policy := logger.GetOverflowPolicy()
+	if !queued && !stopped {
+		if a.saturated.CompareAndSwap(false, true) {
+			logger.EmitEvent(logger.Event{Type: logger.EventQueueSaturated, Count: a.queueDepth()})
+		}
+		if policy.Mode == logger.OverflowBlock {
+			queued, stopped = a.offerBlocking(entry, policy.Timeout)
+		}
+	}
+	if stopped {
+		a.logStopped(entry)
+		return
+	}
+
+	if queued {
 		// Log successfully added to the queue
-	default:
-		// Log queue is full; optionally drop the log or handle the overflow
-		logger.Logger().Warn("Log queue is full, dropping log", zap.String("level", level), zap.String("message", message))
+		if entry.claimed != nil {
+			a.watchDeadline(entry)
+		}
+	} else if policy.Mode == logger.OverflowBlock || !logger.OverflowDroppable(entry.level) {
+		a.logOverflowed(entry)
+	} else {
+		// Log queue is full; drop the debug or info log
+		a.core.Logger().Warn("Log queue is full, dropping log", zlog.String("level", entry.level), zlog.String("message", entry.message))
+		a.core.CountDropped(1)
+		logger.EmitEvent(logger.Event{Type: logger.EventLogDropped, Count: 1, Reason: "queue_full"})
+	}
+}
+
+// offerBlocking retries queuing an entry until there is room in the queue,
+// stopping begins or timeout elapses; 0 waits as long as it takes. The queue
+// is polled, so that the wait holds no lock StopLogger would wait for.
+func (a *Applogs) offerBlocking(entry logEntry, timeout time.Duration) (queued, stopped bool) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(overflowPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-deadline:
+			return false, false
+		case <-a.stop.stopping:
+			return false, true
+		case <-ticker.C:
+			if queued, stopped = a.offer(entry); queued || stopped {
+				return queued, stopped
+			}
+		}
+	}
+}
+
+// logOverflowed saves an entry that found no room in the queue to fallback,
+// after waiting in OverflowBlock mode
+func (a *Applogs) logOverflowed(entry logEntry) {
+	if entry.claimed != nil && !entry.claim() {
+		return
+	}
+	a.core.Logger().Warn("Log queue is full, saving log to fallback", zlog.String("level", entry.level), zlog.String("message", entry.message))
+	if a.saveToFallback(entry) {
+		a.logToZap(entry)
+	}
+}
+
+// offer queues an entry unless stopping has begun, reporting whether it was
+// queued and whether the logger is stopping
+func (a *Applogs) offer(entry logEntry) (queued, stopped bool) {
+	if a.stop.closing.Load() {
+		return false, true
+	}
+	a.stop.mu.RLock()
+	defer a.stop.mu.RUnlock()
+	if a.stop.closing.Load() {
+		return false, true
+	}
+	return a.queue.Enqueue(Entry{entry: entry}), false
+}
+
+// logStopped saves or drops an entry logged after StopLogger, per the stop policy
+func (a *Applogs) logStopped(entry logEntry) {
+	if entry.claimed != nil && !entry.claim() {
+		return
+	}
+	if logger.StopPolicy() == logger.StopDrop {
+		logger.EmitEvent(logger.Event{Type: logger.EventLogDropped, Count: 1, Reason: "stopped"})
+		return
+	}
+	if a.saveToFallback(entry) {
+		a.logToZap(entry)
+	}
+}
+
+// saveToFallback writes an entry to the fallback directory, reporting
+// whether it could be encoded
+func (a *Applogs) saveToFallback(entry logEntry) bool {
+	encoded := entry.encoded
+	if encoded == nil {
+		payload, err := logger.EncodeLogData(newLogData(entry))
+		if err != nil {
+			a.core.Logger().Error("Failed to marshal log data to JSON", zlog.Error(err))
+			return false
+		}
+		encoded = &payload
+	}
+	a.core.SaveToFallback(*encoded)
+	return true
+}
+
+// queueDepth returns the number of entries waiting in the queue
+func (a *Applogs) queueDepth() int {
+	if a.queue == nil {
+		return 0
+	}
+	return a.queue.Len()
+}
+
+// queueCapacity returns the number of entries the queue can hold, 0 when unbounded
+func (a *Applogs) queueCapacity() int {
+	if a.queue == nil {
+		return 0
+	}
+	return a.queue.Cap()
+}
+
+// watchDeadline delivers the entry on the priority path if it is still queued at its deadline
+func (a *Applogs) watchDeadline(entry logEntry) {
+	time.AfterFunc(time.Until(entry.deadline), func() {
+		if !entry.claim() {
+			return
+		}
+		a.core.LogToPriorityPath(entryLogData(entry))
+		a.logToZap(entry)
+		if entry.level == "fatal" {
+			a.exitOnFatal()
+		}
+	})
+}
+
+// extractDeadline reads the DeadlineField from the fields map, if present
+func extractDeadline(fields map[string]interface{}) (time.Time, bool) {
+	switch deadline := fields[DeadlineField].(type) {
+	case time.Time:
+		return deadline, true
+	case time.Duration:
+		return time.Now().Add(deadline), true
 	}
+	return time.Time{}, false
 }
 
-// processLogs handles asynchronous processing of logs from the queue
+// withoutField returns a copy of fields with key removed
+func withoutField(fields map[string]interface{}, key string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// processLogs handles asynchronous processing of logs from the queue.
+// Entries are pushed to Redis in adaptive batches: a small batch keeps latency
+// low under steady load, and the batch grows while the queue is backed up.
+// A partial batch is flushed as soon as the queue is empty, or with a flush
+// interval once it has waited that long for more entries.
 func (a *Applogs) processLogs() {
-	for entry := range a.logQueue {
-		// Log to Redis and Uber Zap
-		logger.LogToRedis(entry.level, entry.message, entry.fields)
-		switch entry.level {
-		case "info":
-			logger.Logger().Info(entry.message, zap.Any("metadata", entry.fields))
-		case "debug":
-			logger.Logger().Debug(entry.message, zap.Any("metadata", entry.fields))
-		case "warn":
-			logger.Logger().Warn(entry.message, zap.Any("metadata", entry.fields))
-		case "error":
-			logger.Logger().Error(entry.message, zap.Any("metadata", entry.fields))
-		case "fatal":
-			logger.Logger().Fatal(entry.message, zap.Any("metadata", entry.fields))
+	defer close(a.stop.done)
+	for !a.runWorker() {
+	}
+}
+
+// runWorker batches and flushes queued entries until the queue is closed
+// and drained, which it reports. A panic, from a sink for instance, is
+// recovered: it is reported, the entries of the batch in flight are saved to
+// fallback, possibly again if part of them had been delivered, and
+// processLogs restarts the loop with the entries still queued.
+func (a *Applogs) runWorker() (drained bool) {
+	cfg := logger.GetBatchConfig()
+	batchSize := cfg.MinSize
+	batch := make([]logEntry, 0, cfg.MaxSize)
+	var flushBy time.Time // When the partial batch is flushed under a flush interval
+	defer func() {
+		if r := recover(); r != nil {
+			a.core.ReportWorkerPanic(r, debug.Stack())
+			for _, entry := range batch {
+				a.saveToFallback(entry)
+			}
+		}
+	}()
+
+	for {
+		queued, ok, timedOut := a.dequeue(len(batch) > 0, flushBy, cfg)
+		if !ok {
+			a.flushBatch(batch)
+			return true
+		}
+
+		if !timedOut {
+			// A Flush marker pushes out the partial batch and releases its caller
+			if marker := queued.entry.flushed; marker != nil {
+				a.flushMarked(batch, marker)
+				batch = batch[:0]
+				continue
+			}
+
+			// Entries already delivered by their deadline watcher are skipped
+			if entry := queued.entry; entry.claim() {
+				if len(batch) == 0 {
+					flushBy = time.Now().Add(cfg.FlushInterval)
+				}
+				batch = append(batch, entry)
+			}
+			if len(batch) == 0 || (len(batch) < batchSize && (a.queueDepth() > 0 || a.lingers(flushBy, cfg))) {
+				continue
+			}
+		}
+
+		a.flushBatch(batch)
+		batch = batch[:0]
+		batchSize = nextBatchSize(batchSize, a.queueDepth(), cfg)
+		if a.queueDepth() == 0 {
+			a.saturated.Store(false) // The next overflow is reported again
+		}
+	}
+}
+
+// dequeue returns the next queued entry. While a partial batch waits under
+// a flush interval, it waits no later than flushBy, reporting timedOut when
+// the batch is due.
+func (a *Applogs) dequeue(pending bool, flushBy time.Time, cfg config.BatchConfig) (entry Entry, ok, timedOut bool) {
+	if pending && a.lingers(flushBy, cfg) {
+		return a.queue.(timedQueue).dequeueWithin(time.Until(flushBy))
+	}
+	if pending && cfg.FlushInterval > 0 && a.queueDepth() == 0 {
+		return Entry{}, true, true // Due, or flushed at once by a custom queue
+	}
+	entry, ok = a.queue.Dequeue()
+	return entry, ok, false
+}
+
+// lingers reports whether a partial batch still waits for more entries: a
+// flush interval is set, it is not over, and the queue supports timed waits
+func (a *Applogs) lingers(flushBy time.Time, cfg config.BatchConfig) bool {
+	if cfg.FlushInterval <= 0 || !time.Now().Before(flushBy) {
+		return false
+	}
+	_, ok := a.queue.(timedQueue)
+	return ok
+}
+
+// flushMarked flushes the batch preceding a Flush marker, releasing the
+// caller of Flush even if the flush panics
+func (a *Applogs) flushMarked(batch []logEntry, marker chan struct{}) {
+	defer close(marker)
+	a.flushBatch(batch)
+}
+
+// nextBatchSize grows the batch size while the queue is backed up and
+// shrinks it once the queue has drained
+func nextBatchSize(current, queueDepth int, cfg config.BatchConfig) int {
+	switch {
+	case queueDepth > cfg.GrowDepth:
+		current *= 2
+		if current > cfg.MaxSize {
+			current = cfg.MaxSize
+		}
+	case queueDepth == 0:
+		current /= 2
+		if current < cfg.MinSize {
+			current = cfg.MinSize
 		}
 	}
+	return current
+}
+
+// flushBatch pushes a batch of entries to Redis and writes them to Uber Zap
+func (a *Applogs) flushBatch(batch []logEntry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	payloads := make([]logger.EncodedEntry, 0, len(batch))
+	for _, entry := range batch {
+		if entry.encoded != nil {
+			payloads = append(payloads, *entry.encoded)
+			continue
+		}
+		encoded, err := logger.EncodeLogData(newLogData(entry))
+		if err != nil {
+			a.core.Logger().Error("Failed to marshal log data to JSON", zlog.Error(err))
+			continue
+		}
+		payloads = append(payloads, encoded)
+	}
+	a.core.LogEncodedBatchToRedis(payloads)
+
+	fatal := false
+	for _, entry := range batch {
+		if !entry.summary { // The detailed entry is already written
+			a.logToZap(entry)
+		}
+		fatal = fatal || entry.level == "fatal"
+	}
+	if fatal {
+		a.exitOnFatal()
+	}
 }
 
-// StopLogger gracefully shuts down the logger, ensuring all logs are processed
+// newLogData builds the Redis payload of an entry
+func newLogData(entry logEntry) map[string]interface{} {
+	logData := logger.NewLogDataAs(entry.identity, entry.level, entry.message, entry.fields)
+	if entry.component != "" {
+		logData["component"] = entry.component
+	}
+	if entry.facility != "" {
+		logData["facility_id"] = entry.facility
+	}
+	if !entry.deadline.IsZero() {
+		logData["deadline"] = entry.deadline.UTC()
+	}
+	if entry.important {
+		logData[logger.ImportantField] = true
+	}
+	if entry.summary {
+		logData[logger.SummaryField] = true
+	}
+	return logData
+}
+
+// entryLogData returns the payload of an entry, decoding it if it was serialized at enqueue time
+func entryLogData(entry logEntry) map[string]interface{} {
+	if entry.encoded == nil {
+		return newLogData(entry)
+	}
+	logData, _ := logger.DecodeLogData(entry.encoded.Data)
+	return logData
+}
+
+// logToZap writes an entry to Uber Zap at its level
+func (a *Applogs) logToZap(entry logEntry) {
+	log := a.core.Logger()
+	fields := []zlog.Field{zlog.Any("metadata", entry.fields)}
+	if entry.encoded != nil {
+		fields[0] = zlog.RawJSON("metadata", entry.metadata)
+	}
+	if entry.component != "" {
+		fields = append(fields, zlog.String("component", entry.component))
+	}
+
+	switch entry.level {
+	case "info":
+		log.Info(entry.message, fields...)
+	case "debug":
+		log.Debug(entry.message, fields...)
+	case "warn":
+		log.Warn(entry.message, fields...)
+	case "error":
+		log.Error(entry.message, fields...)
+	case "fatal":
+		zlog.WriteFatal(log, entry.message, fields...) // The process exits in exitOnFatal
+	}
+}
+
+// StopLogger gracefully shuts down the logger, returning once every queued
+// entry has been pushed to Redis or saved to fallback and the local log
+// files have been synced. It is safe to call while other goroutines log:
+// entries logged once it has begun are saved to fallback or dropped
+// according to the stop policy. The periodic recovery and cleanup loops of
+// the logger are stopped too, so nothing keeps running.
 func (a *Applogs) StopLogger() {
-	close(a.logQueue) // Close the log queue to stop processing
-	logger.Logger().Info("Logger stopped gracefully")
+	_ = a.StopLoggerWithTimeout(0)
+}
+
+// StopLoggerWithTimeout stops the logger like StopLogger, but waits at most
+// timeout for the queue to drain, returning ErrStopTimeout if it did not.
+// The worker then keeps draining in the background. A timeout of 0 waits
+// as long as draining takes. Calling it again waits for the same drain.
+func (a *Applogs) StopLoggerWithTimeout(timeout time.Duration) error {
+	if a.nop {
+		return nil
+	}
+	a.stopHeartbeat()
+	if a.sync {
+		a.core.Stop()
+		return a.core.Logger().Sync()
+	}
+	// Wait for the calls queuing an entry; later ones see closing and do not touch the queue
+	a.stop.mu.Lock()
+	if a.stop.closing.CompareAndSwap(false, true) {
+		close(a.stop.stopping)
+		a.queue.Close() // Close the log queue to stop processing
+	}
+	a.stop.mu.Unlock()
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-a.stop.done:
+		case <-timer.C:
+			a.core.Logger().Warn("Logger stop timed out before the queue drained",
+				zlog.Int("queue_depth", a.queueDepth()),
+				zlog.Duration("timeout", timeout))
+			return ErrStopTimeout
+		}
+	} else {
+		<-a.stop.done
+	}
+
+	a.core.Stop()
+	_ = a.core.Logger().Sync()
+	a.core.Logger().Info("Logger stopped gracefully")
+	return nil
 }
 
 // Info log
@@ -93,14 +1354,70 @@ func (a *Applogs) Error(message string, fields map[string]interface{}) { a.logAs
 // Fatal log
 func (a *Applogs) Fatal(message string, fields map[string]interface{}) { a.logAsync("fatal", message, fields) }
 
+// Log logs at a level chosen at runtime, e.g. from a response status code,
+// like the method of that level. The level is debug, info, warn, error or
+// fatal, in any case; an unknown level logs the entry as info and is
+// returned as an error.
+func (a *Applogs) Log(level, message string, fields map[string]interface{}) error {
+	normalized := strings.ToLower(strings.TrimSpace(level))
+	if _, ok := logger.LevelRank(normalized); !ok {
+		a.logAsync("info", message, fields)
+		return fmt.Errorf("unknown level %q", level)
+	}
+	a.logAsync(normalized, message, fields)
+	return nil
+}
+
+// Debugf logs a message formatted as by fmt.Sprintf, without fields
+func (a *Applogs) Debugf(format string, args ...interface{}) {
+	a.logf("debug", format, args)
+}
+
+// Infof logs a message formatted as by fmt.Sprintf, without fields
+func (a *Applogs) Infof(format string, args ...interface{}) {
+	a.logf("info", format, args)
+}
+
+// Warnf logs a message formatted as by fmt.Sprintf, without fields
+func (a *Applogs) Warnf(format string, args ...interface{}) {
+	a.logf("warn", format, args)
+}
+
+// Errorf logs a message formatted as by fmt.Sprintf, without fields
+func (a *Applogs) Errorf(format string, args ...interface{}) {
+	a.logf("error", format, args)
+}
+
+// Fatalf logs a message formatted as by fmt.Sprintf, without fields
+func (a *Applogs) Fatalf(format string, args ...interface{}) {
+	a.logf("fatal", format, args)
+}
+
+// logf formats the message like the sugared logger, the format alone when
+// there are no args, and logs it through logAsync. Nothing is formatted for
+// a disabled level.
+func (a *Applogs) logf(level, format string, args []interface{}) {
+	if a.nop || !logger.ComponentLevelEnabled(a.component, level) {
+		return
+	}
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	a.logAsync(level, message, nil)
+}
+
 // LogRequest logs details about an incoming request
 func (a *Applogs) LogRequest(method, url, clientIP string, headers map[string][]string) {
 	fields := map[string]interface{}{
 		"method":    method,
 		"url":       url,
 		"client_ip": clientIP,
-		"headers":   headers,
-		"timestamp": time.Now().UTC(),
+		"timestamp": logger.Now().UTC(),
+	}
+	addHeaderFields(fields, logger.RedactHeaders(logger.FilterHeaders(headers)), logger.HeaderFormat())
+	if logger.LogQueryParams() {
+		addQueryFields(fields, url)
 	}
 	a.logAsync("info", "Incoming request", fields)
 }
@@ -110,19 +1427,22 @@ func (a *Applogs) LogResponse(statusCode int, duration time.Duration) {
 	fields := map[string]interface{}{
 		"status_code": statusCode,
 		"duration_ms": duration.Milliseconds(),
-		"timestamp":   time.Now().UTC(),
+		"timestamp":   logger.Now().UTC(),
 	}
 	a.logAsync("info", "Outgoing response", fields)
 }
 
-// LogPanic logs panic details for recovery
+// LogPanic logs panic details for recovery. The panic value is mapped to
+// fields by DefaultPanicFields, or by the mapping set with SetPanicFields.
 func (a *Applogs) LogPanic(panicData interface{}, method, url, clientIP string) {
 	fields := map[string]interface{}{
-		"panic":     panicData,
 		"method":    method,
 		"url":       url,
 		"client_ip": clientIP,
-		"timestamp": time.Now().UTC(),
+		"timestamp": logger.Now().UTC(),
+	}
+	for key, value := range panicValueFields(panicData) {
+		fields[key] = value
 	}
 	a.logAsync("error", "Recovered from panic", fields)
 }
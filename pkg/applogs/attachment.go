@@ -0,0 +1,46 @@
+package applogs
+
+import (
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// Fields of entries logged by LogWithAttachment
+const (
+	AttachmentKeyField   = logger.AttachmentKeyField   // Redis key holding the attached blob
+	AttachmentErrorField = logger.AttachmentErrorField // Why the blob could not be stored
+)
+
+// LogWithAttachment logs an info entry referencing a binary artifact too
+// large for the fields, such as a failing request body. The blob is stored
+// with SET under its own key next to the entry's list, expiring after
+// APPLG_ATTACHMENT_TTL (a day by default), and the entry records that key in
+// AttachmentKeyField. The blob is stored before the call returns; when that
+// fails the entry is logged all the same, with the error in
+// AttachmentErrorField, and the error is returned. Nothing is stored when
+// the entry is dropped by level filtering.
+func (a *Applogs) LogWithAttachment(message string, fields map[string]interface{}, blob []byte) error {
+	if !a.admits("info") {
+		return nil
+	}
+
+	key := logger.AttachmentKey(a.currentIdentity(), a.facility)
+	err := a.instance().StoreAttachment(key, blob)
+	attached := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		attached[k] = v
+	}
+	if err != nil {
+		attached[AttachmentErrorField] = err.Error()
+	} else {
+		attached[AttachmentKeyField] = key
+	}
+	a.submit(a.newEntry("info", message, attached))
+	return err
+}
+
+// SetAttachmentTTL sets how long the blobs of LogWithAttachment are kept in Redis
+func (a *Applogs) SetAttachmentTTL(ttl time.Duration) {
+	logger.SetAttachmentTTL(ttl)
+}
@@ -0,0 +1,47 @@
+package applogs
+
+import (
+	"strings"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// LogEntry is an entry sent to Consume by a producer goroutine
+type LogEntry struct {
+	Level   string // debug, info, warn, error or fatal; other levels are logged as info
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Consume logs every entry received from ch, in order, until ch is closed
+// or StopLogger begins, then returns; run it on its own goroutine. Entries
+// go through level filtering, shedding, sampling and the overflow handling
+// of the queue like those of Info and the other methods. Entries still in
+// ch when StopLogger begins are left there.
+func (a *Applogs) Consume(ch <-chan LogEntry) {
+	var stopping <-chan struct{} // Nil for loggers without a worker, which never stop consuming
+	if a.stop != nil {
+		stopping = a.stop.stopping
+	}
+
+	for {
+		select {
+		case entry, ok := <-ch:
+			if !ok {
+				return
+			}
+			a.logAsync(consumedLevel(entry.Level), entry.Message, entry.Fields)
+		case <-stopping:
+			return
+		}
+	}
+}
+
+// consumedLevel normalizes the level of a consumed entry
+func consumedLevel(level string) string {
+	level = strings.ToLower(level)
+	if _, ok := logger.LevelRank(level); !ok {
+		return "info"
+	}
+	return level
+}
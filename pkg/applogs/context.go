@@ -0,0 +1,156 @@
+package applogs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// Fields added by the context-aware methods when context annotations are enabled
+const (
+	CtxErrField       = "ctx_err"          // Error of a context that is already done
+	CtxRemainingField = "ctx_remaining_ms" // Milliseconds left until the deadline of a live context
+)
+
+// contextKey is the context key holding the request-scoped logger
+type contextKey struct{}
+
+// nopLogger is returned by FromContext when no logger was stored
+var nopLogger = NewNopLogger()
+
+// NewNopLogger returns a logger that discards every entry. It does not
+// initialize Redis, files or background goroutines.
+func NewNopLogger() *Applogs {
+	return &Applogs{nop: true, throttle: newThrottler()}
+}
+
+// IntoContext returns a copy of ctx carrying the given logger
+func IntoContext(ctx context.Context, logger *Applogs) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored by IntoContext, or a Nop logger if absent
+func FromContext(ctx context.Context) *Applogs {
+	if logger, ok := ctx.Value(contextKey{}).(*Applogs); ok && logger != nil {
+		return logger
+	}
+	return nopLogger
+}
+
+// SetAnnotateContext makes the context-aware methods record the state of their
+// context: CtxErrField when it is already cancelled or past its deadline,
+// CtxRemainingField otherwise when it has a deadline. It is off by default.
+func (a *Applogs) SetAnnotateContext(enabled bool) {
+	logger.SetAnnotateContext(enabled)
+}
+
+// contextField lifts the value stored under a context key into a log field
+type contextField struct {
+	key    interface{}
+	logKey string
+}
+
+var (
+	contextFieldsMu sync.RWMutex
+	contextFieldSet []contextField // Registered by RegisterContextField, in registration order
+)
+
+// RegisterContextField makes the context-aware methods copy the value stored
+// in their context under key into the field logKey, e.g. a trace or user ID
+// set by a middleware. Registering a key again changes its field; an empty
+// logKey unregisters it. Fields passed explicitly take precedence, and
+// contexts without the value add nothing.
+func RegisterContextField(key interface{}, logKey string) {
+	contextFieldsMu.Lock()
+	defer contextFieldsMu.Unlock()
+
+	for i, field := range contextFieldSet {
+		if field.key == key {
+			contextFieldSet = append(contextFieldSet[:i:i], contextFieldSet[i+1:]...)
+			break
+		}
+	}
+	if logKey != "" {
+		contextFieldSet = append(contextFieldSet, contextField{key: key, logKey: logKey})
+	}
+}
+
+// registeredContextFields returns a snapshot of the registered context fields
+func registeredContextFields() []contextField {
+	contextFieldsMu.RLock()
+	defer contextFieldsMu.RUnlock()
+	return contextFieldSet
+}
+
+// DebugContext logs at debug level on behalf of the operation of ctx, adding
+// the registered context fields and, when enabled, the state of ctx
+func (a *Applogs) DebugContext(ctx context.Context, message string, fields map[string]interface{}) {
+	a.logAsync("debug", message, contextFields(ctx, fields))
+}
+
+// InfoContext logs at info level on behalf of the operation of ctx, adding
+// the registered context fields and, when enabled, the state of ctx
+func (a *Applogs) InfoContext(ctx context.Context, message string, fields map[string]interface{}) {
+	a.logAsync("info", message, contextFields(ctx, fields))
+}
+
+// WarnContext logs at warn level on behalf of the operation of ctx, adding
+// the registered context fields and, when enabled, the state of ctx
+func (a *Applogs) WarnContext(ctx context.Context, message string, fields map[string]interface{}) {
+	a.logAsync("warn", message, contextFields(ctx, fields))
+}
+
+// ErrorContext logs at error level on behalf of the operation of ctx, adding
+// the registered context fields and, when enabled, the state of ctx
+func (a *Applogs) ErrorContext(ctx context.Context, message string, fields map[string]interface{}) {
+	a.logAsync("error", message, contextFields(ctx, fields))
+}
+
+// FatalContext logs at fatal level on behalf of the operation of ctx, adding
+// the registered context fields and, when enabled, the state of ctx
+func (a *Applogs) FatalContext(ctx context.Context, message string, fields map[string]interface{}) {
+	a.logAsync("fatal", message, contextFields(ctx, fields))
+}
+
+// contextFields returns fields with the registered context values of ctx
+// and, when annotations are enabled, its state. The caller's map is left
+// untouched, and is returned as is when nothing is added.
+func contextFields(ctx context.Context, fields map[string]interface{}) map[string]interface{} {
+	if ctx == nil {
+		return fields
+	}
+
+	var added map[string]interface{}
+	add := func(key string, value interface{}) {
+		if _, ok := fields[key]; ok {
+			return // Explicit fields take precedence
+		}
+		if added == nil {
+			added = make(map[string]interface{}, len(fields)+2)
+			for k, v := range fields {
+				added[k] = v
+			}
+		}
+		added[key] = value
+	}
+
+	for _, field := range registeredContextFields() {
+		if value := ctx.Value(field.key); value != nil {
+			add(field.logKey, value)
+		}
+	}
+	if logger.AnnotateContext() {
+		if err := ctx.Err(); err != nil {
+			add(CtxErrField, err.Error())
+		} else if deadline, ok := ctx.Deadline(); ok {
+			add(CtxRemainingField, time.Until(deadline).Milliseconds())
+		}
+	}
+
+	if added == nil {
+		return fields
+	}
+	return added
+}
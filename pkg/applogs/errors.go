@@ -0,0 +1,54 @@
+package applogs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ChainSuffix is appended to the name of an error field to name the field
+// holding its wrapped-error chain, e.g. "error_chain" for "error"
+const ChainSuffix = "_chain"
+
+// maxErrorChain bounds the levels recorded in an error chain
+const maxErrorChain = 32
+
+// expandErrors returns fields with every error value replaced by its message,
+// which would otherwise be marshaled as an empty object. An error wrapping
+// others also gets a <name>_chain array with the message and type of each
+// level, outermost first, found by walking errors.Unwrap. The caller's map is
+// left untouched.
+func expandErrors(fields map[string]interface{}) map[string]interface{} {
+	var expanded map[string]interface{}
+	for key, value := range fields {
+		err, ok := value.(error)
+		if !ok || err == nil {
+			continue
+		}
+		if expanded == nil {
+			expanded = make(map[string]interface{}, len(fields)+1)
+			for k, v := range fields {
+				expanded[k] = v
+			}
+		}
+		expanded[key] = err.Error()
+		if chain := errorChain(err); len(chain) > 1 {
+			expanded[key+ChainSuffix] = chain
+		}
+	}
+	if expanded == nil {
+		return fields
+	}
+	return expanded
+}
+
+// errorChain lists err and the errors it wraps
+func errorChain(err error) []map[string]interface{} {
+	var chain []map[string]interface{}
+	for ; err != nil && len(chain) < maxErrorChain; err = errors.Unwrap(err) {
+		chain = append(chain, map[string]interface{}{
+			"message": err.Error(),
+			"type":    fmt.Sprintf("%T", err),
+		})
+	}
+	return chain
+}
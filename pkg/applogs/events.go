@@ -0,0 +1,30 @@
+package applogs
+
+import (
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// Event is an operational event of the client, for status displays
+type Event = logger.Event
+
+// EventType identifies an operational event
+type EventType = logger.EventType
+
+const (
+	EventRedisStateChanged = logger.EventRedisStateChanged // Redis became reachable or unreachable; see Event.State
+	EventLogDropped        = logger.EventLogDropped        // Entries were discarded; see Event.Reason
+	EventFallbackWritten   = logger.EventFallbackWritten   // An entry was saved to the fallback directory
+	EventRecoveryCompleted = logger.EventRecoveryCompleted // A fallback file was resent
+	EventQueueSaturated    = logger.EventQueueSaturated    // The log queue filled up and started dropping
+	EventWorkerPanic       = logger.EventWorkerPanic       // The log worker panicked and was restarted
+	EventRedisReconnected  = logger.EventRedisReconnected  // Redis answered again after sustained failures; see Event.Count
+	EventBacklogDelayed    = logger.EventBacklogDelayed    // The oldest pending fallback entry is older than SetBacklogMaxAge
+)
+
+// Subscribe returns a channel receiving the client's operational events,
+// buffered with the given size. A subscriber that falls behind misses
+// events instead of slowing the logger down. Call cancel to unsubscribe,
+// which closes the channel.
+func (a *Applogs) Subscribe(buffer int) (events <-chan Event, cancel func()) {
+	return logger.Subscribe(buffer)
+}
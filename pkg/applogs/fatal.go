@@ -0,0 +1,81 @@
+package applogs
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// fatalExiting is set once a fatal entry has begun exiting the process, so
+// that the fatal entries delivered meanwhile do not exit it a second time
+var fatalExiting atomic.Bool
+
+// exitOnFatal exits the process once a fatal entry is delivered in
+// FatalExit mode. The queue is closed as StopLogger closes it, the entries
+// queued behind the fatal one are delivered and the sinks flushed within the
+// fatal flush timeout, then the local log files are synced and the process
+// exits with the fatal exit code.
+func (a *Applogs) exitOnFatal() {
+	if logger.FatalMode() != logger.FatalExit || !fatalExiting.CompareAndSwap(false, true) {
+		return
+	}
+	timeout := logger.FatalFlushTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		if a.queue != nil { // Test loggers have no queue
+			a.drainForExit()
+		}
+		a.core.FlushSinks(ctx)
+	}()
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		a.core.Logger().Warn("Fatal flush timed out, exiting with entries undelivered",
+			zlog.Int("queue_depth", a.queueDepth()),
+			zlog.Duration("timeout", timeout))
+	}
+
+	a.core.CloseFallbackFiles()
+	_ = a.core.Logger().Sync()
+	os.Exit(logger.FatalExitCode())
+}
+
+// drainForExit closes the queue and delivers the entries left in it. It
+// runs beside the worker, which may be the goroutine delivering the fatal
+// entry.
+func (a *Applogs) drainForExit() {
+	a.stop.mu.Lock()
+	if a.stop.closing.CompareAndSwap(false, true) {
+		close(a.stop.stopping)
+		a.queue.Close()
+	}
+	a.stop.mu.Unlock()
+
+	batch := make([]logEntry, 0, logger.GetBatchConfig().MaxSize)
+	for {
+		queued, ok := a.queue.Dequeue()
+		if !ok {
+			a.flushBatch(batch)
+			return
+		}
+		if marker := queued.entry.flushed; marker != nil {
+			a.flushMarked(batch, marker)
+			batch = batch[:0]
+			continue
+		}
+		if queued.entry.claim() {
+			batch = append(batch, queued.entry)
+		}
+		if len(batch) == cap(batch) {
+			a.flushBatch(batch)
+			batch = batch[:0]
+		}
+	}
+}
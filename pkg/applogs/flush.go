@@ -0,0 +1,52 @@
+package applogs
+
+import (
+	"context"
+	"time"
+)
+
+// flushRetryDelay is how long Flush waits before offering its marker again
+// to a full queue
+const flushRetryDelay = time.Millisecond
+
+// Flush blocks until every entry queued before the call has been pushed to
+// Redis or saved to fallback, or until ctx is done, in which case it returns
+// ctx.Err() and the entries are still delivered in the background. Unlike
+// StopLogger, the logger keeps working afterwards.
+//
+// Flush queues a marker entry behind the others and waits for the worker to
+// reach it. A custom Queue must hand the marker out like any other entry;
+// its Level and Message are empty. On a stopping logger, Flush waits for the
+// drain StopLogger started.
+func (a *Applogs) Flush(ctx context.Context) error {
+	if a.nop || a.sync {
+		return nil // Synchronous loggers hold nothing back
+	}
+
+	marker := logEntry{flushed: make(chan struct{})}
+	done := marker.flushed
+	for {
+		queued, stopped := a.offer(marker)
+		if stopped {
+			done = a.stop.done
+			break
+		}
+		if queued {
+			break
+		}
+
+		// The queue is full; the marker must not be dropped like an entry
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(flushRetryDelay):
+		}
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
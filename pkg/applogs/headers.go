@@ -0,0 +1,42 @@
+package applogs
+
+import (
+	"strings"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+)
+
+// Header representations accepted by SetHeaderFormat and APPLG_HEADER_FORMAT
+const (
+	HeaderFormatRaw      = config.HeaderFormatRaw      // "headers": {"Accept": ["a", "b"]}
+	HeaderFormatObject   = config.HeaderFormatObject   // "headers": {"Accept": "a, b"}
+	HeaderFormatPrefixed = config.HeaderFormatPrefixed // "header_accept": "a, b"
+)
+
+// addHeaderFields stores headers in fields using the given representation
+func addHeaderFields(fields map[string]interface{}, headers map[string][]string, format string) {
+	switch format {
+	case HeaderFormatObject:
+		fields["headers"] = FlattenHeaders(headers)
+	case HeaderFormatPrefixed:
+		for name, values := range headers {
+			fields[headerFieldName(name)] = strings.Join(values, ", ")
+		}
+	default:
+		fields["headers"] = headers
+	}
+}
+
+// FlattenHeaders converts headers to plain strings, joining multiple values with commas
+func FlattenHeaders(headers map[string][]string) map[string]string {
+	flat := make(map[string]string, len(headers))
+	for name, values := range headers {
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// headerFieldName turns a header name such as User-Agent into header_user_agent
+func headerFieldName(name string) string {
+	return "header_" + strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
@@ -0,0 +1,114 @@
+package applogs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// HeartbeatMessage is the message of the periodic heartbeat entries
+const HeartbeatMessage = "heartbeat"
+
+// RuntimeStatsMessage is the message of the periodic runtime stats entries
+const RuntimeStatsMessage = "runtime_stats"
+
+// Groups of statistics accepted by SetRuntimeStats
+const (
+	StatGoroutines = logger.StatGoroutines // goroutines
+	StatHeap       = logger.StatHeap       // heap_alloc_bytes, heap_sys_bytes, heap_objects
+	StatGC         = logger.StatGC         // gc_count, gc_pause_last_ms, gc_pause_total_ms
+	StatFDs        = logger.StatFDs        // open_fds, on platforms exposing /proc/self/fd
+)
+
+// heartbeat runs a periodic entry of a logger: the heartbeat or the runtime stats
+type heartbeat struct {
+	mu     sync.Mutex
+	stop   chan struct{} // Closed to stop the running heartbeat; nil when none runs
+	exited chan struct{} // Closed once the running heartbeat has returned
+}
+
+// SetHeartbeatInterval starts emitting a heartbeat entry every interval, or
+// stops the heartbeat when interval is 0. Heartbeats travel through the queue
+// like any other entry, so their arrival confirms the whole pipeline works.
+func (a *Applogs) SetHeartbeatInterval(interval time.Duration) {
+	if a.nop {
+		return
+	}
+	logger.SetHeartbeatInterval(interval)
+	a.heartbeat.start(interval, a.emitHeartbeat)
+}
+
+// SetRuntimeStats starts emitting a RuntimeStatsMessage entry every interval
+// with the selected groups of process statistics (all of them when none is
+// given), or stops it when interval is 0. Like heartbeats, these entries
+// ignore level filtering.
+func (a *Applogs) SetRuntimeStats(interval time.Duration, stats ...string) {
+	if a.nop {
+		return
+	}
+	logger.SetRuntimeStats(interval, stats...)
+	a.stats.start(interval, a.emitRuntimeStats)
+}
+
+// stopHeartbeat stops the heartbeat and runtime stats, waiting until they can no longer enqueue
+func (a *Applogs) stopHeartbeat() {
+	a.heartbeat.start(0, nil)
+	a.stats.start(0, nil)
+}
+
+// start stops the running heartbeat, if any, then calls emit every interval
+// unless interval is 0
+func (h *heartbeat) start(interval time.Duration, emit func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stop != nil {
+		close(h.stop)
+		<-h.exited
+		h.stop, h.exited = nil, nil
+	}
+	if interval <= 0 {
+		return
+	}
+
+	h.stop = make(chan struct{})
+	h.exited = make(chan struct{})
+	go runHeartbeat(interval, emit, h.stop, h.exited)
+}
+
+// runHeartbeat calls emit every interval until stop is closed
+func runHeartbeat(interval time.Duration, emit func(), stop, exited chan struct{}) {
+	defer close(exited)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			emit()
+		}
+	}
+}
+
+// emitHeartbeat enqueues a heartbeat entry, with the sampling ratio while
+// sampling is enabled. Heartbeats bypass level filtering and sampling, they
+// must always get through.
+func (a *Applogs) emitHeartbeat() {
+	fields := map[string]interface{}{
+		"queue_depth":    a.queueDepth(),
+		"queue_capacity": a.queueCapacity(),
+		"redis_state":    a.instance().RedisState(),
+	}
+	if logger.SampleTarget() > 0 {
+		fields["sample_ratio"] = logger.SampleRatio()
+		fields["logs_sampled_total"] = logger.LogsSampledTotal()
+	}
+	a.enqueue(a.newEntry("info", HeartbeatMessage, fields))
+}
+
+// emitRuntimeStats enqueues a runtime stats entry, bypassing level filtering
+func (a *Applogs) emitRuntimeStats() {
+	a.enqueue(a.newEntry("info", RuntimeStatsMessage, logger.CollectRuntimeStats()))
+}
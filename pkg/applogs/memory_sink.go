@@ -0,0 +1,157 @@
+package applogs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+)
+
+// dumpMessageWidth caps the message column of MemorySink.String, so that one
+// long message does not push every other line's fields out of view
+const dumpMessageWidth = 40
+
+// MemorySink is a Sink recording the pushed entries in memory, for tests
+// that need no Redis. The zero value is ready to use:
+//
+//	sink := &applogs.MemorySink{}
+//	log.SetSink(sink)
+//	t.Log(sink) // Dumps the captured entries when a test fails
+//
+// NewCaptureLogger pairs one with a test logger.
+type MemorySink struct {
+	mu      sync.Mutex
+	entries [][]byte // Payloads in the order they were pushed
+}
+
+// Push records entries; it never fails
+func (s *MemorySink) Push(ctx context.Context, key string, entries [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		s.entries = append(s.entries, append([]byte(nil), entry...))
+	}
+	return nil
+}
+
+// Logs returns the captured entries, oldest first, with their metadata as
+// Fields. Payloads that cannot be decoded are left out.
+func (s *MemorySink) Logs() []LogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logs := make([]LogEntry, 0, len(s.entries))
+	for _, data := range s.entries {
+		logData, err := logger.DecodeLogData(data)
+		if err != nil {
+			continue
+		}
+		entry := LogEntry{Fields: map[string]interface{}{}}
+		entry.Level, _ = logData["level"].(string)
+		entry.Message, _ = logData["message"].(string)
+		if metadata, ok := logData["metadata"].(map[string]interface{}); ok {
+			entry.Fields = metadata
+		}
+		logs = append(logs, entry)
+	}
+	return logs
+}
+
+// Reset discards the captured entries
+func (s *MemorySink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+}
+
+// NewCaptureLogger initializes a test logger recording its entries in the
+// returned sink, as NewTestLogger does but without a Redis client: entries
+// are captured synchronously, so a test can assert on sink.Logs() right
+// after logging, with neither a network nor a sleep.
+func NewCaptureLogger() (*Applogs, *MemorySink) {
+	sink := &MemorySink{}
+	core, _ := logger.NewTestInstanceWithSink(sink)
+	return newTestLogger(core), sink
+}
+
+// String formats the captured entries one per line, oldest first, for a
+// readable trail in test failures: the level in capitals, the message
+// padded to a common width, then the component and fields as sorted
+// key=value pairs, nested maps with dotted keys.
+//
+//	INFO  User logged in  user_id=123
+//	WARN  Slow query      component=db ms=850
+//	ERROR Payment failed  error="card declined"
+func (s *MemorySink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type line struct{ level, message, fields string }
+	lines := make([]line, 0, len(s.entries))
+	width := 0
+	for _, data := range s.entries {
+		logData, err := logger.DecodeLogData(data)
+		if err != nil {
+			lines = append(lines, line{level: "?", message: string(data)})
+			continue
+		}
+		l := line{}
+		l.level, _ = logData["level"].(string)
+		l.message, _ = logData["message"].(string)
+		l.fields = dumpFields(logData)
+		if len(l.message) > width {
+			width = len(l.message)
+		}
+		lines = append(lines, l)
+	}
+	if width > dumpMessageWidth {
+		width = dumpMessageWidth
+	}
+
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(padRight(strings.ToUpper(l.level), len("ERROR")+1))
+		if l.fields == "" {
+			b.WriteString(l.message)
+		} else {
+			b.WriteString(padRight(l.message, width+2))
+			b.WriteString(l.fields)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// dumpFields renders the component and metadata of a payload as logfmt
+func dumpFields(logData map[string]interface{}) string {
+	fields := map[string]interface{}{}
+	if metadata, ok := logData["metadata"].(map[string]interface{}); ok {
+		fields = metadata
+	}
+	if component, ok := logData["component"].(string); ok && component != "" {
+		fields = mergeFields(map[string]interface{}{"component": component}, fields)
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(map[string]interface{}{"metadata": fields})
+	if err != nil {
+		return ""
+	}
+	logfmt, err := zlog.JSONToLogfmt(data)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(logfmt))
+}
+
+// padRight pads s with spaces to width bytes, leaving longer strings whole
+func padRight(s string, width int) string {
+	if len(s) >= width {
+		return s + " "
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -0,0 +1,147 @@
+package applogs
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// Request ID of the entries of Middleware
+const (
+	RequestIDField  = "request_id"   // Field holding the request ID
+	RequestIDHeader = "X-Request-ID" // Header the request ID is read from and echoed in
+)
+
+// maxRequestIDLength is the longest X-Request-ID accepted from a client
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key holding the request ID set by Middleware
+type requestIDKey struct{}
+
+// Middleware wraps next so that every request is logged with LogRequest, its
+// response with LogResponse, and a panic of next with LogPanic, the client
+// then receiving a 500 if nothing was written yet. A panic with
+// http.ErrAbortHandler is not logged and keeps aborting the request.
+//
+// The entries share a request ID in RequestIDField: the X-Request-ID of the
+// request when it is set to a reasonable value, a random ID otherwise, echoed
+// in the X-Request-ID header of the response. A child logger adding the ID is
+// stored in the request context, so handlers reach it with FromContext, and
+// RequestIDFromContext returns the ID itself.
+func (a *Applogs) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := RequestID(r)
+		w.Header().Set(RequestIDHeader, id)
+		log := a.WithFields(map[string]interface{}{RequestIDField: id})
+		url, clientIP := r.URL.String(), ClientIP(r)
+		log.LogRequest(r.Method, url, clientIP, r.Header)
+
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				log.LogPanic(p, r.Method, url, clientIP)
+				if !rec.wroteHeader {
+					rec.WriteHeader(http.StatusInternalServerError)
+				}
+			}
+			log.LogResponse(rec.status(), time.Since(start))
+		}()
+		next.ServeHTTP(rec, r.WithContext(WithRequestID(IntoContext(r.Context(), log), id)))
+	})
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID id, for
+// middlewares of other frameworks doing what Middleware does
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored by Middleware, or "" if absent
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID returns the X-Request-ID of r, or a new random ID when it is
+// missing, too long or not printable ASCII, which keeps clients from
+// injecting arbitrary content into the entries
+func RequestID(r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" || len(id) > maxRequestIDLength {
+		return logger.NewEntryID()
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return logger.NewEntryID()
+		}
+	}
+	return id
+}
+
+// ClientIP returns the address of the client of r: the first address of
+// X-Forwarded-For when a proxy set it, the host of RemoteAddr otherwise.
+// X-Forwarded-For is only trustworthy behind a proxy that overwrites it.
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := strings.TrimSpace(first); ip != "" {
+			return ip
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush passes flushes through to writers that support them, for streaming handlers
+func (w *statusRecorder) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the wrapped writer
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// status returns the status code of the response, 200 when the handler wrote nothing
+func (w *statusRecorder) status() int {
+	if !w.wroteHeader {
+		return http.StatusOK
+	}
+	return w.code
+}
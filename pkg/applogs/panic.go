@@ -0,0 +1,57 @@
+package applogs
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Fields describing the panic value in the entries of LogPanic
+const (
+	PanicField     = "panic"      // Message of the panic value
+	PanicTypeField = "panic_type" // Go type of a panic value that is not a string
+)
+
+var (
+	panicFieldsMu sync.RWMutex
+	panicFields   func(value interface{}) map[string]interface{} // Replaces DefaultPanicFields when set
+)
+
+// DefaultPanicFields is the built-in mapping of a recovered panic value to
+// log fields. A string is recorded as is in PanicField. An error is recorded
+// as its message, with its type in PanicTypeField, and the chain of errors
+// it wraps in panic_chain as for error fields. Any other value is rendered
+// with %+v, which keeps the field names of a struct, and its type recorded.
+func DefaultPanicFields(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case string:
+		return map[string]interface{}{PanicField: v}
+	case error:
+		fields := map[string]interface{}{PanicField: v.Error(), PanicTypeField: fmt.Sprintf("%T", v)}
+		if chain := errorChain(v); len(chain) > 1 {
+			fields[PanicField+ChainSuffix] = chain
+		}
+		return fields
+	default:
+		return map[string]interface{}{PanicField: fmt.Sprintf("%+v", v), PanicTypeField: fmt.Sprintf("%T", v)}
+	}
+}
+
+// SetPanicFields overrides how LogPanic maps a panic value to fields, e.g.
+// to extract the code of an application error type. A custom mapping can
+// delegate to DefaultPanicFields; nil restores the default.
+func (a *Applogs) SetPanicFields(fn func(value interface{}) map[string]interface{}) {
+	panicFieldsMu.Lock()
+	defer panicFieldsMu.Unlock()
+	panicFields = fn
+}
+
+// panicValueFields maps a panic value with the configured mapping
+func panicValueFields(value interface{}) map[string]interface{} {
+	panicFieldsMu.RLock()
+	fn := panicFields
+	panicFieldsMu.RUnlock()
+	if fn == nil {
+		return DefaultPanicFields(value)
+	}
+	return fn(value)
+}
@@ -0,0 +1,216 @@
+package applogs
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Queue holds entries between the logging call and the worker pushing them.
+// The default is a buffered channel; a custom implementation can add
+// persistence, priorities or spilling to disk. Implementations must follow
+// this contract:
+//
+//   - Enqueue must not block. It returns false when the entry cannot be
+//     accepted, which is handled like a full channel, see SetOverflowPolicy.
+//   - Dequeue blocks until an entry is available and returns false once the
+//     queue is closed and every entry has been dequeued. Only the worker
+//     goroutine calls it.
+//   - Len and Cap drive load shedding, adaptive batching and heartbeats. Cap
+//     may be 0 for an unbounded queue, which disables load shedding.
+//   - Close is called once, by StopLogger.
+//   - Flush queues a marker entry, with an empty level and message, that must
+//     be dequeued like any other.
+//
+// Enqueue, Len and Cap are called from any goroutine logging.
+type Queue interface {
+	Enqueue(entry Entry) bool
+	Dequeue() (Entry, bool)
+	Len() int
+	Cap() int
+	Close()
+}
+
+// Entry is a log entry held by a Queue. Its content is opaque apart from
+// the level and message, which let a queue order or inspect entries.
+type Entry struct {
+	entry logEntry
+}
+
+// Level returns the level of the entry
+func (e Entry) Level() string {
+	return e.entry.level
+}
+
+// Message returns the message of the entry
+func (e Entry) Message() string {
+	return e.entry.message
+}
+
+// timedQueue is implemented by the built-in queues, so that the worker can
+// wait a bounded time for more entries before flushing a partial batch
+type timedQueue interface {
+	// dequeueWithin is Dequeue returning timedOut when no entry came within timeout
+	dequeueWithin(timeout time.Duration) (entry Entry, ok, timedOut bool)
+}
+
+// channelQueue is the default Queue, a buffered channel
+type channelQueue chan Entry
+
+// NewChannelQueue returns the default Queue: a buffered channel of size entries
+func NewChannelQueue(size int) Queue {
+	return channelQueue(make(chan Entry, size))
+}
+
+func (q channelQueue) Enqueue(entry Entry) bool {
+	select {
+	case q <- entry:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q channelQueue) Dequeue() (Entry, bool) {
+	entry, ok := <-q
+	return entry, ok
+}
+
+func (q channelQueue) dequeueWithin(timeout time.Duration) (Entry, bool, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case entry, ok := <-q:
+		return entry, ok, false
+	case <-timer.C:
+		return Entry{}, true, true
+	}
+}
+
+func (q channelQueue) Len() int { return len(q) }
+
+func (q channelQueue) Cap() int { return cap(q) }
+
+func (q channelQueue) Close() { close(q) }
+
+// priorityChannelQueue gives error and fatal entries their own channel,
+// always drained before the others. Both channels share a capacity of size
+// entries.
+type priorityChannelQueue struct {
+	high   chan Entry
+	low    chan Entry
+	size   int
+	queued atomic.Int64 // Entries accepted and not yet dequeued, at most size
+}
+
+// NewPriorityQueue returns a Queue dequeuing error and fatal entries before
+// the others. It holds size entries in all, of either kind. It is the queue
+// of NewLogger when APPLG_PRIORITY_QUEUE is set.
+func NewPriorityQueue(size int) Queue {
+	return &priorityChannelQueue{high: make(chan Entry, size), low: make(chan Entry, size), size: size}
+}
+
+func (q *priorityChannelQueue) Enqueue(entry Entry) bool {
+	if q.queued.Add(1) > int64(q.size) {
+		q.queued.Add(-1)
+		return false
+	}
+	target := q.low
+	if level := entry.Level(); level == "error" || level == "fatal" {
+		target = q.high
+	}
+	select {
+	case target <- entry:
+		return true
+	default:
+		q.queued.Add(-1)
+		return false
+	}
+}
+
+func (q *priorityChannelQueue) Dequeue() (Entry, bool) {
+	entry, ok := q.receive()
+	if ok {
+		q.queued.Add(-1)
+	}
+	return entry, ok
+}
+
+// receive prefers the high channel whenever it holds an entry. Both channels
+// are closed together, so once either reports closed the other only has to
+// be drained.
+func (q *priorityChannelQueue) receive() (Entry, bool) {
+	select {
+	case entry, ok := <-q.high:
+		if ok {
+			return entry, true
+		}
+		entry, ok = <-q.low
+		return entry, ok
+	default:
+	}
+
+	select {
+	case entry, ok := <-q.high:
+		if ok {
+			return entry, true
+		}
+		entry, ok = <-q.low
+		return entry, ok
+	case entry, ok := <-q.low:
+		if ok {
+			return entry, true
+		}
+		entry, ok = <-q.high
+		return entry, ok
+	}
+}
+
+// dequeueWithin waits like Dequeue, no longer than timeout
+func (q *priorityChannelQueue) dequeueWithin(timeout time.Duration) (Entry, bool, bool) {
+	entry, ok, timedOut := q.receiveWithin(timeout)
+	if ok && !timedOut {
+		q.queued.Add(-1)
+	}
+	return entry, ok, timedOut
+}
+
+// receiveWithin receives like receive, no longer than timeout
+func (q *priorityChannelQueue) receiveWithin(timeout time.Duration) (Entry, bool, bool) {
+	select {
+	case entry, ok := <-q.high:
+		if ok {
+			return entry, true, false
+		}
+		entry, ok = <-q.low
+		return entry, ok, false
+	default:
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case entry, ok := <-q.high:
+		if ok {
+			return entry, true, false
+		}
+		entry, ok = <-q.low
+		return entry, ok, false
+	case entry, ok := <-q.low:
+		if ok {
+			return entry, true, false
+		}
+		entry, ok = <-q.high
+		return entry, ok, false
+	case <-timer.C:
+		return Entry{}, true, true
+	}
+}
+
+func (q *priorityChannelQueue) Len() int { return len(q.high) + len(q.low) }
+
+func (q *priorityChannelQueue) Cap() int { return q.size }
+
+func (q *priorityChannelQueue) Close() {
+	close(q.high)
+	close(q.low)
+}
@@ -0,0 +1,63 @@
+package applogs
+
+import "github.com/bashx3r0/scala-applogs-client/internal/logger"
+
+// Stats is a snapshot of the queue of a logger and of its delivery counters,
+// see Stats. The counters are totals since the logger was created, shared
+// with its views but not with other loggers.
+type Stats struct {
+	QueueLength     int    // Entries waiting in the queue
+	QueueCapacity   int    // Entries the queue can hold, 0 when unbounded or without a queue
+	Dropped         uint64 // Entries dropped because the queue was full
+	Pushed          uint64 // Entries delivered to Redis or the sink, recovered entries aside
+	FallbackWritten uint64 // Entries saved to fallback
+	Recovered       uint64 // Fallback entries resent by recovery
+	RecoveryFailed  uint64 // Fallback entries recovery failed to resend, once per pass
+}
+
+// LatencyHistogram is a snapshot of push durations, see PushLatency
+type LatencyHistogram = logger.LatencyHistogram
+
+// Stats returns the current queue length and capacity with the delivery
+// counters. It is safe to call while logging, e.g. to feed a metrics
+// dashboard: the counters are read atomically, though not all at once.
+func (a *Applogs) Stats() Stats {
+	counters := a.instance().Counters()
+	return Stats{
+		QueueLength:     a.queueDepth(),
+		QueueCapacity:   a.queueCapacity(),
+		Dropped:         counters.Dropped,
+		Pushed:          counters.Pushed,
+		FallbackWritten: counters.FallbackWritten,
+		Recovered:       counters.Recovered,
+		RecoveryFailed:  counters.RecoveryFailed,
+	}
+}
+
+// PushLatency returns the histogram of the durations of the pushes to Redis
+// or the sink since the process started, each retry counted on its own
+func (a *Applogs) PushLatency() LatencyHistogram {
+	return logger.PushLatency()
+}
+
+// ProcessStats is a snapshot of the totals shared by every logger of the
+// process, see ReadProcessStats
+type ProcessStats struct {
+	Shed         uint64           // Entries dropped by load shedding
+	Sampled      uint64           // Entries dropped by adaptive sampling
+	Lost         uint64           // Entries that reached neither Redis nor the fallback disk
+	FallbackFull uint64           // Entries dropped to keep the fallback directory within its budget
+	PushLatency  LatencyHistogram // Durations of the pushes to Redis or the sink
+}
+
+// ReadProcessStats returns the totals shared by every logger since the
+// process started, which Stats leaves out as they belong to no logger
+func ReadProcessStats() ProcessStats {
+	return ProcessStats{
+		Shed:         logger.LogsShedTotal(),
+		Sampled:      logger.LogsSampledTotal(),
+		Lost:         logger.LogsLostTotal(),
+		FallbackFull: logger.FallbackDroppedTotal(),
+		PushLatency:  logger.PushLatency(),
+	}
+}
@@ -0,0 +1,148 @@
+package applogs
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+)
+
+// RedactedValue replaces the value of fields tagged log:"sensitive"
+const RedactedValue = config.RedactedValue
+
+// cycleValue replaces a struct pointer already being expanded higher up
+const cycleValue = "[cycle]"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// InfoStruct logs the exported fields of a struct (or pointer to struct) at
+// info level. Field names follow the json tag; fields tagged log:"-" are
+// skipped and fields tagged log:"sensitive" are logged as RedactedValue.
+// Nested structs become nested objects, and a pointer cycle is logged as
+// "[cycle]" instead of being expanded again.
+func (a *Applogs) InfoStruct(message string, v interface{}) {
+	a.logAsync("info", message, structFields(v))
+}
+
+// structFields builds a fields map from a struct; any other value is logged under "value"
+func structFields(v interface{}) map[string]interface{} {
+	visiting := map[uintptr]bool{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return map[string]interface{}{}
+		}
+		visiting[rv.Pointer()] = true
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct || hasCustomEncoding(rv.Type()) {
+		return map[string]interface{}{"value": v}
+	}
+
+	fields := make(map[string]interface{})
+	expandStruct(fields, rv, visiting)
+	return fields
+}
+
+// expandStruct adds the fields of a struct value to fields. visiting holds
+// the struct pointers on the current path, to detect cycles.
+func expandStruct(fields map[string]interface{}, rv reflect.Value, visiting map[uintptr]bool) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		logTag := field.Tag.Get("log")
+		if !field.IsExported() || logTag == "-" {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+		value := rv.Field(i)
+
+		// Untagged embedded structs are flattened, as encoding/json does;
+		// fields of the outer struct take precedence
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			if embedded, ok := fieldValue(value, visiting).(map[string]interface{}); ok {
+				if logTag == "sensitive" {
+					continue
+				}
+				for k, v := range embedded {
+					if _, set := fields[k]; !set {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+		if logTag == "sensitive" {
+			fields[name] = RedactedValue
+			continue
+		}
+		fields[name] = fieldValue(value, visiting)
+	}
+}
+
+// fieldValue converts a field to its logged value, expanding nested structs
+func fieldValue(value reflect.Value, visiting map[uintptr]bool) interface{} {
+	var ptr uintptr
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		if value.Kind() == reflect.Ptr && ptr == 0 {
+			ptr = value.Pointer()
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct || hasCustomEncoding(value.Type()) {
+		return value.Interface()
+	}
+
+	if ptr != 0 {
+		if visiting[ptr] {
+			return cycleValue
+		}
+		visiting[ptr] = true
+		defer delete(visiting, ptr)
+	}
+	nested := make(map[string]interface{})
+	expandStruct(nested, value, visiting)
+	return nested
+}
+
+// hasCustomEncoding reports whether a type encodes itself, like time.Time,
+// and must be logged as is rather than expanded
+func hasCustomEncoding(rt reflect.Type) bool {
+	ptr := reflect.PointerTo(rt)
+	return rt.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType) ||
+		rt.Implements(textMarshalerType) || ptr.Implements(textMarshalerType)
+}
+
+// jsonFieldName returns the name of a field from its json tag, whether
+// omitempty is set, and whether the tag excludes the field
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
@@ -0,0 +1,157 @@
+package applogs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IgnoredField holds the last argument of a structured call with an odd
+// number of key/value arguments, as zap's SugaredLogger does
+const IgnoredField = "ignored"
+
+// SugaredApplogs wraps a logger with the looser API of zap's SugaredLogger:
+// Sprint-style, printf-style (suffix f), println-style (suffix ln) and
+// key/value (suffix w) variants of each level. Entries go through the same
+// asynchronous path as the Applogs methods.
+type SugaredApplogs struct {
+	base *Applogs
+}
+
+// Sugar returns a sugared view of the logger
+func (a *Applogs) Sugar() *SugaredApplogs {
+	return &SugaredApplogs{base: a}
+}
+
+// Desugar returns the underlying logger
+func (s *SugaredApplogs) Desugar() *Applogs {
+	return s.base
+}
+
+// Debug logs the arguments joined as by fmt.Sprint
+func (s *SugaredApplogs) Debug(args ...interface{}) { s.log("debug", "", args, nil) }
+
+// Info logs the arguments joined as by fmt.Sprint
+func (s *SugaredApplogs) Info(args ...interface{}) { s.log("info", "", args, nil) }
+
+// Warn logs the arguments joined as by fmt.Sprint
+func (s *SugaredApplogs) Warn(args ...interface{}) { s.log("warn", "", args, nil) }
+
+// Error logs the arguments joined as by fmt.Sprint
+func (s *SugaredApplogs) Error(args ...interface{}) { s.log("error", "", args, nil) }
+
+// Fatal logs the arguments joined as by fmt.Sprint
+func (s *SugaredApplogs) Fatal(args ...interface{}) { s.log("fatal", "", args, nil) }
+
+// Debugf logs a message formatted as by fmt.Sprintf
+func (s *SugaredApplogs) Debugf(template string, args ...interface{}) {
+	s.log("debug", template, args, nil)
+}
+
+// Infof logs a message formatted as by fmt.Sprintf
+func (s *SugaredApplogs) Infof(template string, args ...interface{}) {
+	s.log("info", template, args, nil)
+}
+
+// Warnf logs a message formatted as by fmt.Sprintf
+func (s *SugaredApplogs) Warnf(template string, args ...interface{}) {
+	s.log("warn", template, args, nil)
+}
+
+// Errorf logs a message formatted as by fmt.Sprintf
+func (s *SugaredApplogs) Errorf(template string, args ...interface{}) {
+	s.log("error", template, args, nil)
+}
+
+// Fatalf logs a message formatted as by fmt.Sprintf
+func (s *SugaredApplogs) Fatalf(template string, args ...interface{}) {
+	s.log("fatal", template, args, nil)
+}
+
+// Debugln logs the arguments joined as by fmt.Sprintln, without the newline
+func (s *SugaredApplogs) Debugln(args ...interface{}) { s.logln("debug", args) }
+
+// Infoln logs the arguments joined as by fmt.Sprintln, without the newline
+func (s *SugaredApplogs) Infoln(args ...interface{}) { s.logln("info", args) }
+
+// Warnln logs the arguments joined as by fmt.Sprintln, without the newline
+func (s *SugaredApplogs) Warnln(args ...interface{}) { s.logln("warn", args) }
+
+// Errorln logs the arguments joined as by fmt.Sprintln, without the newline
+func (s *SugaredApplogs) Errorln(args ...interface{}) { s.logln("error", args) }
+
+// Fatalln logs the arguments joined as by fmt.Sprintln, without the newline
+func (s *SugaredApplogs) Fatalln(args ...interface{}) { s.logln("fatal", args) }
+
+// Debugw logs a message with fields given as alternating keys and values
+func (s *SugaredApplogs) Debugw(message string, keysAndValues ...interface{}) {
+	s.log("debug", message, nil, keysAndValues)
+}
+
+// Infow logs a message with fields given as alternating keys and values
+func (s *SugaredApplogs) Infow(message string, keysAndValues ...interface{}) {
+	s.log("info", message, nil, keysAndValues)
+}
+
+// Warnw logs a message with fields given as alternating keys and values
+func (s *SugaredApplogs) Warnw(message string, keysAndValues ...interface{}) {
+	s.log("warn", message, nil, keysAndValues)
+}
+
+// Errorw logs a message with fields given as alternating keys and values
+func (s *SugaredApplogs) Errorw(message string, keysAndValues ...interface{}) {
+	s.log("error", message, nil, keysAndValues)
+}
+
+// Fatalw logs a message with fields given as alternating keys and values
+func (s *SugaredApplogs) Fatalw(message string, keysAndValues ...interface{}) {
+	s.log("fatal", message, nil, keysAndValues)
+}
+
+// log formats the message like zap: the template alone when there are no
+// args, fmt.Sprint of the args when there is no template, fmt.Sprintf
+// otherwise. Nothing is formatted for a disabled level.
+func (s *SugaredApplogs) log(level, template string, args, keysAndValues []interface{}) {
+	if !s.base.admits(level) {
+		return
+	}
+	message := template
+	if len(args) > 0 {
+		if template == "" {
+			message = fmt.Sprint(args...)
+		} else {
+			message = fmt.Sprintf(template, args...)
+		}
+	}
+	s.base.submit(s.base.newEntry(level, message, sweetenFields(keysAndValues)))
+}
+
+// logln logs the args joined with spaces, as fmt.Sprintln does
+func (s *SugaredApplogs) logln(level string, args []interface{}) {
+	if !s.base.admits(level) {
+		return
+	}
+	message := strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+	s.base.submit(s.base.newEntry(level, message, nil))
+}
+
+// sweetenFields turns alternating keys and values into fields. Keys that are
+// not strings are formatted with fmt.Sprint, and a trailing key without a
+// value is kept under IgnoredField rather than lost.
+func sweetenFields(keysAndValues []interface{}) map[string]interface{} {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i == len(keysAndValues)-1 {
+			fields[IgnoredField] = keysAndValues[i]
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields[key] = keysAndValues[i+1]
+	}
+	return fields
+}
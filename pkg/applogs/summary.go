@@ -0,0 +1,37 @@
+package applogs
+
+import (
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// LogSummary writes the full entry to the main key and a compact summary
+// entry, holding the summary message and the fields selected by
+// APPLG_SUMMARY_FIELDS or SetSummaryFields, to the summary list (the usual
+// key followed by ":summary"), so dashboards can query the small list alone.
+func (a *Applogs) LogSummary(level, summary string, fields map[string]interface{}) {
+	if !a.admits(level) {
+		return
+	}
+	a.enqueue(a.newEntry(level, summary, fields))
+
+	entry := a.newEntry(level, summary, summaryFields(fields))
+	entry.summary = true
+	a.submit(entry)
+}
+
+// SetSummaryFields selects the fields copied into the summary entries of
+// LogSummary (default: status_code, duration_ms and error)
+func (a *Applogs) SetSummaryFields(fields ...string) {
+	logger.SetSummaryFields(fields...)
+}
+
+// summaryFields copies the selected summary fields present in fields
+func summaryFields(fields map[string]interface{}) map[string]interface{} {
+	selected := make(map[string]interface{})
+	for _, name := range logger.SummaryFields() {
+		if value, ok := fields[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected
+}
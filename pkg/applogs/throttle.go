@@ -0,0 +1,70 @@
+package applogs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleWindow is the window used by WarnThrottled unless SetThrottleWindow is called
+const DefaultThrottleWindow = time.Minute
+
+// throttler tracks occurrences of throttled warnings per key
+type throttler struct {
+	mu     sync.Mutex
+	window time.Duration
+	counts map[string]*throttleCount
+}
+
+// throttleCount holds the occurrences of one key in the current window
+type throttleCount struct {
+	message     string
+	occurrences int
+}
+
+func newThrottler() *throttler {
+	return &throttler{window: DefaultThrottleWindow, counts: make(map[string]*throttleCount)}
+}
+
+// SetThrottleWindow changes the window used by WarnThrottled for windows opened afterwards
+func (a *Applogs) SetThrottleWindow(window time.Duration) {
+	a.throttle.mu.Lock()
+	defer a.throttle.mu.Unlock()
+	a.throttle.window = window
+}
+
+// WarnThrottled logs a warning for a frequent condition identified by key.
+// The first occurrence in each window is logged with its fields; later ones
+// are only counted, and when the window ends a rollup warning reports how
+// many times the condition occurred.
+func (a *Applogs) WarnThrottled(key, message string, fields map[string]interface{}) {
+	a.throttle.mu.Lock()
+	if count, ok := a.throttle.counts[key]; ok {
+		count.occurrences++
+		a.throttle.mu.Unlock()
+		return
+	}
+	window := a.throttle.window
+	a.throttle.counts[key] = &throttleCount{message: message, occurrences: 1}
+	a.throttle.mu.Unlock()
+
+	a.logAsync("warn", message, fields)
+	time.AfterFunc(window, func() { a.rollupThrottled(key, window) })
+}
+
+// rollupThrottled closes the window of key, logging a rollup if occurrences were suppressed
+func (a *Applogs) rollupThrottled(key string, window time.Duration) {
+	a.throttle.mu.Lock()
+	count := a.throttle.counts[key]
+	delete(a.throttle.counts, key)
+	a.throttle.mu.Unlock()
+
+	if count == nil || count.occurrences < 2 {
+		return
+	}
+	a.logAsync("warn", fmt.Sprintf("%s occurred %d times in the last %s", count.message, count.occurrences, window), map[string]interface{}{
+		"throttle_key":   key,
+		"occurrences":    count.occurrences,
+		"window_seconds": window.Seconds(),
+	})
+}
@@ -0,0 +1,56 @@
+package applogs
+
+import (
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+)
+
+// DurationField holds the elapsed time of timer entries, in milliseconds
+// with microsecond precision, like the duration_ms of LogResponse
+const DurationField = "duration_ms"
+
+// Timer measures an operation and logs its duration when stopped. It is a
+// small value without allocation of its own, so timing hot paths is cheap.
+type Timer struct {
+	logger *Applogs
+	name   string
+	fields map[string]interface{}
+	start  time.Time
+}
+
+// StartTimer starts timing the operation name. Stop logs an entry with name as
+// message, the given fields and DurationField, at the level set by
+// APPLG_TIMER_LEVEL or SetTimerLevel (info by default):
+//
+//	timer := log.StartTimer("db.query", map[string]interface{}{"table": "users"})
+//	defer timer.Stop()
+func (a *Applogs) StartTimer(name string, fields map[string]interface{}) Timer {
+	return Timer{logger: a, name: name, fields: fields, start: time.Now()}
+}
+
+// SetTimerLevel sets the level of the entries logged by timers
+func (a *Applogs) SetTimerLevel(level string) {
+	logger.SetTimerLevel(level)
+}
+
+// Stop logs the time elapsed since StartTimer and returns it. The fields
+// passed to StartTimer are copied, not modified.
+func (t Timer) Stop() time.Duration {
+	elapsed := time.Since(t.start)
+	if t.logger == nil {
+		return elapsed
+	}
+	level := logger.TimerLevel()
+	if !t.logger.admits(level) {
+		return elapsed
+	}
+
+	fields := make(map[string]interface{}, len(t.fields)+1)
+	for k, v := range t.fields {
+		fields[k] = v
+	}
+	fields[DurationField] = float64(elapsed.Microseconds()) / 1000
+	t.logger.submit(t.logger.newEntry(level, t.name, fields))
+	return elapsed
+}
@@ -0,0 +1,66 @@
+package applogs
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+)
+
+// QueryField holds the query parameters of requests logged by LogRequest,
+// see SetLogQueryParams
+const QueryField = "query"
+
+// SensitiveParams are the fragments of parameter names whose values are
+// replaced by config.RedactedValue. Names are matched case-insensitively and
+// by substring, so "access_token" and "X-Api-Key" are redacted too.
+var SensitiveParams = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "api-key", "authorization", "session"}
+
+// IsSensitiveParam reports whether the value of a parameter named name is redacted
+func IsSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, fragment := range SensitiveParams {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValuesFields converts query parameters or form data into log fields: a
+// parameter given once becomes a string, one given several times a slice of
+// strings, and the values of sensitive parameters are redacted
+func ValuesFields(values url.Values) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values))
+	for name, list := range values {
+		switch {
+		case IsSensitiveParam(name):
+			fields[name] = config.RedactedValue
+		case len(list) == 1:
+			fields[name] = list[0]
+		default:
+			fields[name] = append([]string(nil), list...)
+		}
+	}
+	return fields
+}
+
+// addQueryFields records the query parameters of a request URL in fields,
+// and replaces the URL with one whose sensitive parameters are redacted.
+// URLs that do not parse are left untouched.
+func addQueryFields(fields map[string]interface{}, rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return
+	}
+	query := u.Query()
+	fields[QueryField] = ValuesFields(query)
+
+	for name := range query {
+		if IsSensitiveParam(name) {
+			query[name] = []string{config.RedactedValue}
+		}
+	}
+	u.RawQuery = query.Encode()
+	fields["url"] = u.String()
+}
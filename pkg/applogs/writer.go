@@ -0,0 +1,63 @@
+package applogs
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxWriterLine is the longest line Writer buffers while waiting for its
+// newline; a longer one is logged in pieces of this size
+const maxWriterLine = 64 << 10
+
+// Writer returns a writer logging each line written to it as an entry at
+// level, e.g. for log.SetOutput or http.Server.ErrorLog, so that the
+// standard library and third-party packages feed the same pipeline. A level
+// other than debug, info, warn, error or fatal logs as info. Payloads of
+// several lines give one entry per line, and a line split across writes is
+// logged once its newline arrives; empty lines are skipped. The writer is
+// safe for concurrent use.
+func (a *Applogs) Writer(level string) io.Writer {
+	return &lineWriter{log: a, level: consumedLevel(level)}
+}
+
+// lineWriter buffers the bytes written after the last newline
+type lineWriter struct {
+	log   *Applogs
+	level string
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+// Write logs the complete lines of p, keeping the rest for the next call
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.logLine(w.pending[:i])
+		w.pending = w.pending[i+1:]
+	}
+	for len(w.pending) >= maxWriterLine {
+		w.logLine(w.pending[:maxWriterLine])
+		w.pending = w.pending[maxWriterLine:]
+	}
+	if len(w.pending) == 0 {
+		w.pending = nil // Releases the buffer of a large payload
+	}
+	return len(p), nil
+}
+
+// logLine logs one line, without its carriage return
+func (w *lineWriter) logLine(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) > 0 {
+		w.log.logAsync(w.level, string(line), nil)
+	}
+}
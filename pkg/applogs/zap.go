@@ -0,0 +1,93 @@
+//go:build !applogs_minimal
+
+package applogs
+
+import (
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Zap returns the Zap logger writing the console and syslog file output of
+// the logger, for integrations that need typed fields, With chaining or Zap
+// sampling. Entries written through it bypass the pipeline: they reach
+// neither Redis nor fallback. ZapCore gives a Zap logger that goes through
+// the pipeline instead. Not available with the applogs_minimal build tag.
+func (a *Applogs) Zap() *zap.Logger {
+	if a.nop {
+		return zap.NewNop()
+	}
+	return a.instance().Logger()
+}
+
+// ZapCore returns a Zap core logging each entry through the pipeline of the
+// logger, like Info and the other methods: zap.New(log.ZapCore()) gives a
+// *zap.Logger whose entries reach Redis, fallback, the console and the
+// syslog file. The fields of the entry and of With become its metadata.
+// DPanic and panic entries are logged as errors, Zap then panicking as
+// usual. Fatal entries are delivered before the call returns and follow the
+// fatal mode, Zap exiting only if the process has not.
+func (a *Applogs) ZapCore() zapcore.Core {
+	return &pipelineCore{log: a}
+}
+
+// pipelineCore hands Zap entries to an Applogs logger
+type pipelineCore struct {
+	log    *Applogs
+	fields []zapcore.Field // Added by With
+}
+
+// Enabled reports whether entries of lvl pass the level of the logger
+func (c *pipelineCore) Enabled(lvl zapcore.Level) bool {
+	return logger.ComponentLevelEnabled(c.log.component, zapLevel(lvl))
+}
+
+// With returns a core adding fields to every entry
+func (c *pipelineCore) With(fields []zapcore.Field) zapcore.Core {
+	return &pipelineCore{log: c.log, fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+// Check adds the core to the entries it is enabled for
+func (c *pipelineCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write logs the entry with its fields as metadata
+func (c *pipelineCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	log := c.log
+	if entry.Level >= zapcore.FatalLevel {
+		log = log.WithSync() // Delivered, and exiting in FatalExit mode, before Zap exits
+	}
+	log.logAsync(zapLevel(entry.Level), entry.Message, enc.Fields)
+	return nil
+}
+
+// Sync is a no-op: entries are flushed by Flush and StopLogger
+func (c *pipelineCore) Sync() error {
+	return nil
+}
+
+// zapLevel maps a Zap level to the level of an entry
+func zapLevel(lvl zapcore.Level) string {
+	switch {
+	case lvl < zapcore.InfoLevel:
+		return "debug"
+	case lvl == zapcore.InfoLevel:
+		return "info"
+	case lvl == zapcore.WarnLevel:
+		return "warn"
+	case lvl < zapcore.FatalLevel:
+		return "error"
+	}
+	return "fatal"
+}
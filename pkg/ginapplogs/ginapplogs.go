@@ -0,0 +1,58 @@
+// Package ginapplogs logs the requests, responses and panics of a Gin
+// engine through an applogs logger. It lives in its own package so that
+// core users never pull in Gin.
+package ginapplogs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/gin-gonic/gin"
+)
+
+// RouteField holds the route pattern of the request, such as /orders/:id
+const RouteField = "route"
+
+// Middleware returns a Gin handler doing what applogs.Middleware does for
+// net/http: it logs every request with LogRequest, its response with the
+// final status and latency with LogResponse, and a panic of a later handler
+// with LogPanic, aborting with a 500 if nothing was written yet. The client
+// IP comes from c.ClientIP, so it follows the trusted proxies of the engine.
+//
+// The entries carry the route pattern in RouteField, when a route matched,
+// and share a request ID in applogs.RequestIDField, read from X-Request-ID
+// or generated and echoed in the response. A child logger adding both is
+// stored in the request context, for applogs.FromContext(c.Request.Context()).
+func Middleware(log *applogs.Applogs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := applogs.RequestID(c.Request)
+		c.Header(applogs.RequestIDHeader, id)
+		fields := map[string]interface{}{applogs.RequestIDField: id}
+		if route := c.FullPath(); route != "" {
+			fields[RouteField] = route
+		}
+		requestLog := log.WithFields(fields)
+		method, url, clientIP := c.Request.Method, c.Request.URL.String(), c.ClientIP()
+		requestLog.LogRequest(method, url, clientIP, c.Request.Header)
+
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				requestLog.LogPanic(p, method, url, clientIP)
+				if c.Writer.Written() {
+					c.Abort()
+				} else {
+					c.AbortWithStatus(http.StatusInternalServerError)
+				}
+			}
+			requestLog.LogResponse(c.Writer.Status(), time.Since(start))
+		}()
+		ctx := applogs.WithRequestID(applogs.IntoContext(c.Request.Context(), requestLog), id)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
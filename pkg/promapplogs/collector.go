@@ -0,0 +1,119 @@
+// Package promapplogs exports the health of an applogs logger as Prometheus
+// metrics. It lives in its own package so that core users never pull in the
+// Prometheus client.
+package promapplogs
+
+import (
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Process-wide metrics, see NewProcessCollector
+var (
+	droppedDesc = prometheus.NewDesc("applogs_logs_dropped_total",
+		"Entries discarded before reaching Redis, the sink or fallback, by reason.", []string{"reason"}, nil)
+	pushDurationDesc = prometheus.NewDesc("applogs_push_duration_seconds",
+		"Duration of the pushes to Redis or the sink, each retry counted on its own.", nil, nil)
+)
+
+// collector reads the Stats of a logger at each scrape
+type collector struct {
+	log *applogs.Applogs
+
+	pushedDesc         *prometheus.Desc
+	queueDroppedDesc   *prometheus.Desc
+	fallbackDesc       *prometheus.Desc
+	recoveredDesc      *prometheus.Desc
+	recoveryFailedDesc *prometheus.Desc
+	queueLengthDesc    *prometheus.Desc
+	queueCapacityDesc  *prometheus.Desc
+}
+
+// NewCollector returns a collector exporting the Stats of log. Register it
+// with the registry of your choice:
+// prometheus.MustRegister(promapplogs.NewCollector(log)). Its metrics are
+// labelled with the identity of log when the collector is created, facility,
+// instance_type, service and instance_id, so that the collectors of several
+// loggers can be registered together. The totals shared by every logger are
+// exported by NewProcessCollector.
+func NewCollector(log *applogs.Applogs) prometheus.Collector {
+	cfg := log.EffectiveConfig()
+	labels := prometheus.Labels{
+		"facility":      cfg.FacilityID,
+		"instance_type": cfg.InstanceType,
+		"service":       cfg.ServiceName,
+		"instance_id":   cfg.InstanceID,
+	}
+	return &collector{
+		log: log,
+		pushedDesc: prometheus.NewDesc("applogs_logs_pushed_total",
+			"Entries delivered to Redis or the sink, recovered entries aside.", nil, labels),
+		queueDroppedDesc: prometheus.NewDesc("applogs_queue_dropped_total",
+			"Entries dropped because the queue was full.", nil, labels),
+		fallbackDesc: prometheus.NewDesc("applogs_fallback_written_total",
+			"Entries saved to fallback.", nil, labels),
+		recoveredDesc: prometheus.NewDesc("applogs_recovered_total",
+			"Fallback entries resent by recovery.", nil, labels),
+		recoveryFailedDesc: prometheus.NewDesc("applogs_recovery_failed_total",
+			"Fallback entries recovery failed to resend, once per pass.", nil, labels),
+		queueLengthDesc: prometheus.NewDesc("applogs_queue_length",
+			"Entries waiting in the queue.", nil, labels),
+		queueCapacityDesc: prometheus.NewDesc("applogs_queue_capacity",
+			"Entries the queue can hold, 0 when unbounded.", nil, labels),
+	}
+}
+
+// Describe sends the descriptors of every metric of the collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.pushedDesc, c.queueDroppedDesc, c.fallbackDesc, c.recoveredDesc,
+		c.recoveryFailedDesc, c.queueLengthDesc, c.queueCapacityDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect sends the current value of every metric of the collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.log.Stats()
+	ch <- prometheus.MustNewConstMetric(c.pushedDesc, prometheus.CounterValue, float64(stats.Pushed))
+	ch <- prometheus.MustNewConstMetric(c.queueDroppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.fallbackDesc, prometheus.CounterValue, float64(stats.FallbackWritten))
+	ch <- prometheus.MustNewConstMetric(c.recoveredDesc, prometheus.CounterValue, float64(stats.Recovered))
+	ch <- prometheus.MustNewConstMetric(c.recoveryFailedDesc, prometheus.CounterValue, float64(stats.RecoveryFailed))
+	ch <- prometheus.MustNewConstMetric(c.queueLengthDesc, prometheus.GaugeValue, float64(stats.QueueLength))
+	ch <- prometheus.MustNewConstMetric(c.queueCapacityDesc, prometheus.GaugeValue, float64(stats.QueueCapacity))
+}
+
+// processCollector reads the totals shared by every logger at each scrape
+type processCollector struct{}
+
+// NewProcessCollector returns a collector exporting the totals shared by
+// every logger of the process, see applogs.ReadProcessStats: the entries
+// dropped by reason and the push latency. Register a single one per
+// registry, next to the collector of each logger.
+func NewProcessCollector() prometheus.Collector {
+	return processCollector{}
+}
+
+// Describe sends the descriptors of every metric of the collector
+func (processCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- droppedDesc
+	ch <- pushDurationDesc
+}
+
+// Collect sends the current value of every metric of the collector
+func (processCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := applogs.ReadProcessStats()
+	for reason, count := range map[string]uint64{
+		"shed":          stats.Shed,
+		"sampled":       stats.Sampled,
+		"lost":          stats.Lost,
+		"fallback_full": stats.FallbackFull,
+	} {
+		ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(count), reason)
+	}
+
+	latency := stats.PushLatency
+	ch <- prometheus.MustNewConstHistogram(pushDurationDesc, latency.Count, latency.Sum.Seconds(), latency.Buckets)
+}
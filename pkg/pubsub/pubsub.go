@@ -0,0 +1,48 @@
+// Package pubsub provides an applogs sink for cloud pub/sub services.
+// Service specific publishers live in their own subpackages so that core
+// users never pull in cloud dependencies.
+package pubsub
+
+import (
+	"context"
+)
+
+// DefaultMaxBatch is the number of entries published per call when none is configured
+const DefaultMaxBatch = 100
+
+// Publisher sends a batch of messages to a cloud pub/sub service. The
+// partition key (the applogs Redis key) keeps entries of one instance ordered.
+type Publisher interface {
+	Publish(ctx context.Context, partitionKey string, messages [][]byte) error
+}
+
+// Sink adapts a Publisher to the applogs Sink interface, splitting large
+// pushes into batches of at most maxBatch messages
+type Sink struct {
+	publisher Publisher
+	maxBatch  int
+}
+
+// NewSink creates a sink publishing through publisher; maxBatch <= 0 uses DefaultMaxBatch
+func NewSink(publisher Publisher, maxBatch int) *Sink {
+	if maxBatch <= 0 {
+		maxBatch = DefaultMaxBatch
+	}
+	return &Sink{publisher: publisher, maxBatch: maxBatch}
+}
+
+// Push publishes entries in batches. It stops at the first failing batch so the
+// caller can save the entries to fallback; already published batches may be
+// delivered again after recovery.
+func (s *Sink) Push(ctx context.Context, key string, entries [][]byte) error {
+	for start := 0; start < len(entries); start += s.maxBatch {
+		end := start + s.maxBatch
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err := s.publisher.Publish(ctx, key, entries[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -17,7 +18,7 @@ import (
 )
 
 // Setup mock Redis using miniredis
-func setupMockRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+func setupMockRedis(t testing.TB) (*miniredis.Miniredis, *redis.Client) {
 	mr, err := miniredis.Run()
 	if err != nil {
 		t.Fatalf("Failed to start miniredis: %v", err)
@@ -28,21 +29,42 @@ func setupMockRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
 	return mr, client
 }
 
-// Helper function to create a mock fallback file path
-func createMockFallbackFile() string {
-	fallbackPath := "./logs/test_fallback.log"
-	_ = os.Remove(fallbackPath) // Ensure a clean slate for each test
+// Set a fixed instance identity through the environment read by InitApplogs
+func setIdentity(t testing.TB, instanceID string) {
+	t.Setenv("SERVICE_NAME", "test-service")
+	t.Setenv("INSTANCE_ID", instanceID)
+	t.Setenv("FACILITY_ID", "TEST")
+	t.Setenv("INSTANCE_TYPE", "unit")
+	t.Cleanup(applogs.ReloadSettings) // Undoes the settings the test changed
+}
+
+// Set a process-wide setting through the environment for the rest of the
+// test, the settings being read again once it is restored
+func setSettingEnv(t testing.TB, key, value string) {
+	t.Cleanup(applogs.ReloadSettings)
+	t.Setenv(key, value)
+	applogs.ReloadSettings()
+}
+
+// Helper function to create a mock fallback directory
+func createMockFallbackDir() string {
+	fallbackPath := "./logs/test_fallback"
+	_ = os.RemoveAll(fallbackPath) // Ensure a clean slate for each test
+	_ = os.MkdirAll(fallbackPath, 0755)
 	return fallbackPath
 }
 
-// Helper function to read fallback logs
-func readFallbackLogs(filepath string) []string {
-	data, _ := ioutil.ReadFile(filepath)
-	lines := bytes.Split(data, []byte("\n"))
+// Helper function to read fallback logs from every fallback file in a directory
+func readFallbackLogs(dir string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, "fallback_*.log"))
 	var logs []string
-	for _, line := range lines {
-		if len(line) > 0 {
-			logs = append(logs, string(line))
+	for _, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		lines := bytes.Split(data, []byte("\n"))
+		for _, line := range lines {
+			if len(line) > 0 {
+				logs = append(logs, string(line))
+			}
 		}
 	}
 	return logs
@@ -65,12 +87,12 @@ func listKeysAndValues(mr *miniredis.Miniredis) {
 // Test Cases
 
 func TestLogQueueProcessingWithMiniredis(t *testing.T) {
+	setIdentity(t, "1")
 	mr, client := setupMockRedis(t)
 	defer mr.Close()
 
-	logger.SetRedisClient(client)
-
 	applogs := applogs.NewLogger(10) // Initialize Applogs with queue size 10
+	applogs.SetRedisClient(client)
 
 	// Log entries
 	applogs.Info("Test info log", map[string]interface{}{"key": "value1"})
@@ -84,7 +106,7 @@ func TestLogQueueProcessingWithMiniredis(t *testing.T) {
 	listKeysAndValues(mr)
 
 	// Validate Redis logs
-	key := "key:value1"
+	key := "applogs:TEST:unit:test-service:1"
 	if !mr.Exists(key) {
 		t.Fatalf("Key %s does not exist in Redis", key)
 	}
@@ -96,24 +118,26 @@ func TestLogQueueProcessingWithMiniredis(t *testing.T) {
 
 	assert.Equal(t, 3, len(logs), "Redis should have received 3 logs")
 
-	// Validate content of the first log
+	// Validate content of the first log (LPUSH keeps the newest entry at the head)
 	var logData map[string]interface{}
-	json.Unmarshal([]byte(logs[0]), &logData)
+	json.Unmarshal([]byte(logs[len(logs)-1]), &logData)
 	assert.Equal(t, "info", logData["level"])
 	assert.Equal(t, "Test info log", logData["message"])
 }
 
 func TestFallbackMechanismWithMiniredis(t *testing.T) {
-	mr, _ := setupMockRedis(t)
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
 	defer mr.Close()
 
 	// Simulate Redis failure by shutting down miniredis
 	mr.Close()
 
-	fallbackPath := createMockFallbackFile()
+	fallbackPath := createMockFallbackDir()
 	logger.SetFallbackPath(fallbackPath)
 
 	applogs := applogs.NewLogger(10)
+	applogs.SetRedisClient(client)
 
 	// Log entry
 	applogs.Info("Fallback log test", map[string]interface{}{"key": "fallback1"})
@@ -0,0 +1,59 @@
+package applogs
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogWithAttachmentStoresBlob(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	blob := []byte{0x00, 0xff, 'b', 'o', 'd', 'y'}
+	err := log.LogWithAttachment("Upstream rejected request", map[string]interface{}{"status": 400}, blob)
+	assert.NoError(t, err)
+
+	entry := lastEntry(t, mr)
+	metadata := entry["metadata"].(map[string]interface{})
+	key, _ := metadata[applogs.AttachmentKeyField].(string)
+	assert.True(t, strings.HasPrefix(key, "applogs:TEST:unit:test-service:1:attachment:"), key)
+	assert.Equal(t, float64(400), metadata["status"])
+
+	stored, err := mr.Get(key)
+	assert.NoError(t, err)
+	assert.Equal(t, string(blob), stored, "The blob is stored byte for byte")
+	assert.Equal(t, 24*time.Hour, mr.TTL(key))
+	assert.Equal(t, 24*time.Hour, log.EffectiveConfig().AttachmentTTL)
+}
+
+func TestLogWithAttachmentReportsStoreFailure(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+
+	err := log.LogWithAttachment("Upstream rejected request", nil, []byte("body"))
+	assert.Error(t, err)
+
+	logs := readFallbackLogs(fallbackPath)
+	if assert.Equal(t, 1, len(logs), "The entry is logged without its attachment") {
+		assert.Contains(t, logs[0], `"`+applogs.AttachmentErrorField+`":`)
+		assert.NotContains(t, logs[0], applogs.AttachmentKeyField)
+	}
+}
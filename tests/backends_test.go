@@ -0,0 +1,78 @@
+package applogs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/bashx3r0/scala-applogs-client/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailingBackendFallsBackToItsOwnDirectory(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+
+	log := applogs.NewLogger(10)
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	publisher := &mockPublisher{fail: true}
+	log.AddBackend("gelf", pubsub.NewSink(publisher, 10))
+	t.Cleanup(func() { logger.AddBackend("gelf", nil) })
+	assert.Contains(t, log.EffectiveConfig().Backends, "backend:gelf")
+
+	log.Info("Sent to both", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "Redis received the entry")
+	assert.Empty(t, readFallbackLogs(fallbackPath), "Nothing is pending for Redis")
+	assert.Equal(t, 1, len(readFallbackLogs(filepath.Join(fallbackPath, "gelf"))), "The entry is pending for the backend")
+
+	publisher.mu.Lock()
+	publisher.fail = false
+	publisher.mu.Unlock()
+	logger.RecoverFallbackLogs()
+
+	published := publisher.published()
+	assert.Equal(t, 1, len(published), "Recovery sends the entry to the backend")
+	assert.Contains(t, published[0], "Sent to both")
+	logs, _ = mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "Redis does not receive the entry twice")
+	assert.Empty(t, readFallbackLogs(filepath.Join(fallbackPath, "gelf")))
+}
+
+func TestFailingRedisRecoversWithoutResendingToBackend(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	down, downClient := setupMockRedis(t)
+	down.Close()
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+
+	log := applogs.NewLogger(10)
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(downClient)
+	publisher := &mockPublisher{}
+	log.AddBackend("gelf", pubsub.NewSink(publisher, 10))
+	t.Cleanup(func() { logger.AddBackend("gelf", nil) })
+
+	log.Error("Redis is down", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Equal(t, 1, len(publisher.published()), "The backend received the entry")
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "The entry is pending for Redis")
+	assert.Empty(t, readFallbackLogs(filepath.Join(fallbackPath, "gelf")))
+
+	log.SetRedisClient(client)
+	logger.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "Recovery sends the entry to Redis")
+	assert.Equal(t, 1, len(publisher.published()), "The backend does not receive the entry twice")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
@@ -0,0 +1,80 @@
+package applogs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeAgedFallbackFile writes a fallback file holding one entry logged age ago
+func writeAgedFallbackFile(t *testing.T, dir, name string, age time.Duration) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"timestamp":     time.Now().Add(-age).UTC(),
+		"level":         "info",
+		"message":       "Delayed",
+		"service_name":  "test-service",
+		"instance_id":   "1",
+		"facility_id":   "TEST",
+		"instance_type": "unit",
+	})
+	if err := os.WriteFile(filepath.Join(dir, name), append(data, '\n'), 0644); err != nil {
+		t.Fatalf("Failed to write fallback file: %v", err)
+	}
+}
+
+func TestBacklogAgeWarning(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close() // The backlog cannot drain
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	events, cancel := log.Subscribe(100)
+	defer cancel()
+
+	writeAgedFallbackFile(t, fallbackPath, "fallback_1_100_20240101120000.log", 12*time.Minute)
+	writeAgedFallbackFile(t, fallbackPath, "fallback_1_100_20240101120900.log", 3*time.Minute)
+	writeAgedFallbackFile(t, fallbackPath, "fallback_2_100_20240101120000.log", time.Hour) // Another instance's
+
+	status := log.FallbackStatus()
+	assert.Equal(t, 2, status.Files, "Only this instance's files are pending")
+	assert.Greater(t, status.Bytes, int64(0))
+	assert.InDelta(t, 12*time.Minute, status.OldestAge, float64(time.Second), "The age is that of the oldest entry")
+
+	delayed := func() int {
+		count := 0
+		for len(events) > 0 {
+			if event := <-events; event.Type == applogs.EventBacklogDelayed {
+				count++
+			}
+		}
+		return count
+	}
+
+	// Below the threshold recovery stays quiet
+	log.SetBacklogMaxAge(15 * time.Minute)
+	t.Cleanup(func() { log.SetBacklogMaxAge(0) })
+	log.RecoverFallbackLogs()
+	assert.Equal(t, 0, delayed())
+
+	// Past it every pass warns
+	log.SetBacklogMaxAge(5 * time.Minute)
+	log.RecoverFallbackLogs()
+	assert.Equal(t, 1, delayed(), "The delayed backlog is reported")
+	assert.Equal(t, 5*time.Minute, log.EffectiveConfig().BacklogMaxAge)
+
+	// Once drained there is nothing left to warn about
+	assert.NoError(t, mr.Restart())
+	log.RecoverFallbackLogs()
+	assert.Equal(t, 0, delayed())
+	assert.Equal(t, applogs.FallbackBacklog{}, log.FallbackStatus(), "The backlog is empty")
+}
@@ -0,0 +1,89 @@
+package applogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+const backpressureKey = "applogs:TEST:unit:test-service:1"
+
+func TestBackpressureDivertsToFallbackAboveHighWater(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_BACKPRESSURE_HIGH_WATER", "5")
+	setSettingEnv(t, "APPLG_BACKPRESSURE_MODE", "fallback")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	for i := 0; i < 10; i++ {
+		mr.Lpush(backpressureKey, "backlog")
+	}
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	assert.Contains(t, log.RedisCommands(), "llen")
+
+	log.Info("Held back", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List(backpressureKey)
+	assert.Equal(t, 10, len(logs), "Nothing should be pushed to a list above the high-water mark")
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+}
+
+func TestBackpressureBlocksUntilConsumerCatchesUp(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	for i := 0; i < 10; i++ {
+		mr.Lpush(backpressureKey, "backlog")
+	}
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	log.SetBackpressure(5, applogs.BackpressureBlock, 2*time.Second)
+	defer log.SetBackpressure(0, "", 0)
+
+	log.Info("Waiting for the consumer", nil)
+	time.Sleep(200 * time.Millisecond)
+	logs, _ := mr.List(backpressureKey)
+	assert.Equal(t, 10, len(logs), "The push should be held back while the list is full")
+
+	// The consumer drains the backlog
+	for i := 0; i < 10; i++ {
+		mr.RPop(backpressureKey)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ = mr.List(backpressureKey)
+	assert.Equal(t, 1, len(logs), "The push should proceed once the list shrinks")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
+
+func TestBackpressureBlockGivesUpAfterWait(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	for i := 0; i < 10; i++ {
+		mr.Lpush(backpressureKey, "backlog")
+	}
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	log.SetBackpressure(5, applogs.BackpressureBlock, 100*time.Millisecond)
+	defer log.SetBackpressure(0, "", 0)
+
+	log.Info("Consumer never catches up", nil)
+	time.Sleep(400 * time.Millisecond)
+
+	logs, _ := mr.List(backpressureKey)
+	assert.Equal(t, 10, len(logs))
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+}
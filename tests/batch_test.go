@@ -0,0 +1,158 @@
+package applogs
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingRedisClient counts the pipelines the worker opens
+type countingRedisClient struct {
+	*redis.Client
+	pipelines atomic.Int64
+}
+
+func (c *countingRedisClient) Pipeline() redis.Pipeliner {
+	c.pipelines.Add(1)
+	return c.Client.Pipeline()
+}
+
+// benchmarkBurst logs bursts of entries and waits for each burst to reach Redis
+func benchmarkBurst(b *testing.B, minSize, maxSize int) {
+	setIdentity(b, "bench")
+	setSettingEnv(b, "APPLG_BATCH_MIN_SIZE", strconv.Itoa(minSize))
+	setSettingEnv(b, "APPLG_BATCH_MAX_SIZE", strconv.Itoa(maxSize))
+	setSettingEnv(b, "APPLG_BATCH_GROW_DEPTH", "10")
+	mr, client := setupMockRedis(b)
+	defer mr.Close()
+
+	// Keep the console core quiet while benchmarking
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout = stdout }()
+
+	const burst = 200
+	key := "applogs:TEST:unit:test-service:bench"
+
+	applogs := applogs.NewLogger(1024)
+	defer applogs.StopLogger()
+	applogs.SetRedisClient(client)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < burst; j++ {
+			applogs.Info("Burst log", map[string]interface{}{"seq": j})
+		}
+		for {
+			logs, _ := mr.List(key)
+			if len(logs) >= burst {
+				break
+			}
+			time.Sleep(50 * time.Microsecond)
+		}
+		mr.Del(key)
+	}
+}
+
+// BenchmarkBurstUnbatched pushes every entry on its own (batch size fixed at 1)
+func BenchmarkBurstUnbatched(b *testing.B) {
+	benchmarkBurst(b, 1, 1)
+}
+
+// BenchmarkBurstAdaptive lets the batch size grow while the burst is queued
+func BenchmarkBurstAdaptive(b *testing.B) {
+	benchmarkBurst(b, 1, 100)
+}
+
+// benchmarkTrickle logs from several goroutines at once and reports the
+// pipelines opened per entry
+func benchmarkTrickle(b *testing.B, flushInterval time.Duration) {
+	setIdentity(b, "bench")
+	setSettingEnv(b, "APPLG_BATCH_MIN_SIZE", "50")
+	setSettingEnv(b, "APPLG_BATCH_MAX_SIZE", "50")
+	setSettingEnv(b, "APPLG_BATCH_FLUSH_INTERVAL", strconv.Itoa(int(flushInterval/time.Millisecond)))
+	mr, client := setupMockRedis(b)
+	defer mr.Close()
+
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout = stdout }()
+
+	applogs := applogs.NewLogger(1024)
+	defer applogs.StopLogger()
+	counting := &countingRedisClient{Client: client}
+	logger.SetRedisClient(counting)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			applogs.Info("Trickle log", nil)
+			time.Sleep(100 * time.Microsecond)
+		}
+	})
+	_ = applogs.Flush(context.Background())
+	b.ReportMetric(float64(counting.pipelines.Load())/float64(b.N), "pipelines/op")
+}
+
+// BenchmarkTrickleNoLinger flushes every partial batch as soon as the queue is empty
+func BenchmarkTrickleNoLinger(b *testing.B) {
+	benchmarkTrickle(b, 0)
+}
+
+// BenchmarkTrickleLinger holds partial batches up to 5ms for more entries
+func BenchmarkTrickleLinger(b *testing.B) {
+	benchmarkTrickle(b, 5*time.Millisecond)
+}
+
+func TestBatchFlushIntervalFillsPartialBatches(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_BATCH_MIN_SIZE", "10")
+	setSettingEnv(t, "APPLG_BATCH_MAX_SIZE", "10")
+	setSettingEnv(t, "APPLG_BATCH_FLUSH_INTERVAL", "200")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	key := "applogs:TEST:unit:test-service:1"
+
+	log := applogs.NewLogger(100)
+	defer log.StopLogger()
+	counting := &countingRedisClient{Client: client}
+	logger.SetRedisClient(counting)
+	assert.Equal(t, 200*time.Millisecond, log.EffectiveConfig().Batch.FlushInterval)
+
+	// Entries trickling in are held until the batch is full
+	for i := 0; i < 10; i++ {
+		log.Info("Trickle log", map[string]interface{}{"seq": i})
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Eventually(t, func() bool {
+		logs, _ := mr.List(key)
+		return len(logs) == 10
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(1), counting.pipelines.Load(), "A full batch is pushed in one pipeline")
+
+	// A partial batch waits out the interval, then is pushed
+	start := time.Now()
+	log.Info("Lone log", nil)
+	assert.Eventually(t, func() bool {
+		logs, _ := mr.List(key)
+		return len(logs) == 11
+	}, time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond, "The partial batch lingers")
+	assert.Equal(t, int64(2), counting.pipelines.Load())
+
+	// Flush does not wait for the interval
+	log.Info("Flushed log", nil)
+	start = time.Now()
+	assert.NoError(t, log.Flush(context.Background()))
+	assert.Less(t, time.Since(start), 150*time.Millisecond, "Flush pushes the partial batch at once")
+	logs, _ := mr.List(key)
+	assert.Equal(t, 12, len(logs))
+}
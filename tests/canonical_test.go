@@ -0,0 +1,59 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderAB struct {
+	B string `json:"b"`
+	A int    `json:"a"`
+}
+
+type orderBA struct {
+	A int    `json:"a"`
+	B string `json:"b"`
+}
+
+func TestCanonicalJSONIsStable(t *testing.T) {
+	input := map[string]interface{}{
+		"zeta":  orderAB{B: "<x>", A: 2},
+		"alpha": json.RawMessage(`{"y": 1, "x": [3, {"d": 1, "c": 2}]}`),
+		"mid":   12345678901234567,
+	}
+
+	first, err := applogs.CanonicalJSON(input)
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		again, _ := applogs.CanonicalJSON(input)
+		assert.Equal(t, string(first), string(again), "Output must be byte-identical across runs")
+	}
+	assert.Equal(t, `{"alpha":{"x":[3,{"c":2,"d":1}],"y":1},"mid":12345678901234567,"zeta":{"a":2,"b":"\u003cx\u003e"}}`, string(first))
+}
+
+func TestCanonicalJSONIgnoresFieldOrder(t *testing.T) {
+	ab, _ := applogs.CanonicalJSON(orderAB{B: "b", A: 1})
+	ba, _ := applogs.CanonicalJSON(orderBA{A: 1, B: "b"})
+	assert.Equal(t, string(ab), string(ba), "Struct field order does not change the output")
+}
+
+func TestCanonicalPayloads(t *testing.T) {
+	setSettingEnv(t, "APPLG_SERIALIZE_ON_ENQUEUE", "true") // Metadata is then embedded as raw JSON
+	setSettingEnv(t, "APPLG_CANONICAL_JSON", "true")
+	mr, sugar := setupSugaredLogger(t)
+	log := sugar.Desugar()
+	defer log.SetCanonicalJSON(false)
+	assert.True(t, log.EffectiveConfig().CanonicalJSON)
+
+	log.Info("Order placed", map[string]interface{}{"order": orderAB{B: "A-17", A: 42}})
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if assert.Equal(t, 1, len(logs)) {
+		assert.Contains(t, logs[0], `"metadata":{"order":{"a":42,"b":"A-17"}}`, "Nested keys are sorted too")
+		canonical, _ := applogs.CanonicalJSON(json.RawMessage(logs[0]))
+		assert.Equal(t, string(canonical), logs[0])
+	}
+}
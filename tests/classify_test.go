@@ -0,0 +1,195 @@
+package applogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// proxyDownError is the error text of a Redis proxy whose backend is down
+const proxyDownError = "ERR twemproxy: server unavailable"
+
+func TestDefaultClassifierDropsUnknownProxyError(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	mr.SetError(proxyDownError)
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	log.Info("Dropped by default", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Empty(t, readFallbackLogs(fallbackPath), "Unknown errors are fatal for the built-in classifier")
+}
+
+func TestCustomClassifierFallsBackOnProxyError(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	mr.SetError(proxyDownError)
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	log.SetClassifyError(func(err error) applogs.ErrorClass {
+		if strings.Contains(err.Error(), "server unavailable") {
+			return applogs.ErrorUnavailable
+		}
+		return applogs.DefaultClassifyError(err)
+	})
+	defer log.SetClassifyError(nil)
+
+	log.Info("Saved by custom classifier", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	logs := readFallbackLogs(fallbackPath)
+	assert.Equal(t, 1, len(logs))
+	assert.Contains(t, logs[0], "Saved by custom classifier")
+}
+
+func TestCustomClassifierRetriesTransientError(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	mr.SetError(proxyDownError)
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	var calls atomic.Int32
+	log.SetClassifyError(func(err error) applogs.ErrorClass {
+		calls.Add(1)
+		mr.SetError("") // The proxy recovers before the retry
+		return applogs.ErrorTransient
+	})
+	defer log.SetClassifyError(nil)
+
+	log.Info("Delivered on retry", nil)
+	time.Sleep(300 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The retried push should reach Redis")
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
+
+// networkErrors are failures to reach Redis, as returned by a dialer
+var networkErrors = []struct {
+	name string
+	err  error
+}{
+	{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}},
+	{"timeout", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}},
+	{"dns failure", &net.DNSError{Err: "no such host", Name: "redis.invalid", IsNotFound: true}},
+	{"no route to host", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}},
+	{"generic network error", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("network is down")}},
+	{"wrapped", fmt.Errorf("push failed: %w", &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE})},
+	{"untyped i/o timeout", errors.New("read tcp 10.0.0.1:6379: i/o timeout")},
+}
+
+func TestDefaultClassifierNetworkErrors(t *testing.T) {
+	for _, tc := range networkErrors {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, applogs.ErrorUnavailable, applogs.DefaultClassifyError(tc.err))
+		})
+	}
+
+	assert.Equal(t, applogs.ErrorUnavailable, applogs.DefaultClassifyError(redis.ErrClosed), "A closed client is unavailable")
+	assert.Equal(t, applogs.ErrorFatal, applogs.DefaultClassifyError(redis.Nil), "A nil reply comes from a reachable Redis")
+	assert.Equal(t, applogs.ErrorFatal, applogs.DefaultClassifyError(errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")))
+}
+
+func TestNetworkErrorsRouteToFallback(t *testing.T) {
+	for _, tc := range networkErrors {
+		t.Run(tc.name, func(t *testing.T) {
+			setIdentity(t, "1")
+			fallbackPath := createMockFallbackDir()
+			defer os.RemoveAll(fallbackPath)
+
+			client := redis.NewClient(&redis.Options{
+				Addr:       "redis.invalid:6379",
+				MaxRetries: -1,
+				Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return nil, tc.err
+				},
+			})
+			defer client.Close()
+
+			log := applogs.NewTestLogger()
+			defer log.StopLogger()
+			log.SetRedisClient(client)
+			log.SetFallbackPath(fallbackPath)
+			log.Info("Kept on "+tc.name, nil)
+
+			logs := readFallbackLogs(fallbackPath)
+			if assert.Equal(t, 1, len(logs), "The entry is saved to fallback") {
+				assert.Contains(t, logs[0], "Kept on "+tc.name)
+			}
+		})
+	}
+}
+
+// A classifier calling every error fatal does not make sink errors drop
+// entries: they go to fallback, for the main sink and the backends alike
+func TestSinkErrorsFallBackWhateverTheClassifier(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetSink(failingSink{})
+	log.AddBackend("collector", failingSink{})
+	t.Cleanup(func() { log.AddBackend("collector", nil) })
+	log.SetClassifyError(func(err error) applogs.ErrorClass { return applogs.ErrorFatal })
+	defer log.SetClassifyError(nil)
+
+	log.Warn("Collector is down", nil)
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "Saved for the sink")
+	assert.Equal(t, 1, len(readFallbackLogs(filepath.Join(fallbackPath, "collector"))), "Saved for the backend")
+}
+
+// The classifier can be replaced while entries are pushed
+func TestSetClassifyErrorWhileLogging(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(100)
+	log.SetRedisClient(client)
+	defer log.SetClassifyError(nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			log.SetClassifyError(applogs.DefaultClassifyError)
+		}
+	}()
+	mr.SetError(proxyDownError)
+	for i := 0; i < 100; i++ {
+		log.Info("Pushed while the classifier changes", nil)
+	}
+	<-done
+	log.StopLogger()
+}
@@ -0,0 +1,52 @@
+package applogs
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanupKeepsAtMostMaxSyslogFiles(t *testing.T) {
+	setIdentity(t, "1")
+	t.Setenv("APPLG_CLEANUP_INTERVAL", "3600")
+	t.Setenv("APPLG_MAX_SYSLOG_FILES", "2")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	// Files well within SYSLOG_KEEP_TIME, which the age-based cleanup keeps
+	dir := filepath.Join("logs", "syslogs")
+	var old []string
+	for i, age := range []time.Duration{3 * time.Hour, 2 * time.Hour} {
+		path := filepath.Join(dir, "syslogs_01012024000"+strconv.Itoa(i)+".log")
+		assert.NoError(t, os.WriteFile(path, []byte("{}\n"), 0644))
+		modTime := time.Now().Add(-age)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+		old = append(old, path)
+	}
+
+	// The first pass runs at startup, without waiting for the interval
+	log := applogs.NewLogger(10)
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	cfg := log.EffectiveConfig()
+	assert.Equal(t, time.Hour, cfg.CleanupInterval)
+	assert.Equal(t, 2, cfg.MaxSyslogFiles)
+
+	assert.Eventually(t, func() bool {
+		files, _ := filepath.Glob(filepath.Join(dir, "syslogs_*"))
+		return len(files) == 2
+	}, time.Second, 10*time.Millisecond, "Only the two newest files are kept")
+	for _, path := range old {
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "Older files are deleted whatever their age")
+	}
+
+	log.Info("Still written", nil)
+	files, _ := filepath.Glob(filepath.Join(dir, "syslogs_*"))
+	assert.Equal(t, 2, len(files), "The file being written is kept")
+}
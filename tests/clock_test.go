@@ -0,0 +1,54 @@
+package applogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockStampsEntries(t *testing.T) {
+	setIdentity(t, "1")
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetClock(applogs.ClockFunc(func() time.Time { return now }))
+	t.Cleanup(func() { log.SetClock(nil) })
+
+	log.Info("Fixed time", nil)
+	log.LogRequest("GET", "/orders", "10.0.0.1", nil)
+	log.LogResponse(200, 12*time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if assert.Equal(t, 3, len(logs)) {
+		for _, entry := range listEntries(t, logs) {
+			assert.Equal(t, "2024-03-01T11:30:00Z", entry["timestamp"], "Timestamps come from the clock, in UTC")
+		}
+	}
+}
+
+func TestClockAgesFallbackBacklog(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetSink(failingSink{})
+	log.SetClock(applogs.ClockFunc(func() time.Time { return now }))
+	t.Cleanup(func() { log.SetClock(nil) })
+
+	log.Error("Pending", nil)
+	now = now.Add(90 * time.Second)
+
+	backlog := log.FallbackStatus()
+	assert.Equal(t, 1, backlog.Files)
+	assert.True(t, backlog.Oldest.Equal(now.Add(-90*time.Second)))
+	assert.Equal(t, 90*time.Second, backlog.OldestAge)
+}
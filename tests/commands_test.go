@@ -0,0 +1,105 @@
+package applogs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// commandRecorder is a go-redis hook recording the name of every issued command
+type commandRecorder struct {
+	mu       sync.Mutex
+	commands map[string]bool
+}
+
+func (r *commandRecorder) record(cmds ...redis.Cmder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cmd := range cmds {
+		r.commands[cmd.Name()] = true
+	}
+}
+
+func (r *commandRecorder) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	r.record(cmd)
+	return ctx, nil
+}
+
+func (r *commandRecorder) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (r *commandRecorder) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	r.record(cmds...)
+	return ctx, nil
+}
+
+func (r *commandRecorder) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func (r *commandRecorder) issued() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var names []string
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	return names
+}
+
+// runListModeWorkload exercises every path talking to Redis: pushes, deadlines,
+// heartbeats and recovery, and returns the commands issued
+func runListModeWorkload(t *testing.T, log *applogs.Applogs, client *redis.Client) []string {
+	recorder := &commandRecorder{commands: map[string]bool{}}
+	client.AddHook(recorder)
+	log.SetRedisClient(client)
+	log.SetHeartbeatInterval(50 * time.Millisecond)
+
+	fallbackPath := createMockFallbackDir()
+	log.SetFallbackPath(fallbackPath)
+	writeFallbackFile(t, fallbackPath, "fallback_1_100_20240101000000.log", "1", "Recovered log")
+
+	log.Info("Live log", map[string]interface{}{"_important": true})
+	log.Error("Deadlined log", map[string]interface{}{"_deadline": -time.Second})
+	logger.RecoverFallbackLogs()
+	time.Sleep(200 * time.Millisecond)
+	log.StopLogger()
+
+	return recorder.issued()
+}
+
+func TestListModeIssuesOnlyDeclaredCommands(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	issued := runListModeWorkload(t, log, client)
+
+	assert.ElementsMatch(t, []string{"ping", "lpush", "publish"}, log.RedisCommands())
+	assert.Subset(t, log.RedisCommands(), issued, "Only declared commands may be issued")
+	assert.Contains(t, issued, "lpush")
+	assert.Contains(t, issued, "publish")
+}
+
+func TestListModeCommandsFollowConfiguration(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_DEADLINE_PATH", "list")
+	setSettingEnv(t, "APPLG_DEDUPE_RECOVERY", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	issued := runListModeWorkload(t, log, client)
+
+	assert.ElementsMatch(t, []string{"ping", "lpush", "zadd", "zremrangebyscore", "expire", "zscore"}, log.RedisCommands())
+	assert.Subset(t, log.RedisCommands(), issued, "Only declared commands may be issued")
+	assert.NotContains(t, issued, "publish")
+}
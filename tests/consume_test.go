@@ -0,0 +1,93 @@
+package applogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeFansInProducerChannels(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(100)
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	// Two producers, each with its own channel drained by Consume
+	var consumers sync.WaitGroup
+	for p := 0; p < 2; p++ {
+		ch := make(chan applogs.LogEntry)
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			log.Consume(ch)
+		}()
+		go func(p int) {
+			defer close(ch)
+			for i := 0; i < 10; i++ {
+				ch <- applogs.LogEntry{Level: "WARN", Message: fmt.Sprintf("Producer %d entry %d", p, i), Fields: map[string]interface{}{"producer": p}}
+			}
+		}(p)
+	}
+	consumers.Wait() // Consume returns once its channel is closed
+	assert.NoError(t, log.Flush(context.Background()))
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 20, len(logs), "Every produced entry reaches Redis")
+	for _, raw := range logs {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(raw), &logData)
+		assert.Equal(t, "warn", logData["level"], "Levels are normalized")
+	}
+}
+
+func TestConsumeUnknownLevelLogsInfo(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	ch := make(chan applogs.LogEntry, 1)
+	ch <- applogs.LogEntry{Level: "notice", Message: "Unknown level"}
+	close(ch)
+	log.Consume(ch)
+
+	assert.Equal(t, "info", lastEntry(t, mr)["level"])
+}
+
+func TestConsumeStopsOnStopLogger(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+
+	ch := make(chan applogs.LogEntry) // Never closed
+	returned := make(chan struct{})
+	go func() {
+		log.Consume(ch)
+		close(returned)
+	}()
+	ch <- applogs.LogEntry{Level: "info", Message: "Before stop"}
+	ch <- applogs.LogEntry{Level: "info", Message: "Also before stop"} // Received once the first is queued
+	log.StopLogger()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Consume did not return after StopLogger")
+	}
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Contains(t, logs[len(logs)-1], "Before stop", "Entries consumed before the stop are drained")
+}
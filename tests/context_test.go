@@ -0,0 +1,131 @@
+package applogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContextReturnsStoredLogger(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+
+	ctx := applogs.IntoContext(context.Background(), log)
+	assert.Same(t, log, applogs.FromContext(ctx))
+
+	applogs.FromContext(ctx).Info("Deep in the call stack", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs))
+}
+
+func TestFromContextReturnsNopLoggerWhenAbsent(t *testing.T) {
+	log := applogs.FromContext(context.Background())
+	assert.NotNil(t, log)
+
+	// The Nop logger discards entries and never panics
+	assert.NotPanics(t, func() {
+		log.Info("Discarded", map[string]interface{}{"key": "value"})
+		log.Error("Discarded", nil)
+		log.LogRequest("GET", "/", "127.0.0.1", nil)
+		log.StopLogger()
+	})
+}
+
+func TestContextMethodsAnnotateContextState(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	log.SetAnnotateContext(true)
+	defer log.SetAnnotateContext(false)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	fields := map[string]interface{}{"step": "charge"}
+	log.WarnContext(cancelled, "Doomed request", fields)
+	entry := lastEntry(t, mr)
+	assert.Equal(t, map[string]interface{}{"step": "charge", applogs.CtxErrField: "context canceled"}, entry["metadata"])
+	assert.Len(t, fields, 1, "The caller's fields are not modified")
+
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelDeadline()
+	log.InfoContext(deadline, "Calling upstream", nil)
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	remaining := metadata[applogs.CtxRemainingField].(float64)
+	assert.InDelta(t, 60000, remaining, 1000)
+	assert.NotContains(t, metadata, applogs.CtxErrField)
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	log.ErrorContext(expired, "Too late", nil)
+	assert.Equal(t, map[string]interface{}{applogs.CtxErrField: "context deadline exceeded"}, lastEntry(t, mr)["metadata"])
+}
+
+func TestContextMethodsWithoutAnnotations(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	assert.False(t, log.EffectiveConfig().AnnotateContext, "Annotations are opt-in")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	log.InfoContext(cancelled, "Plain entry", map[string]interface{}{"step": "charge"})
+
+	assert.Equal(t, map[string]interface{}{"step": "charge"}, lastEntry(t, mr)["metadata"])
+}
+
+type traceIDKey struct{}
+
+type userIDKey struct{}
+
+func TestContextMethodsLiftRegisteredFields(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	applogs.RegisterContextField(traceIDKey{}, "trace_id")
+	applogs.RegisterContextField(userIDKey{}, "user_id")
+	defer applogs.RegisterContextField(traceIDKey{}, "")
+	defer applogs.RegisterContextField(userIDKey{}, "")
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "4bf92f3577b34da6")
+	ctx = context.WithValue(ctx, userIDKey{}, 1042)
+	fields := map[string]interface{}{"order": 7}
+	log.InfoContext(ctx, "Order placed", fields)
+	assert.Equal(t, map[string]interface{}{"order": float64(7), "trace_id": "4bf92f3577b34da6", "user_id": float64(1042)}, lastEntry(t, mr)["metadata"])
+	assert.Len(t, fields, 1, "The caller's fields are not modified")
+
+	// Explicit fields win, and absent values add nothing
+	log.ErrorContext(ctx, "Order failed", map[string]interface{}{"user_id": "override"})
+	assert.Equal(t, "override", lastEntry(t, mr)["metadata"].(map[string]interface{})["user_id"])
+	log.WarnContext(context.Background(), "No request", nil)
+	assert.Equal(t, map[string]interface{}{}, lastEntry(t, mr)["metadata"])
+
+	// Plain methods are unchanged
+	log.Info("Plain call", nil)
+	assert.Equal(t, map[string]interface{}{}, lastEntry(t, mr)["metadata"])
+
+	// Registering a key again renames its field
+	applogs.RegisterContextField(traceIDKey{}, "trace")
+	log.DebugContext(ctx, "Renamed", nil)
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "4bf92f3577b34da6", metadata["trace"])
+	assert.NotContains(t, metadata, "trace_id")
+}
@@ -0,0 +1,141 @@
+package applogs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingRedisClient holds every pipeline until released, simulating a backed-up Redis
+type blockingRedisClient struct {
+	*redis.Client
+	release chan struct{}
+}
+
+func (c *blockingRedisClient) Pipeline() redis.Pipeliner {
+	<-c.release
+	return c.Client.Pipeline()
+}
+
+func TestDeadlinedEntryDivertedFromBackedUpQueue(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	applogs := applogs.NewLogger(10)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+
+	sub := mr.NewSubscriber()
+	defer sub.Close()
+	sub.Subscribe("applogs:priority:TEST:unit:test-service:1")
+
+	// The first entry blocks the worker, so the rest wait in the queue
+	applogs.Info("Backlog log 1", nil)
+	applogs.Info("Backlog log 2", nil)
+	applogs.Error("Disk almost full", map[string]interface{}{
+		"_deadline": 50 * time.Millisecond,
+		"disk":      "/var",
+	})
+
+	select {
+	case msg := <-sub.Messages():
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(msg.Message), &logData)
+		assert.Equal(t, "Disk almost full", logData["message"])
+		assert.Equal(t, true, logData["deadline_expired"])
+		assert.Equal(t, map[string]interface{}{"disk": "/var"}, logData["metadata"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("Deadlined entry was not diverted to the priority channel")
+	}
+
+	// Once Redis catches up the backlog is delivered without the diverted entry
+	close(blocking.release)
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 2, len(logs), "Only the backlog entries should reach the main key")
+}
+
+// Without a subscriber the expired entry is pushed to the priority list
+// rather than published to nobody
+func TestDeadlinedEntryListedWithoutSubscriber(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	defer log.StopLogger()
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+
+	log.Info("Backlog log", nil)
+	log.Error("Disk almost full", map[string]interface{}{"_deadline": 50 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+	close(blocking.release)
+
+	assert.Eventually(t, func() bool {
+		logs, _ := mr.List("applogs:priority:TEST:unit:test-service:1")
+		return len(logs) == 1
+	}, 2*time.Second, 10*time.Millisecond, "The expired entry is listed")
+	logs, _ := mr.List("applogs:priority:TEST:unit:test-service:1")
+	assert.Contains(t, logs[0], `"deadline_expired":true`)
+}
+
+// keyRecordingSink holds its first push until released and records the key
+// of every push
+type keyRecordingSink struct {
+	release chan struct{}
+	held    atomic.Bool
+	mu      sync.Mutex
+	keys    []string
+}
+
+func (s *keyRecordingSink) Push(ctx context.Context, key string, entries [][]byte) error {
+	if s.held.CompareAndSwap(false, true) {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *keyRecordingSink) pushedTo(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, pushed := range s.keys {
+		if pushed == key {
+			return true
+		}
+	}
+	return false
+}
+
+// A logger with a sink delivers expired entries through it, not to Redis or fallback
+func TestDeadlinedEntryGoesThroughSink(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	logger.SetFallbackPath(fallbackPath)
+	sink := &keyRecordingSink{release: make(chan struct{})}
+
+	log := applogs.NewLoggerWithSink(10, sink)
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+
+	log.Info("Backlog log", nil)
+	log.Error("Disk almost full", map[string]interface{}{"_deadline": 50 * time.Millisecond})
+	assert.Eventually(t, func() bool {
+		return sink.pushedTo("applogs:priority:TEST:unit:test-service:1")
+	}, 2*time.Second, 10*time.Millisecond, "The expired entry reaches the sink")
+	close(sink.release)
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
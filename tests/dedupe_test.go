@@ -0,0 +1,137 @@
+package applogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// lostAckRedisClient executes every pipeline but reports it as failed, as
+// when Redis closes the connection after processing the commands, go-redis
+// then setting the connection error on every command
+type lostAckRedisClient struct {
+	*redis.Client
+}
+
+func (c *lostAckRedisClient) Pipeline() redis.Pipeliner {
+	return &lostAckPipeliner{Pipeliner: c.Client.Pipeline()}
+}
+
+type lostAckPipeliner struct {
+	redis.Pipeliner
+}
+
+func (p *lostAckPipeliner) Exec(ctx context.Context) ([]redis.Cmder, error) {
+	cmds, _ := p.Pipeliner.Exec(ctx)
+	err := errors.New("read tcp: connection refused")
+	for _, cmd := range cmds {
+		cmd.SetErr(err)
+	}
+	return cmds, err
+}
+
+// pushWithLostAck logs one entry through a lost acknowledgement, then recovers the fallback
+func pushWithLostAck(t *testing.T, dedupe bool) []string {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	t.Cleanup(mr.Close)
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetFallbackPath(fallbackPath)
+	log.SetDedupeRecovery(dedupe, time.Minute)
+	logger.SetRedisClient(&lostAckRedisClient{Client: client})
+
+	log.Info("Lost ack", nil)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "The entry should also be saved to fallback")
+
+	logger.SetRedisClient(client)
+	logger.RecoverFallbackLogs()
+	assert.Empty(t, readFallbackLogs(fallbackPath), "The fallback file should be drained")
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	return logs
+}
+
+func TestEntryIDsAreUnique(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.Info("First", nil)
+	log.Info("Second", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if !assert.Equal(t, 2, len(logs)) {
+		return
+	}
+	ids := make([]string, 2)
+	for i, entry := range logs {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(entry), &logData)
+		ids[i], _ = logData[applogs.EntryIDField].(string)
+		assert.Len(t, ids[i], 32)
+	}
+	assert.NotEqual(t, ids[0], ids[1])
+}
+
+func TestLostAckDeliversTwiceWithoutDedupe(t *testing.T) {
+	logs := pushWithLostAck(t, false)
+
+	assert.Equal(t, 2, len(logs), "Without dedupe the recovered entry is a duplicate")
+	assert.Equal(t, logs[0], logs[1], "Both copies carry the same entry ID")
+}
+
+func TestLostAckDedupedOnRecovery(t *testing.T) {
+	logs := pushWithLostAck(t, true)
+
+	assert.Equal(t, 1, len(logs), "Recovery should skip the entry already delivered")
+}
+
+// Seen IDs are timed with the clock of the logger, and a change of the
+// window keeps finding the IDs recorded before it
+func TestDedupeFollowsClockAndWindowChanges(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetClock(applogs.ClockFunc(func() time.Time { return now }))
+	t.Cleanup(func() { log.SetClock(nil) })
+	log.SetFallbackPath(fallbackPath)
+	log.SetDedupeRecovery(true, time.Minute)
+
+	lostAck := func(message string) {
+		logger.SetRedisClient(&lostAckRedisClient{Client: client})
+		log.Info(message, nil)
+		logger.SetRedisClient(client)
+	}
+
+	lostAck("Recorded before the change")
+	log.SetDedupeRecovery(true, 10*time.Minute)
+	now = now.Add(2 * time.Minute)
+	log.RecoverFallbackLogs()
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The ID is still seen within the new window")
+
+	lostAck("Recorded long ago")
+	now = now.Add(11 * time.Minute)
+	log.RecoverFallbackLogs()
+	logs, _ = mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 3, len(logs), "An ID older than the window is no longer seen")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
@@ -0,0 +1,50 @@
+package applogs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrappedErrorChainCaptured(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	root := &fs.PathError{Op: "open", Path: "/etc/app.yaml", Err: fs.ErrNotExist}
+	middle := fmt.Errorf("load config: %w", root)
+	top := fmt.Errorf("start server: %w", middle)
+	log.Error("Startup failed", map[string]interface{}{"error": top, "attempt": 1})
+
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, top.Error(), metadata["error"], "The error is logged as its message")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"message": top.Error(), "type": "*fmt.wrapError"},
+		map[string]interface{}{"message": middle.Error(), "type": "*fmt.wrapError"},
+		map[string]interface{}{"message": root.Error(), "type": "*fs.PathError"},
+		map[string]interface{}{"message": "file does not exist", "type": "*errors.errorString"},
+	}, metadata["error"+applogs.ChainSuffix])
+	assert.Equal(t, float64(1), metadata["attempt"])
+}
+
+func TestPlainErrorHasNoChain(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	fields := map[string]interface{}{"cause": errors.New("timeout")}
+	log.Warn("Retrying", fields)
+
+	assert.Equal(t, map[string]interface{}{"cause": "timeout"}, lastEntry(t, mr)["metadata"])
+	assert.IsType(t, errors.New(""), fields["cause"], "The caller's fields are not modified")
+}
@@ -0,0 +1,92 @@
+package applogs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// waitForEvent returns the first event of the given type, failing after a timeout
+func waitForEvent(t *testing.T, events <-chan applogs.Event, eventType applogs.EventType) applogs.Event {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("No %s event received", eventType)
+			return applogs.Event{}
+		}
+	}
+}
+
+func TestRedisStateChangedEvents(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	events, cancel := log.Subscribe(100)
+	defer cancel()
+
+	log.Info("While connected", nil)
+	connected := waitForEvent(t, events, applogs.EventRedisStateChanged)
+	assert.Equal(t, "connected", connected.State)
+
+	mr.Close()
+	log.Info("While down", nil)
+	down := waitForEvent(t, events, applogs.EventRedisStateChanged)
+	assert.Equal(t, "unavailable", down.State)
+	assert.Error(t, down.Err)
+	assert.False(t, down.Time.IsZero())
+
+	written := waitForEvent(t, events, applogs.EventFallbackWritten)
+	assert.Equal(t, 1, written.Count)
+	assert.Equal(t, filepath.Clean(fallbackPath), filepath.Dir(written.File))
+}
+
+func TestQueueSaturatedAndDroppedEvents(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(1)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	defer close(blocking.release)
+
+	events, cancel := log.Subscribe(100)
+	defer cancel()
+
+	// The worker blocks on the first entry, the second fills the queue
+	for i := 0; i < 4; i++ {
+		log.Info("Burst", nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	saturated := waitForEvent(t, events, applogs.EventQueueSaturated)
+	assert.Equal(t, 1, saturated.Count)
+	dropped := waitForEvent(t, events, applogs.EventLogDropped)
+	assert.Equal(t, "queue_full", dropped.Reason)
+}
+
+func TestCancelClosesEventChannel(t *testing.T) {
+	log := applogs.NewNopLogger()
+	events, cancel := log.Subscribe(1)
+	cancel()
+	cancel() // Cancelling twice is harmless
+
+	_, open := <-events
+	assert.False(t, open)
+}
@@ -0,0 +1,60 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFacilityLandsOnTenantKey(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+
+	log.Info("Default tenant", nil)
+	log.WithFacility("TENANT2").Named("billing").Info("Other tenant", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	own, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(own))
+
+	tenant, _ := mr.List("applogs:TENANT2:unit:test-service:1")
+	if !assert.Equal(t, 1, len(tenant)) {
+		return
+	}
+	var logData map[string]interface{}
+	json.Unmarshal([]byte(tenant[0]), &logData)
+	assert.Equal(t, "Other tenant", logData["message"])
+	assert.Equal(t, "TENANT2", logData["facility_id"])
+	assert.Equal(t, "billing", logData["component"])
+}
+
+func TestWithFacilitySurvivesFallbackRecovery(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	log.WithFacility("TENANT2").Warn("Tenant log during outage", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	mr2, client2 := setupMockRedis(t)
+	defer mr2.Close()
+	logger.SetRedisClient(client2)
+	logger.RecoverFallbackLogs()
+
+	assert.False(t, mr2.Exists("applogs:TEST:unit:test-service:1"))
+	tenant, _ := mr2.List("applogs:TENANT2:unit:test-service:1")
+	assert.Equal(t, 1, len(tenant), "The fallback record should remember the tenant")
+}
@@ -0,0 +1,119 @@
+package applogs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackFilesRotateBySize(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_MAX_FALLBACK_FILE_BYTES", "1024")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	assert.Equal(t, int64(1024), log.EffectiveConfig().FallbackFileBytes)
+
+	mr.Close()
+	for i := 0; i < 20; i++ {
+		log.Info("Outage log", map[string]interface{}{"padding": strings.Repeat("x", 100)})
+	}
+	assert.Equal(t, 20, len(readFallbackLogs(fallbackPath)), "No entry is lost across rotations")
+
+	files, _ := filepath.Glob(filepath.Join(fallbackPath, "fallback_1_*.log"))
+	assert.Greater(t, len(files), 2, "Files are rotated once full")
+	for _, file := range files {
+		info, _ := os.Stat(file)
+		assert.LessOrEqual(t, info.Size(), int64(1024), "No file grows past the limit")
+	}
+
+	// Recovery drains the file being written too; the next entry opens a new one
+	assert.NoError(t, mr.Restart())
+	logger.RecoverFallbackLogs()
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 20, len(logs))
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+
+	mr.Close()
+	log.Info("After recovery", nil)
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "The entry is not written to the recovered file")
+
+	// A file removed while open is replaced
+	_ = os.RemoveAll(fallbackPath)
+	log.Info("After removal", nil)
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+}
+
+// dirSize sums the size of the files in dir and its subdirectories
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func TestFallbackBudgetDropsOldestFiles(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_MAX_FALLBACK_FILE_BYTES", "1024")
+	setSettingEnv(t, "APPLG_MAX_FALLBACK_BYTES", "4096")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	assert.Equal(t, int64(4096), log.EffectiveConfig().MaxFallbackBytes)
+	lostBefore, droppedBefore := log.LogsLostTotal(), log.FallbackDroppedTotal()
+
+	mr.Close()
+	for i := 0; i < 100; i++ {
+		log.Info("Outage log", map[string]interface{}{"seq": i, "padding": strings.Repeat("x", 100)})
+	}
+
+	assert.LessOrEqual(t, dirSize(fallbackPath), int64(4096), "The directory stays within the budget")
+	logs := readFallbackLogs(fallbackPath)
+	assert.NotEmpty(t, logs)
+	assert.Equal(t, droppedBefore+uint64(100-len(logs)), log.FallbackDroppedTotal(), "Every entry is kept or counted")
+	assert.Contains(t, logs[len(logs)-1], `"seq":99`, "The newest entries are kept")
+	assert.Equal(t, lostBefore, log.LogsLostTotal(), "Dropped entries are not reported as lost")
+}
+
+func TestFallbackBudgetRefusesEntriesThatCannotFit(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_MAX_FALLBACK_BYTES", "64")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	before := log.FallbackDroppedTotal()
+
+	mr.Close()
+	for i := 0; i < 5; i++ {
+		log.Info("Larger than the budget", nil)
+	}
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+	assert.Equal(t, before+5, log.FallbackDroppedTotal())
+}
@@ -0,0 +1,112 @@
+package applogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// readGzipFallbackLogs decompresses every compressed fallback file in dir
+func readGzipFallbackLogs(t *testing.T, dir string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, "fallback_*.log.gz"))
+	var logs []string
+	for _, file := range files {
+		f, err := applogs.OpenSyslogFile(file)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file, err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			logs = append(logs, scanner.Text())
+		}
+		assert.NoError(t, scanner.Err(), "Appended members read back as one stream")
+		f.Close()
+	}
+	return logs
+}
+
+func TestCompressedFallbackIsRecovered(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_COMPRESS_FALLBACK", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	t.Cleanup(func() { log.SetCompressFallback(false) })
+	assert.True(t, log.EffectiveConfig().CompressFallback)
+
+	mr.Close()
+	for _, message := range []string{"First", "Second", "Third"} {
+		log.Info(message, map[string]interface{}{"order": message})
+	}
+	assert.Empty(t, readFallbackLogs(fallbackPath), "No plain file is written")
+	assert.Equal(t, 3, len(readGzipFallbackLogs(t, fallbackPath)))
+
+	// Plain files left by a previous run are recovered alongside
+	log.SetCompressFallback(false)
+	log.Info("Plain", nil)
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+
+	assert.NoError(t, mr.Restart())
+	logger.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 4, len(logs), "Both compressed and plain entries are resent")
+	var messages []string
+	for _, entry := range logs {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(entry), &logData)
+		messages = append(messages, logData["message"].(string))
+	}
+	assert.ElementsMatch(t, []string{"First", "Second", "Third", "Plain"}, messages, "Decompressed entries keep their content")
+	files, _ := filepath.Glob(filepath.Join(fallbackPath, "fallback_*"))
+	assert.Empty(t, files, "Recovered files are removed")
+}
+
+func TestTruncatedCompressedFallbackKeepsCompleteEntries(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_COMPRESS_FALLBACK", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	t.Cleanup(func() { log.SetCompressFallback(false) })
+
+	mr.Close()
+	log.Info("Complete", nil)
+	files, _ := filepath.Glob(filepath.Join(fallbackPath, "fallback_*.log.gz"))
+	if !assert.Equal(t, 1, len(files)) {
+		return
+	}
+
+	// A crash in the middle of the second append leaves half a member
+	member, _ := os.ReadFile(files[0])
+	f, _ := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write(member[:len(member)/2])
+	f.Close()
+
+	assert.NoError(t, mr.Restart())
+	logger.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The entry before the damaged member is resent")
+	assert.Equal(t, "Complete", lastEntry(t, mr)["message"])
+	_, err := os.Stat(files[0] + ".corrupt")
+	assert.NoError(t, err, "The damaged file is set aside")
+}
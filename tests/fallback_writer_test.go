@@ -0,0 +1,71 @@
+package applogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackWriterReceivesNDJSON(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+
+	var buf syncBuffer
+	log.SetFallbackWriter(&buf)
+	defer log.SetFallbackWriter(nil)
+	assert.Equal(t, logger.FallbackWriter, log.EffectiveConfig().FallbackMode)
+
+	log.Error("Redis is down", map[string]interface{}{"attempt": 1})
+	log.Warn("Still down", nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	for _, line := range lines {
+		var logData map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &logData), "Each line is a complete entry")
+		assert.Equal(t, "test-service", logData["service_name"])
+	}
+	assert.Empty(t, readFallbackLogs(fallbackPath), "Nothing is written to disk")
+}
+
+func TestFallbackStderrMode(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	setSettingEnv(t, "APPLG_FALLBACK_MODE", "stderr")
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	defer log.SetFallbackWriter(nil)
+	assert.Equal(t, logger.FallbackStderr, log.EffectiveConfig().FallbackMode)
+
+	log.Error("Printed for the collector", nil)
+	w.Close()
+
+	line, _ := bufio.NewReader(r).ReadString('\n')
+	assert.True(t, strings.HasPrefix(line, logger.FallbackLinePrefix))
+	var logData map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, logger.FallbackLinePrefix)), &logData))
+	assert.Equal(t, "Printed for the collector", logData["message"])
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
@@ -0,0 +1,108 @@
+package applogs
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFatalExitMode(t *testing.T) {
+	if os.Getenv("APPLOGS_FATAL_CHILD") == "1" {
+		// Runs in the child process started below
+		log := applogs.NewTestLogger()
+		log.Fatal("Critical failure", map[string]interface{}{"service": "database"})
+		t.Fatal("Fatal returned in exit mode")
+	}
+
+	setIdentity(t, "1")
+	mr, _ := setupMockRedis(t)
+	defer mr.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalExitMode$")
+	cmd.Env = append(os.Environ(), "APPLOGS_FATAL_CHILD=1", "APPLG_CORE_REDIS="+mr.Addr(), "APPLG_FATAL_MODE=exit")
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if assert.True(t, errors.As(err, &exitErr), "The child exits: %v", err) {
+		assert.Equal(t, 1, exitErr.ExitCode())
+	}
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The entry is pushed before the process exits")
+}
+
+func TestFatalPanicMode(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_FATAL_MODE", "panic")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	assert.Equal(t, applogs.FatalPanic, log.EffectiveConfig().FatalMode)
+
+	deferred := false
+	assert.PanicsWithValue(t, "Critical failure", func() {
+		defer func() { deferred = true }()
+		log.Fatal("Critical failure", map[string]interface{}{"service": "database"})
+	})
+	assert.True(t, deferred, "Deferred functions run")
+
+	// The entry is delivered before the panic, although the logger is asynchronous
+	entry := lastEntry(t, mr)
+	assert.Equal(t, "fatal", entry["level"])
+	assert.Equal(t, "Critical failure", entry["message"])
+
+	assert.Panics(t, func() { log.Sugar().Fatalf("Shard %d lost", 3) }, "Every fatal method panics")
+}
+
+func TestFatalNoneMode(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetFatalMode(applogs.FatalNone)
+	t.Cleanup(func() { log.SetFatalMode(applogs.FatalExit) })
+
+	log.Fatal("Critical failure", nil)
+	log.Info("Still running", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 2, len(logs), "Logging goes on after a fatal entry")
+	assert.Contains(t, logs[1], `"level":"fatal"`)
+}
+
+func TestFatalExitFlushesQueue(t *testing.T) {
+	if os.Getenv("APPLOGS_FATAL_CHILD") == "1" {
+		log := applogs.NewLogger(100)
+		log.Fatal("Last words", nil)
+		for i := 0; i < 20; i++ {
+			log.Info("Queued behind the fatal entry", nil)
+		}
+		select {} // The worker exits the process
+	}
+
+	setIdentity(t, "1")
+	mr, _ := setupMockRedis(t)
+	defer mr.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalExitFlushesQueue$")
+	cmd.Env = append(os.Environ(), "APPLOGS_FATAL_CHILD=1", "APPLG_CORE_REDIS="+mr.Addr(), "APPLG_FATAL_EXIT_CODE=3")
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if assert.True(t, errors.As(err, &exitErr), "The child exits: %v", err) {
+		assert.Equal(t, 3, exitErr.ExitCode(), "APPLG_FATAL_EXIT_CODE sets the status")
+	}
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if assert.Equal(t, 21, len(logs), "Entries queued behind the fatal entry are delivered before exiting") {
+		assert.Contains(t, logs[len(logs)-1], "Last words")
+	}
+}
@@ -0,0 +1,41 @@
+package applogs
+
+import (
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFieldsMergesBaseFields(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	base := map[string]interface{}{"request_id": "req-1", "tenant": "acme"}
+	requestLog := log.WithFields(base)
+	base["tenant"] = "changed" // The child keeps its own copy
+
+	requestLog.Info("Order placed", map[string]interface{}{"order_id": 42})
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "req-1", metadata["request_id"])
+	assert.Equal(t, "acme", metadata["tenant"])
+	assert.Equal(t, float64(42), metadata["order_id"])
+
+	// Per-call fields win, and nested children add to their parent's fields
+	requestLog.WithFields(map[string]interface{}{"step": "payment"}).Warn("Retrying", map[string]interface{}{"tenant": "override"})
+	metadata = lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "req-1", metadata["request_id"])
+	assert.Equal(t, "payment", metadata["step"])
+	assert.Equal(t, "override", metadata["tenant"])
+
+	// Calls without fields still carry the base fields, and the parent none
+	requestLog.Error("Failed", nil)
+	metadata = lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "req-1", metadata["request_id"])
+	log.Info("Unrelated", nil)
+	assert.Equal(t, map[string]interface{}{}, lastEntry(t, mr)["metadata"])
+}
@@ -0,0 +1,77 @@
+package applogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlushDeliversQueuedEntries(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(100)
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	for i := 0; i < 50; i++ {
+		log.Info("Before flush", map[string]interface{}{"i": i})
+	}
+	assert.NoError(t, log.Flush(context.Background()))
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 50, len(logs), "Every entry queued before Flush is in Redis when it returns")
+
+	// The logger keeps working after a flush
+	log.Error("After flush", nil)
+	assert.NoError(t, log.Flush(context.Background()))
+	logs, _ = mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 51, len(logs))
+}
+
+func TestFlushSavesToFallbackWhenRedisIsDown(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer createMockFallbackDir() // Later tests must not recover the entry
+
+	log := applogs.NewLogger(10)
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	log.Error("Payment declined", nil)
+	assert.NoError(t, log.Flush(context.Background()))
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "A flushed entry is durable in fallback")
+}
+
+func TestFlushRespectsContextDeadline(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	defer log.StopLogger()
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	log.Info("Stuck in the worker", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, log.Flush(ctx), context.DeadlineExceeded)
+
+	close(blocking.release)
+	assert.NoError(t, log.Flush(context.Background()), "The entry is delivered once Redis responds")
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs))
+}
+
+func TestFlushOnSynchronousLogger(t *testing.T) {
+	log := applogs.NewTestLogger()
+	assert.NoError(t, log.Flush(context.Background()), "Nothing is ever held back")
+}
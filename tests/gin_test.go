@@ -0,0 +1,82 @@
+package applogs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/bashx3r0/scala-applogs-client/pkg/ginapplogs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newGinEngine returns a test engine logging through log
+func newGinEngine(log *applogs.Applogs) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ginapplogs.Middleware(log))
+	return engine
+}
+
+func TestGinMiddlewareLogsRequestAndResponse(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	engine := newGinEngine(log)
+	engine.GET("/orders/:id", func(c *gin.Context) {
+		applogs.FromContext(c.Request.Context()).Info("Order loaded", nil)
+		c.String(http.StatusAccepted, "ok")
+	})
+	req := httptest.NewRequest("GET", "/orders/7", nil)
+	req.RemoteAddr = "10.0.0.5:51234"
+	req.Header.Set(applogs.RequestIDHeader, "req-7")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	assert.Equal(t, "req-7", rec.Header().Get(applogs.RequestIDHeader))
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	entries := listEntries(t, logs)
+	if assert.Equal(t, 3, len(entries)) {
+		request := entries[0]["metadata"].(map[string]interface{})
+		assert.Equal(t, "/orders/7", request["url"])
+		assert.Equal(t, "10.0.0.5", request["client_ip"])
+		assert.Equal(t, float64(202), entries[2]["metadata"].(map[string]interface{})["status_code"])
+		for _, entry := range entries {
+			metadata := entry["metadata"].(map[string]interface{})
+			assert.Equal(t, "/orders/:id", metadata[ginapplogs.RouteField])
+			assert.Equal(t, "req-7", metadata[applogs.RequestIDField])
+		}
+	}
+}
+
+func TestGinMiddlewareRecoversPanics(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	engine := newGinEngine(log)
+	engine.POST("/orders", func(c *gin.Context) {
+		panic("nil order")
+	})
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() { engine.ServeHTTP(rec, httptest.NewRequest("POST", "/orders", nil)) })
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	entries := listEntries(t, logs)
+	if assert.Equal(t, 3, len(entries)) {
+		assert.Equal(t, "Recovered from panic", entries[1]["message"])
+		assert.Equal(t, "nil order", entries[1]["metadata"].(map[string]interface{})["panic"])
+		assert.Equal(t, float64(500), entries[2]["metadata"].(map[string]interface{})["status_code"])
+	}
+}
@@ -0,0 +1,113 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// logRequestMetadata logs a request with the given header format and returns its Redis metadata
+func logRequestMetadata(t *testing.T, format string) map[string]interface{} {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetHeaderFormat(format)
+	defer log.SetHeaderFormat(applogs.HeaderFormatRaw)
+
+	log.LogRequest("GET", "/api/users", "192.168.1.1", map[string][]string{
+		"User-Agent": {"curl/7.68.0"},
+		"Accept":     {"text/html", "application/json"},
+	})
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if len(logs) != 1 {
+		t.Fatalf("Expected 1 log in Redis, got %d", len(logs))
+	}
+	var logData map[string]interface{}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	return logData["metadata"].(map[string]interface{})
+}
+
+func TestLogRequestRawHeaders(t *testing.T) {
+	metadata := logRequestMetadata(t, applogs.HeaderFormatRaw)
+	assert.Equal(t, map[string]interface{}{
+		"User-Agent": []interface{}{"curl/7.68.0"},
+		"Accept":     []interface{}{"text/html", "application/json"},
+	}, metadata["headers"])
+}
+
+func TestLogRequestObjectHeaders(t *testing.T) {
+	metadata := logRequestMetadata(t, applogs.HeaderFormatObject)
+	assert.Equal(t, map[string]interface{}{
+		"User-Agent": "curl/7.68.0",
+		"Accept":     "text/html, application/json",
+	}, metadata["headers"])
+}
+
+func TestLogRequestPrefixedHeaders(t *testing.T) {
+	metadata := logRequestMetadata(t, applogs.HeaderFormatPrefixed)
+	assert.NotContains(t, metadata, "headers")
+	assert.Equal(t, "curl/7.68.0", metadata["header_user_agent"])
+	assert.Equal(t, "text/html, application/json", metadata["header_accept"])
+}
+
+func TestLogRequestHeaderLists(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	t.Cleanup(func() {
+		log.SetHeaderAllowlist()
+		log.SetHeaderDenylist()
+	})
+	headers := map[string][]string{
+		"User-Agent":    {"curl/7.68.0"},
+		"Accept":        {"text/html"},
+		"X-Api-Key":     {"k-123"},
+		"Authorization": {"Bearer secret-token"},
+	}
+
+	log.SetHeaderDenylist("x-api-KEY")
+	log.LogRequest("GET", "/api/users", "192.168.1.1", headers)
+	assert.Equal(t, map[string]interface{}{
+		"User-Agent":    []interface{}{"curl/7.68.0"},
+		"Accept":        []interface{}{"text/html"},
+		"Authorization": []interface{}{"***"},
+	}, lastEntry(t, mr)["metadata"].(map[string]interface{})["headers"], "Names are matched case-insensitively")
+
+	log.SetHeaderAllowlist("user-agent", "AUTHORIZATION", "x-api-key")
+	log.LogRequest("GET", "/api/users", "192.168.1.1", headers)
+	assert.Equal(t, map[string]interface{}{
+		"User-Agent":    []interface{}{"curl/7.68.0"},
+		"Authorization": []interface{}{"***"},
+	}, lastEntry(t, mr)["metadata"].(map[string]interface{})["headers"], "Allowlisted secrets stay redacted, the denylist wins")
+	assert.Len(t, headers, 4, "The headers are not modified")
+
+	assert.Equal(t, []string{"authorization", "user-agent", "x-api-key"}, log.EffectiveConfig().HeaderAllowlist)
+}
+
+func TestHeaderListsFromEnvironment(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_HEADER_ALLOWLIST", "User-Agent, Accept")
+	setSettingEnv(t, "APPLG_HEADER_DENYLIST", "Accept")
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	t.Cleanup(func() {
+		log.SetHeaderAllowlist()
+		log.SetHeaderDenylist()
+	})
+	cfg := log.EffectiveConfig()
+	assert.Equal(t, []string{"accept", "user-agent"}, cfg.HeaderAllowlist)
+	assert.Equal(t, []string{"accept"}, cfg.HeaderDenylist)
+}
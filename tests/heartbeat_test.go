@@ -0,0 +1,108 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatEntriesAppear(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_LOG_SPEC", "*=error") // Heartbeats are not subject to levels
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetHeartbeatInterval(50 * time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, log.EffectiveConfig().HeartbeatInterval)
+
+	time.Sleep(280 * time.Millisecond)
+	log.StopLogger()
+	time.Sleep(100 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.GreaterOrEqual(t, len(logs), 3, "A heartbeat should be pushed every interval")
+
+	var logData struct {
+		Level    string                 `json:"level"`
+		Message  string                 `json:"message"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	assert.Equal(t, applogs.HeartbeatMessage, logData.Message)
+	assert.Equal(t, "info", logData.Level)
+	assert.Equal(t, "connected", logData.Metadata["redis_state"])
+	assert.Equal(t, float64(10), logData.Metadata["queue_capacity"])
+	assert.Contains(t, logData.Metadata, "queue_depth")
+
+	// No heartbeat is emitted once the logger is stopped
+	count := len(logs)
+	time.Sleep(150 * time.Millisecond)
+	logs, _ = mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, count, len(logs))
+}
+
+func TestHeartbeatDisabledByDefault(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	defer log.StopLogger()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, mr.Exists("applogs:TEST:unit:test-service:1"))
+	assert.Equal(t, time.Duration(0), log.EffectiveConfig().HeartbeatInterval)
+}
+
+func TestRuntimeStatsEntries(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetRuntimeStats(50*time.Millisecond, applogs.StatGoroutines, applogs.StatHeap, applogs.StatGC)
+	defer log.SetRuntimeStats(0)
+	assert.Equal(t, 50*time.Millisecond, log.EffectiveConfig().StatsInterval)
+
+	time.Sleep(130 * time.Millisecond)
+	log.StopLogger()
+	time.Sleep(100 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if !assert.GreaterOrEqual(t, len(logs), 2, "A stats entry should be pushed every interval") {
+		return
+	}
+	var logData struct {
+		Message  string                 `json:"message"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	assert.Equal(t, applogs.RuntimeStatsMessage, logData.Message)
+	assert.Greater(t, logData.Metadata["goroutines"], float64(0))
+	assert.Greater(t, logData.Metadata["heap_alloc_bytes"], float64(0))
+	for _, field := range []string{"heap_sys_bytes", "heap_objects", "gc_count", "gc_pause_last_ms", "gc_pause_total_ms"} {
+		assert.Contains(t, logData.Metadata, field)
+	}
+	assert.NotContains(t, logData.Metadata, "open_fds", "Groups not selected are left out")
+}
+
+func TestRuntimeStatsFromEnvironment(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_RUNTIME_STATS_INTERVAL", "60")
+	setSettingEnv(t, "APPLG_RUNTIME_STATS", "fds, goroutines, bogus")
+
+	log := applogs.NewLogger(10)
+	defer log.StopLogger()
+	defer log.SetRuntimeStats(0)
+
+	cfg := log.EffectiveConfig()
+	assert.Equal(t, time.Minute, cfg.StatsInterval)
+	assert.Equal(t, []string{applogs.StatFDs, applogs.StatGoroutines}, cfg.RuntimeStats, "Unknown groups are ignored")
+}
@@ -0,0 +1,92 @@
+package applogs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+func TestTestLoggerIsSynchronousAndHermetic(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	defer client.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	log.Info("Delivered before returning", nil)
+	log.Named("db").Warn("Also synchronous", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 2, len(logs), "Entries should reach Redis without waiting")
+
+	log.StopLogger()
+}
+
+func TestTestLoggerFallbackAndExplicitRecovery(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	defer client.Close()
+	down, downClient := setupMockRedis(t)
+	down.Close()
+	defer downClient.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(downClient)
+
+	log.Error("Saved to fallback", nil)
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+
+	log.SetRedisClient(client)
+	logger.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs))
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
+
+func TestNoGoroutineLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_RECONNECT_BASE", "10")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	defer client.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	// The worker, the recovery and cleanup loops and a reconnection all stop
+	log := applogs.NewLogger(10)
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	mr.Close()
+	for i := 0; i < 5; i++ {
+		log.Error("Redis is down", nil)
+	}
+	assert.Eventually(t, func() bool { return !log.IsHealthy() }, time.Second, 10*time.Millisecond, "Reconnection started")
+	log.StopLogger()
+
+	// Reinitializing replaces the loops rather than adding to them
+	assert.NoError(t, mr.Restart())
+	first := applogs.NewLogger(10)
+	first.SetRedisClient(client)
+	second := applogs.NewLogger(10)
+	second.SetRedisClient(client)
+	second.Info("Delivered", nil)
+	second.StopLogger()
+	first.StopLogger()
+}
@@ -0,0 +1,88 @@
+package applogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityChangeMidStreamKeepsQueuedEntriesOnTheirKey(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	defer log.SetIdentity(logger.CurrentIdentity())
+
+	// The first batch is still queued when the identity changes
+	log.Info("Before the change", nil)
+	log.Info("Before the change", nil)
+	time.Sleep(50 * time.Millisecond)
+	log.SetIdentity(applogs.Identity{ServiceName: "renamed-service", InstanceID: "2", FacilityID: "TEST", InstanceType: "unit"})
+	log.Info("After the change", nil)
+	close(blocking.release)
+	time.Sleep(300 * time.Millisecond)
+
+	before, _ := mr.List("applogs:TEST:unit:test-service:1")
+	after, _ := mr.List("applogs:TEST:unit:renamed-service:2")
+	assert.Equal(t, 2, len(before), "Entries logged before the change keep the old key")
+	assert.Equal(t, 1, len(after), "Entries logged after the change use the new key")
+	assert.Equal(t, "renamed-service", log.EffectiveConfig().ServiceName)
+}
+
+func TestIdentityChangeKeepsFallbackAndPriorityRouting(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	down, downClient := setupMockRedis(t)
+	down.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(downClient)
+	logger.SetDeadlinePath(logger.DeadlinePathList, "")
+	defer logger.SetDeadlinePath("", "")
+	defer log.SetIdentity(logger.CurrentIdentity())
+
+	log.Error("Saved under instance 1", nil)
+	expired := logger.NewLogData("error", "Expired under instance 1", nil)
+	log.SetIdentity(applogs.Identity{ServiceName: "test-service", InstanceID: "2", FacilityID: "TEST", InstanceType: "unit"})
+	log.Error("Saved under instance 2", nil)
+
+	log.SetRedisClient(client)
+	logger.LogToPriorityPath(expired)
+	logger.RecoverFallbackLogs()
+
+	first, _ := mr.List("applogs:TEST:unit:test-service:1")
+	second, _ := mr.List("applogs:TEST:unit:test-service:2")
+	priority, _ := mr.List("applogs:priority:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(first), "The file written under the old instance ID is still recovered")
+	assert.Equal(t, 1, len(second))
+	assert.Equal(t, 1, len(priority), "Expired entries go to the priority list of their own identity")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
+
+func TestPurgeAfterIdentityChangeOnlyPurgesTheCurrentService(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	defer log.SetIdentity(logger.CurrentIdentity())
+
+	log.Info("Old service", nil)
+	log.SetIdentity(applogs.Identity{ServiceName: "renamed-service", InstanceID: "1", FacilityID: "TEST", InstanceType: "unit"})
+	log.Info("New service", nil)
+
+	deleted, err := log.PurgeServiceKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Equal(t, []string{"applogs:TEST:unit:test-service:1"}, mr.Keys())
+}
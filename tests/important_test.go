@@ -0,0 +1,67 @@
+package applogs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportantEntriesSurviveTrim(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+
+	log.Info("Firehose 1", nil)
+	log.Info("Deploy finished", map[string]interface{}{"_important": true, "version": "1.4.2"})
+	log.Info("Firehose 2", nil)
+	log.Info("Firehose 3", nil)
+	time.Sleep(200 * time.Millisecond)
+
+	// Trim the main list down to its newest entry
+	client.LTrim(context.Background(), "applogs:TEST:unit:test-service:1", 0, 0)
+
+	normal, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(normal), "The trim should evict older normal entries")
+
+	important, _ := mr.List("applogs:TEST:unit:test-service:1:important")
+	if !assert.Equal(t, 1, len(important), "The important entry should live in its own list") {
+		return
+	}
+	var logData map[string]interface{}
+	json.Unmarshal([]byte(important[0]), &logData)
+	assert.Equal(t, "Deploy finished", logData["message"])
+	assert.Equal(t, true, logData["important"])
+	assert.Equal(t, map[string]interface{}{"version": "1.4.2"}, logData["metadata"], "The reserved field is not logged")
+}
+
+func TestImportantEntriesRecoveredToImportantList(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	log.Error("Incident opened", map[string]interface{}{"_important": true})
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+
+	mr2, client2 := setupMockRedis(t)
+	defer mr2.Close()
+	logger.SetRedisClient(client2)
+	logger.RecoverFallbackLogs()
+
+	assert.False(t, mr2.Exists("applogs:TEST:unit:test-service:1"))
+	important, _ := mr2.List("applogs:TEST:unit:test-service:1:important")
+	assert.Equal(t, 1, len(important))
+}
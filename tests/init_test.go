@@ -0,0 +1,101 @@
+package applogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitApplogsReportsInvalidRedisAddress(t *testing.T) {
+	setIdentity(t, "1")
+	t.Setenv("APPLG_CORE_REDIS", "redis-host:6379:0")
+
+	err := logger.InitApplogsForTesting()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid redis address redis-host:6379:0")
+	}
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	assert.Error(t, log.InitError(), "NewLogger keeps the error for the caller")
+	assert.NotPanics(t, func() { log.Info("Still logged to the console", nil) })
+}
+
+func TestMustInitPanicsOnFailure(t *testing.T) {
+	setIdentity(t, "1")
+	t.Setenv("APPLG_CORE_REDIS", "redis-host:6379:0")
+	defer func() { logger.Default().StopBackground() }()
+
+	var err error
+	func() {
+		defer func() { err, _ = recover().(error) }()
+		logger.MustInit()
+	}()
+	if assert.Error(t, err, "MustInit panics with the error of InitApplogs") {
+		assert.Contains(t, err.Error(), "invalid redis address redis-host:6379:0")
+	}
+}
+
+func TestMustNewLoggerPanicsOnFailure(t *testing.T) {
+	setIdentity(t, "1")
+	t.Setenv("APPLG_CORE_REDIS", "redis-host:6379:0")
+	defer func() { logger.Default().StopBackground() }()
+
+	var err error
+	func() {
+		defer func() { err, _ = recover().(error) }()
+		applogs.MustNewLogger(10)
+	}()
+	if assert.Error(t, err, "MustNewLogger panics with InitError") {
+		assert.Contains(t, err.Error(), "invalid redis address redis-host:6379:0")
+	}
+}
+
+func TestMustNewLoggerSucceeds(t *testing.T) {
+	setIdentity(t, "1")
+	mr, _ := setupMockRedis(t)
+	defer mr.Close()
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+
+	var log *applogs.Applogs
+	assert.NotPanics(t, func() { log = applogs.MustNewLogger(10) })
+	defer log.StopLogger()
+	assert.NoError(t, log.InitError())
+}
+
+func TestInitApplogsSucceeds(t *testing.T) {
+	setIdentity(t, "1")
+	mr, _ := setupMockRedis(t)
+	defer mr.Close()
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+
+	assert.NoError(t, logger.InitApplogsForTesting())
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	assert.NoError(t, log.InitError())
+}
+
+func TestNewLoggerWithConfigReportsUnwritableFallbackPath(t *testing.T) {
+	setIdentity(t, "1")
+	mr, _ := setupMockRedis(t)
+	defer mr.Close()
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+
+	// A directory cannot be created under a regular file
+	file := filepath.Join(t.TempDir(), "not-a-directory")
+	assert.NoError(t, os.WriteFile(file, nil, 0644))
+
+	log, err := applogs.NewLoggerWithConfig(applogs.Config{FallbackPath: filepath.Join(file, "fallback")})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "create log directory")
+	}
+	if !assert.NotNil(t, log, "The logger carries on without its fallback directory") {
+		return
+	}
+	defer log.StopLogger()
+	assert.Equal(t, err, log.InitError())
+}
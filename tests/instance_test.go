@@ -0,0 +1,60 @@
+package applogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestLoggersRunSideBySide(t *testing.T) {
+	setIdentity(t, "1")
+	billingRedis, billingClient := setupMockRedis(t)
+	defer billingRedis.Close()
+	shippingRedis, shippingClient := setupMockRedis(t)
+	defer shippingRedis.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+	billingPath, shippingPath := filepath.Join(fallbackPath, "billing"), filepath.Join(fallbackPath, "shipping")
+
+	billing := applogs.NewTestLogger()
+	defer billing.StopLogger()
+	billing.SetIdentity(applogs.Identity{ServiceName: "billing", InstanceID: "1", FacilityID: "TEST", InstanceType: "unit"})
+	billing.SetFallbackPath(billingPath)
+	billing.SetRedisClient(billingClient)
+
+	shipping := applogs.NewTestLogger()
+	defer shipping.StopLogger()
+	shipping.SetIdentity(applogs.Identity{ServiceName: "shipping", InstanceID: "1", FacilityID: "TEST", InstanceType: "unit"})
+	shipping.SetFallbackPath(shippingPath)
+	shipping.SetRedisClient(shippingClient)
+	assert.False(t, zap.L().Core().Enabled(zap.ErrorLevel), "Zap's global logger is left alone")
+
+	// Each logger pushes to its own server under its own identity
+	billing.Info("From billing", nil)
+	shipping.Info("From shipping", nil)
+	assert.True(t, billing.IsHealthy())
+	assert.Equal(t, []string{"applogs:TEST:unit:billing:1"}, billingRedis.Keys())
+	assert.Equal(t, []string{"applogs:TEST:unit:shipping:1"}, shippingRedis.Keys())
+
+	// An outage of one server only sends its logger to fallback
+	billingRedis.Close()
+	billing.Info("During the billing outage", nil)
+	shipping.Info("Not affected", nil)
+	assert.Equal(t, 1, len(readFallbackLogs(billingPath)))
+	assert.Empty(t, readFallbackLogs(shippingPath))
+	logs, _ := shippingRedis.List("applogs:TEST:unit:shipping:1")
+	assert.Equal(t, 2, len(logs))
+
+	// Recovery drains the files of the logger it runs for
+	assert.NoError(t, billingRedis.Restart())
+	shipping.RecoverFallbackLogs()
+	assert.Equal(t, 1, len(readFallbackLogs(billingPath)))
+	billing.RecoverFallbackLogs()
+	assert.Empty(t, readFallbackLogs(billingPath))
+	logs, _ = billingRedis.List("applogs:TEST:unit:billing:1")
+	assert.Equal(t, 2, len(logs))
+}
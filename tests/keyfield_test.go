@@ -0,0 +1,51 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyFieldMatchesDestinationKey(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_KEY_FIELD", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	defer log.SetKeyField(false)
+	assert.True(t, log.EffectiveConfig().KeyField)
+
+	log.Info("Default key", nil)
+	log.WithFacility("ACME").Error("Tenant key", map[string]interface{}{applogs.ImportantField: true})
+	log.LogSummary("info", "Summary key", nil)
+
+	for _, key := range []string{
+		"applogs:TEST:unit:test-service:1",
+		"applogs:ACME:unit:test-service:1:important",
+		"applogs:TEST:unit:test-service:1:summary",
+	} {
+		logs, _ := mr.List(key)
+		for _, raw := range logs {
+			var logData map[string]interface{}
+			json.Unmarshal([]byte(raw), &logData)
+			assert.Equal(t, key, logData[applogs.KeyField], "Entry %q", logData["message"])
+		}
+		assert.NotEmpty(t, logs, key)
+	}
+}
+
+func TestKeyFieldOffByDefault(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	log.Info("No key", nil)
+	assert.NotContains(t, lastEntry(t, mr), applogs.KeyField)
+}
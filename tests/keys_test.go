@@ -0,0 +1,53 @@
+package applogs
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelimiterInIdentityFieldIsEscaped(t *testing.T) {
+	setIdentity(t, "1")
+	t.Setenv("SERVICE_NAME", "vendor:api%v2")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	log.Info("Escaped key", nil)
+	log.Error("Escaped important key", map[string]interface{}{applogs.ImportantField: true})
+
+	assert.ElementsMatch(t, []string{
+		"applogs:TEST:unit:vendor%3Aapi%25v2:1",
+		"applogs:TEST:unit:vendor%3Aapi%25v2:1:important",
+	}, mr.Keys())
+
+	// Splitting on the delimiter yields exactly the original fields
+	segments := strings.Split("applogs:TEST:unit:vendor%3Aapi%25v2:1", ":")
+	assert.Len(t, segments, 5)
+	service, err := url.PathUnescape(segments[3])
+	assert.NoError(t, err)
+	assert.Equal(t, "vendor:api%v2", service)
+}
+
+func TestKeyDelimiterConfigurable(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_KEY_DELIMITER", "/")
+	t.Setenv("SERVICE_NAME", "vendor/api:v2")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	defer logger.SetKeyDelimiter(":")
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	assert.Equal(t, "/", log.EffectiveConfig().KeyDelimiter)
+	assert.Equal(t, "applogs/priority/TEST/unit/vendor%2Fapi:v2/1", log.EffectiveConfig().DeadlineTarget)
+
+	log.Info("Slash separated", nil)
+	assert.Equal(t, []string{"applogs/TEST/unit/vendor%2Fapi:v2/1"}, mr.Keys())
+}
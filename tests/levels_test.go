@@ -0,0 +1,135 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	spec, err := logger.ParseLevelSpec("auth=debug, db=WARN,*=info")
+	assert.NoError(t, err)
+	assert.Equal(t, logger.LevelSpec{"auth": "debug", "db": "warn", "*": "info"}, spec)
+
+	assert.Equal(t, "debug", spec.MinLevel("auth"))
+	assert.Equal(t, "debug", spec.MinLevel("auth.jwt"), "Nested components inherit their parent level")
+	assert.Equal(t, "warn", spec.MinLevel("db"))
+	assert.Equal(t, "info", spec.MinLevel("payments"), "Unlisted components use the wildcard level")
+
+	empty, err := logger.ParseLevelSpec("")
+	assert.NoError(t, err)
+	assert.Equal(t, "debug", empty.MinLevel("anything"), "An empty spec enables every level")
+}
+
+func TestParseLevelSpecRejectsInvalidEntries(t *testing.T) {
+	for _, spec := range []string{"auth", "=debug", "auth=verbose"} {
+		_, err := logger.ParseLevelSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestNamedLoggersHonorLevelSpec(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_LOG_SPEC", "auth=debug,db=warn,*=info")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	defer log.SetLogSpec("")
+
+	log.Named("auth").Debug("auth debug", nil)    // passes: auth=debug
+	log.Named("db").Info("db info", nil)          // dropped: db=warn
+	log.Named("db").Warn("db warn", nil)          // passes
+	log.Named("payments").Debug("pay debug", nil) // dropped: *=info
+	log.Named("payments").Info("pay info", nil)   // passes
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	var messages []string
+	var components []string
+	for i := len(logs) - 1; i >= 0; i-- {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(logs[i]), &logData)
+		messages = append(messages, logData["message"].(string))
+		components = append(components, logData["component"].(string))
+	}
+	assert.Equal(t, []string{"auth debug", "db warn", "pay info"}, messages)
+	assert.Equal(t, []string{"auth", "db", "payments"}, components)
+}
+
+func TestSetLevelDropsEntriesBelowIt(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "LOG_LEVEL", "INFO")
+	mr, client := setupMockRedis(t)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	t.Cleanup(func() { log.SetLevel("debug") })
+	log.SetRedisClient(client)
+	assert.Equal(t, "info", log.Level(), "LOG_LEVEL sets the initial level")
+	assert.Equal(t, "info", log.EffectiveConfig().Level)
+
+	log.Debug("Dropped at info", nil)
+	log.Info("Kept at info", nil)
+
+	assert.NoError(t, log.SetLevel("warn"))
+	log.Info("Dropped at warn", nil)
+	log.Named("auth").Sugar().Infow("Dropped for components too")
+	log.Warn("Kept at warn", nil)
+	log.Error("Kept above warn", nil)
+
+	assert.Error(t, log.SetLevel("verbose"))
+	assert.Equal(t, "warn", log.Level(), "An unknown level leaves the level unchanged")
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	var messages []string
+	for _, entry := range logs {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(entry), &logData)
+		messages = append(messages, logData["message"].(string))
+	}
+	assert.Equal(t, []string{"Kept above warn", "Kept at warn", "Kept at info"}, messages)
+}
+
+func TestCreatingLoggerKeepsLevel(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "LOG_LEVEL", "info")
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	t.Cleanup(func() { log.SetLevel("debug") })
+	assert.NoError(t, log.SetLevel("warn"))
+	other := applogs.NewTestLogger()
+	defer other.StopLogger()
+	assert.Equal(t, "warn", log.Level(), "LOG_LEVEL is read once, not by every logger")
+
+	configured, err := applogs.NewLoggerWithConfig(applogs.Config{Level: "error"})
+	assert.NoError(t, err)
+	defer configured.StopLogger()
+	assert.Equal(t, "error", log.Level(), "An explicit Config.Level applies to every logger")
+}
+
+func TestLogWithDynamicLevel(t *testing.T) {
+	setIdentity(t, "1")
+	log, sink := applogs.NewCaptureLogger()
+	defer log.StopLogger()
+	log.SetFatalMode(applogs.FatalNone)
+	t.Cleanup(func() { log.SetFatalMode(applogs.FatalExit) })
+
+	for _, level := range []string{"debug", "info", "WARN", " error ", "fatal"} {
+		assert.NoError(t, log.Log(level, "Dynamic", nil))
+	}
+	err := log.Log("verbose", "Unknown level", nil)
+	assert.EqualError(t, err, `unknown level "verbose"`)
+
+	var levels []string
+	for _, entry := range sink.Logs() {
+		levels = append(levels, entry.Level)
+	}
+	assert.Equal(t, []string{"debug", "info", "warn", "error", "fatal", "info"}, levels, "Unknown levels log as info")
+}
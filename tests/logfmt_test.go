@@ -0,0 +1,51 @@
+package applogs
+
+import (
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONToLogfmtQuoting(t *testing.T) {
+	cases := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"plain values", `{"level":"info","msg":"started"}`, `level=info msg=started`},
+		{"spaces", `{"msg":"user logged in"}`, `msg="user logged in"`},
+		{"quotes", `{"msg":"say \"hi\""}`, `msg="say \"hi\""`},
+		{"equals sign", `{"query":"a=b"}`, `query="a=b"`},
+		{"newline", `{"msg":"line1\nline2"}`, `msg="line1\nline2"`},
+		{"backslash", `{"path":"C:\\temp"}`, `path="C:\\temp"`},
+		{"empty string", `{"msg":""}`, `msg=""`},
+		{"unicode", `{"msg":"selamat_pagi_你好"}`, `msg=selamat_pagi_你好`},
+		{"numbers and booleans", `{"count":12345678901234567,"ok":true,"ratio":0.5}`, `count=12345678901234567 ok=true ratio=0.5`},
+		{"null", `{"user":null}`, `user=null`},
+		{"array", `{"tags":["a","b"]}`, `tags="[\"a\",\"b\"]"`},
+		{"metadata flattened", `{"msg":"hit","metadata":{"user_id":7,"page":"/home page"}}`, `msg=hit user_id=7 page="/home page"`},
+		{"null metadata keeps its key", `{"msg":"hit","metadata":null}`, `msg=hit metadata=null`},
+		{"nested object dotted", `{"metadata":{"db":{"host":"x","port":5432}}}`, `db.host=x db.port=5432`},
+		{"unsafe key", `{"bad key":"v"}`, `bad_key=v`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			line, err := zlog.JSONToLogfmt([]byte(tc.json))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want+"\n", string(line))
+		})
+	}
+}
+
+func TestJSONToLogfmtRejectsNonObjects(t *testing.T) {
+	_, err := zlog.JSONToLogfmt([]byte(`["not", "an", "object"]`))
+	assert.Error(t, err)
+}
+
+func TestNormalizeEncoder(t *testing.T) {
+	assert.Equal(t, zlog.EncoderLogfmt, zlog.NormalizeEncoder("logfmt"))
+	assert.Equal(t, zlog.EncoderConsole, zlog.NormalizeEncoder("console"))
+	assert.Equal(t, zlog.EncoderJSON, zlog.NormalizeEncoder("yaml"))
+}
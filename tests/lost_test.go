@@ -0,0 +1,77 @@
+package applogs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLostLogWhenRedisAndFallbackFail(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	// A fallback path below a regular file can never be opened
+	blocker := filepath.Join(createMockFallbackDir(), "not-a-dir")
+	os.WriteFile(blocker, []byte("x"), 0644)
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(filepath.Join(blocker, "fallback"))
+
+	output := &syncBuffer{}
+	logger.SetLostLogOutput(output)
+	defer logger.SetLostLogOutput(os.Stderr)
+
+	var mu sync.Mutex
+	var lost []map[string]interface{}
+	log.OnLostLog(func(entry map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		lost = append(lost, entry)
+	})
+	defer log.OnLostLog(nil)
+
+	before := log.LogsLostTotal()
+	log.Error("Nowhere to go", map[string]interface{}{"order_id": 42})
+	time.Sleep(300 * time.Millisecond)
+
+	assert.Equal(t, before+1, log.LogsLostTotal(), "logs_lost_total should be incremented")
+
+	mu.Lock()
+	assert.Equal(t, 1, len(lost), "OnLostLog should receive the entry")
+	if len(lost) == 1 {
+		assert.Equal(t, "Nowhere to go", lost[0]["message"])
+	}
+	mu.Unlock()
+
+	line := output.String()
+	assert.True(t, strings.HasPrefix(line, logger.LostLogPrefix), "Lost entry should be written in the recognizable format")
+	assert.Contains(t, line, `"message":"Nowhere to go"`)
+}
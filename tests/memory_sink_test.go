@@ -0,0 +1,49 @@
+package applogs
+
+import (
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySinkDump(t *testing.T) {
+	setIdentity(t, "1")
+	sink := &applogs.MemorySink{}
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetSink(sink)
+	t.Cleanup(func() { log.SetSink(nil) })
+
+	log.Info("User logged in", map[string]interface{}{"user_id": 123})
+	log.Named("db").Warn("Slow query", map[string]interface{}{"ms": 850, "query": map[string]interface{}{"table": "orders"}})
+	log.Error("Payment failed", map[string]interface{}{"error": "card declined"})
+	log.Debug("Done", nil)
+
+	assert.Equal(t, ""+
+		"INFO  User logged in  user_id=123\n"+
+		"WARN  Slow query      component=db ms=850 query.table=orders\n"+
+		"ERROR Payment failed  error=\"card declined\"\n"+
+		"DEBUG Done\n", sink.String())
+}
+
+func TestCaptureLoggerRecordsEntries(t *testing.T) {
+	setIdentity(t, "1")
+	log, sink := applogs.NewCaptureLogger()
+	defer log.StopLogger()
+	assert.NoError(t, log.InitError())
+
+	log.Info("Order placed", map[string]interface{}{"order_id": "o-1"})
+	log.Warn("Stock low", nil)
+
+	assert.Equal(t, []applogs.LogEntry{
+		{Level: "info", Message: "Order placed", Fields: map[string]interface{}{"order_id": "o-1"}},
+		{Level: "warn", Message: "Stock low", Fields: map[string]interface{}{}},
+	}, sink.Logs(), "Entries are captured as soon as they are logged")
+
+	sink.Reset()
+	assert.Empty(t, sink.Logs())
+	log.Error("After reset", nil)
+	assert.Equal(t, 1, len(sink.Logs()))
+}
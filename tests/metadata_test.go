@@ -0,0 +1,83 @@
+package applogs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// syslogLine returns the last syslog file line holding message
+func syslogLine(t *testing.T, message string) string {
+	files, _ := filepath.Glob(filepath.Join("logs", "syslogs", "syslogs_*.log"))
+	found := ""
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, `"msg":"`+message+`"`) {
+				found = line
+			}
+		}
+	}
+	if found == "" {
+		t.Fatalf("%q is not in the syslog file", message)
+	}
+	return found
+}
+
+func TestNilAndEmptyFieldsGiveEmptyMetadata(t *testing.T) {
+	for _, serialize := range []string{"false", "true"} {
+		t.Run("serialize="+serialize, func(t *testing.T) {
+			setIdentity(t, "1")
+			setSettingEnv(t, "APPLG_SERIALIZE_ON_ENQUEUE", serialize)
+			setSettingEnv(t, "APPLG_STACKTRACE_ON_FATAL", "false")
+			mr, client := setupMockRedis(t)
+			defer mr.Close()
+
+			log := applogs.NewTestLogger()
+			defer log.StopLogger()
+			log.SetRedisClient(client)
+			log.SetFatalMode(applogs.FatalNone)
+			t.Cleanup(func() { log.SetFatalMode(applogs.FatalExit) })
+
+			methods := map[string]func(string, map[string]interface{}){
+				"debug": log.Debug, "info": log.Info, "warn": log.Warn, "error": log.Error, "fatal": log.Fatal,
+			}
+			for level, logf := range methods {
+				for name, fields := range map[string]map[string]interface{}{"nil": nil, "empty": {}} {
+					message := "Without fields " + level + " " + name + " " + serialize
+					logf(message, fields)
+
+					entry := lastEntry(t, mr)
+					assert.Equal(t, message, entry["message"])
+					assert.Equal(t, map[string]interface{}{}, entry["metadata"], "%s with %s fields", level, name)
+					assert.Contains(t, syslogLine(t, message), `"metadata":{}`, "%s with %s fields", level, name)
+				}
+			}
+		})
+	}
+}
+
+func TestLogToRedisWithNilFieldsGivesEmptyMetadata(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	logger.LogToRedis("info", "Direct", nil)
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if assert.Equal(t, 1, len(logs)) {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(logs[0]), &entry))
+		assert.Equal(t, map[string]interface{}{}, entry["metadata"])
+		assert.NotContains(t, logs[0], `"metadata":null`)
+	}
+}
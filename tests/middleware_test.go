@@ -0,0 +1,103 @@
+package applogs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareLogsRequestAndResponse(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	var handlerID string
+	handler := log.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerID = applogs.RequestIDFromContext(r.Context())
+		applogs.FromContext(r.Context()).Info("Order created", nil)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	req := httptest.NewRequest("POST", "/orders?id=7", nil)
+	req.RemoteAddr = "10.0.0.5:51234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	id := rec.Header().Get(applogs.RequestIDHeader)
+	assert.Len(t, id, 32, "A random ID is generated")
+	assert.Equal(t, id, handlerID)
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	entries := listEntries(t, logs)
+	if assert.Equal(t, 3, len(entries)) {
+		request := entries[0]["metadata"].(map[string]interface{})
+		assert.Equal(t, "POST", request["method"])
+		assert.Equal(t, "/orders?id=7", request["url"])
+		assert.Equal(t, "10.0.0.5", request["client_ip"])
+		assert.Contains(t, request["headers"], "User-Agent")
+		assert.Equal(t, float64(201), entries[2]["metadata"].(map[string]interface{})["status_code"])
+		for _, entry := range entries {
+			assert.Equal(t, id, entry["metadata"].(map[string]interface{})[applogs.RequestIDField], "Entries share the request ID")
+		}
+	}
+	assert.Equal(t, "", applogs.RequestIDFromContext(req.Context()))
+}
+
+func TestMiddlewareReusesRequestID(t *testing.T) {
+	log := applogs.NewNopLogger()
+	handler := log.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(applogs.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "req-42", rec.Header().Get(applogs.RequestIDHeader))
+
+	req.Header.Set(applogs.RequestIDHeader, "two words")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Len(t, rec.Header().Get(applogs.RequestIDHeader), 32, "Unprintable IDs are replaced")
+}
+
+func TestMiddlewareRecoversPanics(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	handler := log.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil order")
+	}))
+	req := httptest.NewRequest("GET", "/orders/7", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() { handler.ServeHTTP(rec, req) })
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	entries := listEntries(t, logs)
+	if assert.Equal(t, 3, len(entries)) {
+		assert.Equal(t, "Recovered from panic", entries[1]["message"])
+		panicked := entries[1]["metadata"].(map[string]interface{})
+		assert.Equal(t, "nil order", panicked["panic"])
+		assert.Equal(t, "203.0.113.9", panicked["client_ip"], "The first forwarded address is the client")
+		assert.Equal(t, rec.Header().Get(applogs.RequestIDHeader), panicked[applogs.RequestIDField])
+		assert.Equal(t, float64(500), entries[2]["metadata"].(map[string]interface{})["status_code"])
+	}
+
+	aborting := log.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		aborting.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
@@ -0,0 +1,143 @@
+package applogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverflowDropCountsDroppedEntries(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(1)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	defer func() {
+		close(blocking.release)
+		log.StopLogger()
+	}()
+
+	before := log.LogsDroppedTotal()
+	// The worker blocks on the first entry, the second fills the queue
+	for i := 0; i < 4; i++ {
+		log.Info("Burst", nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, uint64(2), log.LogsDroppedTotal()-before)
+}
+
+func TestOverflowBlockWaitsForRoom(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(1)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	log.SetOverflowPolicy(applogs.OverflowBlock)
+	t.Cleanup(func() { log.SetOverflowPolicy(applogs.OverflowDrop) })
+
+	log.Info("Blocks the worker", nil)
+	time.Sleep(10 * time.Millisecond)
+	log.Info("Fills the queue", nil)
+
+	done := make(chan struct{})
+	go func() {
+		log.Info("Waits for room", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("The caller returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	before := log.LogsDroppedTotal()
+	close(blocking.release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("The caller stayed blocked once the queue drained")
+	}
+	log.StopLogger()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 3, len(logs), "No entry is dropped")
+	assert.Equal(t, before, log.LogsDroppedTotal())
+}
+
+func TestOverflowBlockWithTimeoutFallsBack(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(1)
+	log.SetFallbackPath(fallbackPath)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	defer func() {
+		close(blocking.release)
+		log.StopLogger()
+	}()
+	log.SetOverflowPolicy(applogs.BlockWithTimeout(30 * time.Millisecond))
+	t.Cleanup(func() { log.SetOverflowPolicy(applogs.OverflowDrop) })
+
+	log.Info("Blocks the worker", nil)
+	time.Sleep(10 * time.Millisecond)
+	log.Info("Fills the queue", nil)
+
+	before := log.LogsDroppedTotal()
+	start := time.Now()
+	log.Error("Waits in vain", nil)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	logs := readFallbackLogs(fallbackPath)
+	if assert.Equal(t, 1, len(logs)) {
+		assert.Contains(t, logs[0], "Waits in vain")
+	}
+	assert.Equal(t, before, log.LogsDroppedTotal(), "The entry is not dropped")
+}
+
+func TestOverflowKeepsErrorsAfterDebugFlood(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(5)
+	log.SetFallbackPath(fallbackPath)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+
+	before := log.LogsDroppedTotal()
+	for i := 0; i < 100; i++ {
+		log.Debug("Flood", nil)
+	}
+	log.Error("Payment failed", nil)
+	log.Warn("Retrying", nil)
+	assert.Greater(t, log.LogsDroppedTotal()-before, uint64(90), "Debug entries are dropped")
+
+	logs := readFallbackLogs(fallbackPath)
+	if assert.Equal(t, 2, len(logs), "Warnings and errors are saved to fallback") {
+		assert.Contains(t, logs[0], "Payment failed")
+		assert.Contains(t, logs[1], "Retrying")
+	}
+
+	close(blocking.release)
+	log.StopLogger()
+	logger.SetRedisClient(client)
+	log.RecoverFallbackLogs()
+	var messages []string
+	all, _ := mr.List("applogs:TEST:unit:test-service:1")
+	for _, entry := range listEntries(t, all) {
+		messages = append(messages, entry["message"].(string))
+	}
+	assert.Contains(t, messages, "Payment failed", "The error lands once recovered")
+	assert.Contains(t, messages, "Retrying")
+}
@@ -0,0 +1,103 @@
+package applogs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// orderError is a struct panic value
+type orderError struct {
+	OrderID int
+	Reason  string
+}
+
+// recoverAndLog panics with value and logs the recovered panic
+func recoverAndLog(log *applogs.Applogs, value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogPanic(r, "POST", "/api/orders", "192.168.1.1")
+		}
+	}()
+	panic(value)
+}
+
+func TestLogPanicNormalizesValue(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	wrapped := fmt.Errorf("charge card: %w", fs.ErrPermission)
+	tests := []struct {
+		name  string
+		value interface{}
+		want  map[string]interface{}
+	}{
+		{"string", "index out of range", map[string]interface{}{
+			"panic": "index out of range",
+		}},
+		{"error", errors.New("nil order"), map[string]interface{}{
+			"panic":      "nil order",
+			"panic_type": "*errors.errorString",
+		}},
+		{"wrapped error", wrapped, map[string]interface{}{
+			"panic":      wrapped.Error(),
+			"panic_type": "*fmt.wrapError",
+			"panic_chain": []interface{}{
+				map[string]interface{}{"message": wrapped.Error(), "type": "*fmt.wrapError"},
+				map[string]interface{}{"message": "permission denied", "type": "*errors.errorString"},
+			},
+		}},
+		{"struct", orderError{OrderID: 42, Reason: "out of stock"}, map[string]interface{}{
+			"panic":      "{OrderID:42 Reason:out of stock}",
+			"panic_type": "applogs.orderError",
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recoverAndLog(log, tc.value)
+
+			entry := lastEntry(t, mr)
+			assert.Equal(t, "Recovered from panic", entry["message"])
+			metadata := entry["metadata"].(map[string]interface{})
+			for key, want := range tc.want {
+				assert.Equal(t, want, metadata[key], key)
+			}
+			if _, ok := tc.want["panic_type"]; !ok {
+				assert.NotContains(t, metadata, "panic_type")
+			}
+			assert.Equal(t, "/api/orders", metadata["url"])
+		})
+	}
+}
+
+func TestCustomPanicFields(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetPanicFields(func(value interface{}) map[string]interface{} {
+		if order, ok := value.(orderError); ok {
+			return map[string]interface{}{"panic": order.Reason, "order_id": order.OrderID}
+		}
+		return applogs.DefaultPanicFields(value)
+	})
+	t.Cleanup(func() { log.SetPanicFields(nil) })
+
+	recoverAndLog(log, orderError{OrderID: 7, Reason: "fraud check failed"})
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "fraud check failed", metadata["panic"])
+	assert.Equal(t, float64(7), metadata["order_id"])
+	assert.Equal(t, "POST", metadata["method"])
+}
@@ -0,0 +1,54 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityQueueDrainsErrorsFirst(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_PRIORITY_QUEUE", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	applogs := applogs.NewLogger(1000)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+
+	// Flood the queue with info logs while the worker is held up
+	for i := 0; i < 500; i++ {
+		applogs.Info("Flood log", map[string]interface{}{"seq": i})
+	}
+	applogs.Error("Payment failed", nil)
+	close(blocking.release)
+
+	key := "applogs:TEST:unit:test-service:1"
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if logs, _ := mr.List(key); len(logs) == 501 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	logs, _ := mr.List(key)
+	assert.Equal(t, 501, len(logs))
+
+	// LPUSH keeps the newest entry at the head, so the push order runs from the tail
+	position := -1
+	for i := len(logs) - 1; i >= 0; i-- {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(logs[i]), &logData)
+		if logData["level"] == "error" {
+			position = len(logs) - 1 - i
+			break
+		}
+	}
+	assert.GreaterOrEqual(t, position, 0, "Error log should reach Redis")
+	assert.LessOrEqual(t, position, 2, "Error log should be pushed ahead of the queued info logs")
+}
@@ -0,0 +1,94 @@
+package applogs
+
+import (
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/bashx3r0/scala-applogs-client/pkg/promapplogs"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatherFamilies scrapes registry, keyed by metric name
+func gatherFamilies(t *testing.T, registry *prometheus.Registry) map[string]*dto.MetricFamily {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+func TestCollectorExportsStats(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(promapplogs.NewCollector(log), promapplogs.NewProcessCollector())
+
+	before := gatherFamilies(t, registry)
+	log.Info("Counted", nil)
+	after := gatherFamilies(t, registry)
+
+	pushed := func(families map[string]*dto.MetricFamily) float64 {
+		return families["applogs_logs_pushed_total"].GetMetric()[0].GetCounter().GetValue()
+	}
+	assert.Equal(t, float64(1), pushed(after)-pushed(before))
+	assert.Equal(t, float64(log.Stats().Pushed), pushed(after), "Values come from Stats")
+
+	latency := func(families map[string]*dto.MetricFamily) uint64 {
+		return families["applogs_push_duration_seconds"].GetMetric()[0].GetHistogram().GetSampleCount()
+	}
+	assert.Equal(t, uint64(1), latency(after)-latency(before))
+
+	reasons := map[string]bool{}
+	for _, metric := range after["applogs_logs_dropped_total"].GetMetric() {
+		reasons[metric.GetLabel()[0].GetValue()] = true
+	}
+	assert.Equal(t, map[string]bool{"shed": true, "sampled": true, "lost": true, "fallback_full": true}, reasons)
+
+	for _, name := range []string{"applogs_queue_dropped_total", "applogs_fallback_written_total", "applogs_recovered_total", "applogs_recovery_failed_total", "applogs_queue_length", "applogs_queue_capacity"} {
+		assert.Contains(t, after, name)
+	}
+}
+
+func TestCollectorsOfSeveralLoggersRegisterTogether(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	billing := applogs.NewTestLogger()
+	defer billing.StopLogger()
+	billing.SetIdentity(applogs.Identity{ServiceName: "billing", InstanceID: "1", FacilityID: "TEST", InstanceType: "unit"})
+	billing.SetRedisClient(client)
+	shipping := applogs.NewTestLogger()
+	defer shipping.StopLogger()
+	shipping.SetIdentity(applogs.Identity{ServiceName: "shipping", InstanceID: "1", FacilityID: "TEST", InstanceType: "unit"})
+	shipping.SetRedisClient(client)
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(promapplogs.NewCollector(billing)))
+	require.NoError(t, registry.Register(promapplogs.NewCollector(shipping)))
+	require.NoError(t, registry.Register(promapplogs.NewProcessCollector()))
+
+	billing.Info("Billed", nil)
+	billing.Info("Billed again", nil)
+	shipping.Info("Shipped", nil)
+
+	pushed := map[string]float64{}
+	for _, metric := range gatherFamilies(t, registry)["applogs_logs_pushed_total"].GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "service" {
+				pushed[label.GetValue()] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{"billing": 2, "shipping": 1}, pushed)
+}
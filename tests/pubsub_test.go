@@ -0,0 +1,149 @@
+package applogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/bashx3r0/scala-applogs-client/pkg/pubsub"
+	"github.com/bashx3r0/scala-applogs-client/pkg/pubsub/eventhubs"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPublisher records published messages, failing while fail is set
+type mockPublisher struct {
+	mu       sync.Mutex
+	fail     bool
+	batches  int
+	messages []string
+}
+
+func (p *mockPublisher) Publish(ctx context.Context, partitionKey string, messages [][]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail {
+		return errors.New("publisher unavailable")
+	}
+	p.batches++
+	for _, message := range messages {
+		p.messages = append(p.messages, string(message))
+	}
+	return nil
+}
+
+func (p *mockPublisher) published() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.messages...)
+}
+
+func TestPubSubSinkSplitsBatches(t *testing.T) {
+	publisher := &mockPublisher{}
+	sink := pubsub.NewSink(publisher, 2)
+
+	err := sink.Push(context.Background(), "applogs:key", [][]byte{[]byte("1"), []byte("2"), []byte("3")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, publisher.batches)
+	assert.Equal(t, []string{"1", "2", "3"}, publisher.published())
+}
+
+func TestPubSubSinkFallsBackAndRecovers(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	logger.SetFallbackPath(fallbackPath)
+
+	applogs := applogs.NewLogger(10)
+	publisher := &mockPublisher{fail: true}
+	applogs.SetSink(pubsub.NewSink(publisher, 10))
+	t.Cleanup(func() { logger.SetSink(nil) })
+
+	applogs.Info("Published later", map[string]interface{}{"key": "value"})
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "Failed publish should be saved to fallback")
+	assert.Empty(t, publisher.published())
+
+	publisher.mu.Lock()
+	publisher.fail = false
+	publisher.mu.Unlock()
+	logger.RecoverFallbackLogs()
+
+	published := publisher.published()
+	assert.Equal(t, 1, len(published), "Recovery should publish the fallback entry")
+	assert.Contains(t, published[0], "Published later")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
+
+func TestEventHubsPublisherSendsBatch(t *testing.T) {
+	var received []map[string]interface{}
+	var auth, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/logs-hub/messages", r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	publisher := eventhubs.NewPublisher(eventhubs.Config{
+		EventHub: "logs-hub",
+		KeyName:  "send",
+		Key:      "secret",
+		Endpoint: server.URL,
+	})
+
+	err := publisher.Publish(context.Background(), "applogs:TEST:unit:test-service:1",
+		[][]byte{[]byte(`{"message":"one"}`), []byte(`{"message":"two"}`)})
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(auth, "SharedAccessSignature sr="), "Request should carry a SAS token")
+	assert.Contains(t, auth, "&skn=send")
+	assert.Equal(t, "application/vnd.microsoft.servicebus.json", contentType)
+	assert.Equal(t, 2, len(received))
+	assert.Equal(t, `{"message":"one"}`, received[0]["Body"])
+	assert.Equal(t, map[string]interface{}{"PartitionKey": "applogs:TEST:unit:test-service:1"}, received[0]["BrokerProperties"])
+}
+
+func TestEventHubsPublisherReportsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	publisher := eventhubs.NewPublisher(eventhubs.Config{EventHub: "logs-hub", Endpoint: server.URL})
+	err := publisher.Publish(context.Background(), "key", [][]byte{[]byte("{}")})
+	assert.Error(t, err)
+}
+
+func TestEventHubsKeyNameIsEscaped(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	publisher := eventhubs.NewPublisher(eventhubs.Config{
+		EventHub: "logs-hub",
+		KeyName:  "send & listen=1",
+		Key:      "secret",
+		Endpoint: server.URL,
+	})
+	assert.NoError(t, publisher.Publish(context.Background(), "key", [][]byte{[]byte("{}")}))
+
+	token, err := url.ParseQuery(strings.TrimPrefix(auth, "SharedAccessSignature "))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "send & listen=1", token.Get("skn"))
+		assert.NotEmpty(t, token.Get("sig"))
+	}
+}
@@ -0,0 +1,60 @@
+package applogs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeServiceKeysRemovesEveryInstance(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	// Enough keys to span several SCAN batches
+	for i := 0; i < 250; i++ {
+		mr.Lpush(fmt.Sprintf("applogs:TEST:unit:test-service:%d", i), "entry")
+	}
+	mr.Lpush("applogs:TEST:unit:test-service:7:important", "entry")
+	mr.Lpush("applogs:priority:TEST:unit:test-service:7", "entry")
+	mr.SAdd("applogs:seen:TEST:unit:test-service:7:2984", "id")
+
+	// Keys of other services, facilities and applications are kept
+	mr.Lpush("applogs:TEST:unit:other-service:1", "entry")
+	mr.Lpush("applogs:ACME:unit:test-service:1", "entry")
+	mr.Lpush("applogs:TEST:unit:test-service-2:1", "entry")
+	mr.Set("unrelated", "value")
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	deleted, err := log.PurgeServiceKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, 253, deleted)
+	assert.ElementsMatch(t, []string{
+		"applogs:TEST:unit:other-service:1",
+		"applogs:ACME:unit:test-service:1",
+		"applogs:TEST:unit:test-service-2:1",
+		"unrelated",
+	}, mr.Keys())
+}
+
+func TestPurgeServiceKeysEscapesPatterns(t *testing.T) {
+	setIdentity(t, "1")
+	t.Setenv("SERVICE_NAME", "svc*")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	mr.Lpush("applogs:TEST:unit:svc*:1", "entry")
+	mr.Lpush("applogs:TEST:unit:svc-other:1", "entry")
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	deleted, err := log.PurgeServiceKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Equal(t, []string{"applogs:TEST:unit:svc-other:1"}, mr.Keys(), "The service name is matched literally")
+}
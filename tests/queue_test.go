@@ -0,0 +1,139 @@
+package applogs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceQueue is an unbounded Queue recording the levels it was given
+type sliceQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	entries []applogs.Entry
+	levels  []string
+	closed  bool
+}
+
+func newSliceQueue() *sliceQueue {
+	q := &sliceQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *sliceQueue) Enqueue(entry applogs.Entry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries = append(q.entries, entry)
+	q.levels = append(q.levels, entry.Level())
+	q.cond.Signal()
+	return true
+}
+
+func (q *sliceQueue) Dequeue() (applogs.Entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.entries) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.entries) == 0 {
+		return applogs.Entry{}, false
+	}
+	entry := q.entries[0]
+	q.entries = q.entries[1:]
+	return entry, true
+}
+
+func (q *sliceQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+func (q *sliceQueue) Cap() int { return 0 }
+
+func (q *sliceQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func TestCustomQueue(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	queue := newSliceQueue()
+	log := applogs.NewLoggerWithQueue(queue)
+	log.SetRedisClient(client)
+
+	log.Info("First", nil)
+	log.Error("Second", nil)
+	log.Warn("Third", nil)
+
+	key := "applogs:TEST:unit:test-service:1"
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if logs, _ := mr.List(key); len(logs) == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.StopLogger()
+
+	logs, _ := mr.List(key)
+	assert.Equal(t, 3, len(logs), "Entries go through the custom queue to Redis")
+	assert.Equal(t, []string{"info", "error", "warn"}, queue.levels)
+	assert.True(t, queue.closed, "StopLogger closes the queue")
+}
+
+func TestPriorityQueueOrdersByLevel(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	queue := applogs.NewPriorityQueue(2)
+	assert.Equal(t, 2, queue.Cap(), "Both kinds of entry share the configured capacity")
+
+	// Wrapping the priority queue holds the worker back until both entries are queued
+	gate := &gatedQueue{Queue: queue, open: make(chan struct{})}
+	log := applogs.NewLoggerWithQueue(gate)
+	log.SetRedisClient(client)
+	log.Info("Routine", nil)
+	log.Error("Urgent", nil)
+	assert.Equal(t, 2, queue.Len())
+	assert.False(t, queue.Enqueue(applogs.Entry{}), "The queue holds no more than its size")
+	close(gate.open)
+
+	key := "applogs:TEST:unit:test-service:1"
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if logs, _ := mr.List(key); len(logs) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.StopLogger()
+
+	// LPUSH keeps the newest entry at the head
+	logs, _ := mr.List(key)
+	if assert.Equal(t, 2, len(logs)) {
+		assert.Contains(t, logs[1], "Urgent", "The error entry is dequeued first")
+		assert.Contains(t, logs[0], "Routine")
+	}
+}
+
+// gatedQueue holds the worker back until open is closed
+type gatedQueue struct {
+	applogs.Queue
+	open chan struct{}
+}
+
+func (q *gatedQueue) Dequeue() (applogs.Entry, bool) {
+	<-q.open
+	return q.Queue.Dequeue()
+}
@@ -0,0 +1,54 @@
+package applogs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectAfterSustainedFailures(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_RECONNECT_BASE", "50")
+	setSettingEnv(t, "APPLG_RECONNECT_MAX", "200")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	events, cancel := log.Subscribe(100)
+	defer cancel()
+	assert.True(t, log.IsHealthy())
+
+	mr.Close()
+	for i := 0; i < 5; i++ {
+		log.Info("During the outage", nil)
+	}
+	assert.False(t, log.IsHealthy(), "Sustained failures mark Redis down")
+	assert.Equal(t, 5, len(readFallbackLogs(fallbackPath)), "Entries go to fallback while Redis is down")
+
+	assert.NoError(t, mr.Restart())
+	assert.Eventually(t, log.IsHealthy, 2*time.Second, 10*time.Millisecond, "The client reconnects with backoff")
+
+	reconnected := 0
+	for len(events) > 0 {
+		if event := <-events; event.Type == applogs.EventRedisReconnected {
+			reconnected++
+		}
+	}
+	assert.Equal(t, 1, reconnected, "The reconnection is reported once")
+
+	log.Info("After the outage", nil)
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "Entries reach Redis again")
+
+	cfg := log.EffectiveConfig()
+	assert.Equal(t, 50*time.Millisecond, cfg.ReconnectBase)
+	assert.Equal(t, 200*time.Millisecond, cfg.ReconnectMax)
+}
@@ -0,0 +1,95 @@
+package applogs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFallbackEntries writes a fallback file of count entries for instance 1
+func writeFallbackEntries(t *testing.T, dir string, count int) string {
+	var lines []string
+	for i := 0; i < count; i++ {
+		data, _ := json.Marshal(map[string]interface{}{
+			"level":         "info",
+			"message":       "Backlog entry",
+			"seq":           i,
+			"service_name":  "test-service",
+			"instance_id":   "1",
+			"facility_id":   "TEST",
+			"instance_type": "unit",
+		})
+		lines = append(lines, string(data))
+	}
+	path := filepath.Join(dir, "fallback_1_100_20240101000000.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write fallback file: %v", err)
+	}
+	return path
+}
+
+func TestRecoveryRespectsRateLimit(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	log.SetRecoveryRate(40) // Chunks of 4 entries, one every 100ms
+	defer log.SetRecoveryRate(0)
+	writeFallbackEntries(t, fallbackPath, 20)
+
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(done)
+		logger.RecoverFallbackLogs()
+	}()
+
+	time.Sleep(150 * time.Millisecond)
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Greater(t, len(logs), 0, "Recovery starts right away")
+	assert.LessOrEqual(t, len(logs), 8, "At most two chunks are pushed in the first 150ms")
+
+	<-done
+	elapsed := time.Since(start)
+	logs, _ = mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 20, len(logs))
+	assert.GreaterOrEqual(t, elapsed, 350*time.Millisecond, "20 entries at 40/s take about half a second")
+}
+
+func TestRateLimitedRecoveryKeepsUnpushedEntries(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	log.SetRecoveryRate(20) // Chunks of 2 entries, one every 100ms
+	defer log.SetRecoveryRate(0)
+	path := writeFallbackEntries(t, fallbackPath, 10)
+
+	// Redis goes away after the first chunks have been pushed
+	time.AfterFunc(150*time.Millisecond, mr.Close)
+	logger.RecoverFallbackLogs()
+
+	remaining := readFallbackLogs(fallbackPath)
+	assert.Less(t, len(remaining), 10, "Pushed entries are removed from the file")
+	assert.Greater(t, len(remaining), 0, "Entries not yet pushed are kept")
+	for _, line := range remaining {
+		assert.Contains(t, line, "Backlog entry")
+	}
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,368 @@
+package applogs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function to write a fallback file containing a single log line
+func writeFallbackFile(t *testing.T, dir, name, instanceID, message string) string {
+	logData := map[string]interface{}{
+		"level":         "info",
+		"message":       message,
+		"service_name":  "test-service",
+		"instance_id":   instanceID,
+		"facility_id":   "TEST",
+		"instance_type": "unit",
+	}
+	data, _ := json.Marshal(logData)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatalf("Failed to write fallback file: %v", err)
+	}
+	return path
+}
+
+func TestRecoveryOnlyProcessesOwnInstanceFiles(t *testing.T) {
+	setIdentity(t, "instance-a")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	logger.SetFallbackPath(fallbackPath)
+
+	applogs := applogs.NewLogger(10)
+	applogs.SetRedisClient(client)
+
+	ownFile := writeFallbackFile(t, fallbackPath, "fallback_instance-a_100_20240101000000.log", "instance-a", "from a")
+	otherFile := writeFallbackFile(t, fallbackPath, "fallback_instance-b_200_20240101000000.log", "instance-b", "from b")
+
+	logger.RecoverFallbackLogs()
+
+	ownLogs, _ := mr.List("applogs:TEST:unit:test-service:instance-a")
+	assert.Equal(t, 1, len(ownLogs), "Own fallback file should be recovered")
+	assert.False(t, mr.Exists("applogs:TEST:unit:test-service:instance-b"), "Other instance logs should not be recovered")
+
+	_, err := os.Stat(ownFile)
+	assert.True(t, os.IsNotExist(err), "Recovered fallback file should be removed")
+	_, err = os.Stat(otherFile)
+	assert.NoError(t, err, "Other instance fallback file should be left untouched")
+}
+
+func TestFallbackFileNameIncludesInstanceIdentity(t *testing.T) {
+	setIdentity(t, "node_1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	logger.SetFallbackPath(fallbackPath)
+
+	applogs := applogs.NewLogger(10)
+	applogs.SetRedisClient(client)
+
+	logger.LogToRedis("info", "Fallback naming test", nil)
+
+	files, _ := filepath.Glob(filepath.Join(fallbackPath, "fallback_node%5F1_*.log"))
+	assert.Equal(t, 1, len(files), "Fallback file should be named after the escaped instance ID")
+}
+
+func TestRecoverySkipsFilesOfInstanceIDsDifferingInAnEscapedCharacter(t *testing.T) {
+	setIdentity(t, "a-b")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	down, downClient := setupMockRedis(t)
+	down.Close()
+	fallbackPath := createMockFallbackDir()
+
+	// a_b saves an entry to fallback, then stops, releasing its file
+	other := applogs.NewTestLogger()
+	other.SetIdentity(applogs.Identity{ServiceName: "test-service", InstanceID: "a_b", FacilityID: "TEST", InstanceType: "unit"})
+	other.SetFallbackPath(fallbackPath)
+	other.SetRedisClient(downClient)
+	other.Warn("From a_b", nil)
+	other.StopLogger()
+	otherFiles, _ := filepath.Glob(filepath.Join(fallbackPath, "fallback_*.log"))
+	require.Len(t, otherFiles, 1)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	log.RecoverFallbackLogs()
+
+	assert.False(t, mr.Exists("applogs:TEST:unit:test-service:a_b"), "a-b does not recover the entries of a_b")
+	_, err := os.Stat(otherFiles[0])
+	assert.NoError(t, err, "The file of a_b is left untouched")
+}
+
+func TestRecoveryDrainsLegacyFallbackFiles(t *testing.T) {
+	setIdentity(t, "instance-a")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	logger.SetFallbackPath(fallbackPath)
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+
+	legacyOwn := writeFallbackFile(t, fallbackPath, "fallback_20240101000000.log", "instance-a", "legacy from a")
+	legacyOther := writeFallbackFile(t, fallbackPath, "fallback_20240101000001.log", "instance-b", "legacy from b")
+	current := writeFallbackFile(t, fallbackPath, "fallback_instance-a_100_20240102000000.log", "instance-a", "current from a")
+
+	logger.RecoverFallbackLogs()
+
+	ownLogs, _ := mr.List("applogs:TEST:unit:test-service:instance-a")
+	assert.Equal(t, 2, len(ownLogs), "Both the legacy and the current file should be recovered")
+	otherLogs, _ := mr.List("applogs:TEST:unit:test-service:instance-b")
+	assert.Equal(t, 1, len(otherLogs), "Legacy entries keep the identity they were logged with")
+
+	for _, path := range []string{legacyOwn, legacyOther, current} {
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "Recovered file %s should be removed", path)
+	}
+	assert.Empty(t, readFallbackLogs(fallbackPath), "No claimed legacy file should be left behind")
+}
+
+func TestRecoveredEntriesCarryMarker(t *testing.T) {
+	setIdentity(t, "instance-a")
+	setSettingEnv(t, "APPLG_MARK_RECOVERED", "true")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	assert.True(t, log.EffectiveConfig().MarkRecovered)
+
+	log.Info("Logged while Redis was down", nil)
+
+	mr2, client2 := setupMockRedis(t)
+	defer mr2.Close()
+	log.SetRedisClient(client2)
+	log.RecoverFallbackLogs()
+
+	logs, _ := mr2.List("applogs:TEST:unit:test-service:instance-a")
+	if !assert.Equal(t, 1, len(logs)) {
+		return
+	}
+	var logData map[string]interface{}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	assert.Equal(t, true, logData["recovered"])
+
+	original, err := time.Parse(time.RFC3339Nano, logData["timestamp"].(string))
+	assert.NoError(t, err)
+	recoveredAt, err := time.Parse(time.RFC3339Nano, logData["recovered_at"].(string))
+	assert.NoError(t, err)
+	assert.True(t, recoveredAt.After(original), "The original timestamp is kept alongside the recovery time")
+}
+
+func TestLiveEntriesCarryNoRecoveredMarker(t *testing.T) {
+	setIdentity(t, "instance-a")
+	setSettingEnv(t, "APPLG_MARK_RECOVERED", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	log.Info("Live log", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:instance-a")
+	assert.Equal(t, 1, len(logs))
+	assert.NotContains(t, logs[0], `"recovered"`)
+}
+
+func TestRecoveryPreservesLargeIntegers(t *testing.T) {
+	setIdentity(t, "instance-a")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	// 2^53 + 1 cannot be represented exactly as a float64
+	log.Info("Large integer", map[string]interface{}{"nanos": int64(9007199254740993)})
+
+	mr2, client2 := setupMockRedis(t)
+	defer mr2.Close()
+	log.SetRedisClient(client2)
+	log.RecoverFallbackLogs()
+
+	logs, _ := mr2.List("applogs:TEST:unit:test-service:instance-a")
+	if assert.Equal(t, 1, len(logs)) {
+		assert.Contains(t, logs[0], `"nanos":9007199254740993`)
+	}
+}
+
+// Recovery is driven explicitly: entries go to fallback and back to Redis
+// without any goroutine or sleep involved
+func TestSynchronousRecoveryPass(t *testing.T) {
+	setIdentity(t, "instance-a")
+	down, downClient := setupMockRedis(t)
+	down.Close()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(downClient)
+
+	log.Error("Payment timed out", map[string]interface{}{"order": 42})
+	log.Warn("Retrying payment", nil)
+	assert.Equal(t, 2, len(readFallbackLogs(fallbackPath)), "Both entries are saved while Redis is down")
+
+	// A pass with Redis still down keeps the entries for the next one
+	log.RecoverFallbackLogs()
+	assert.Equal(t, 2, len(readFallbackLogs(fallbackPath)))
+
+	log.SetRedisClient(client)
+	log.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:instance-a")
+	assert.Equal(t, 2, len(logs), "The pass has completed when RecoverFallbackLogs returns")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
+
+// Hand-edited or partially written fallback files must not crash recovery:
+// invalid entries are set aside and the valid ones still recovered
+func TestRecoverySkipsEntriesWithoutIdentity(t *testing.T) {
+	setIdentity(t, "instance-a")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	log.SetMarkRecovered(true)
+	t.Cleanup(func() { log.SetMarkRecovered(false) })
+
+	path := writeFallbackFile(t, fallbackPath, "fallback_instance-a_100_20240101000000.log", "instance-a", "valid")
+	lines := `{"level":"info","message":"no instance","service_name":"test-service","facility_id":"TEST","instance_type":"unit"}` + "\n" +
+		`{"level":"info","message":"numeric instance","service_name":"test-service","instance_id":7,"facility_id":"TEST","instance_type":"unit"}` + "\n" +
+		"null\n"
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(lines)
+	file.Close()
+
+	assert.NotPanics(t, log.RecoverFallbackLogs)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:instance-a")
+	if assert.Equal(t, 1, len(logs), "The valid entry is recovered") {
+		assert.Contains(t, logs[0], `"message":"valid"`)
+	}
+	assert.Equal(t, []string{"applogs:TEST:unit:test-service:instance-a"}, mr.Keys(), "No entry lands on a malformed key")
+	_, err := os.Stat(path + ".corrupt")
+	assert.NoError(t, err, "The file is set aside for inspection")
+}
+
+// Entries logged without a service or instance name carry empty strings,
+// which are recovered like any other value
+func TestRecoveryAcceptsEmptyIdentityStrings(t *testing.T) {
+	setIdentity(t, "instance-a")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+
+	path := filepath.Join(fallbackPath, "fallback_instance-a_100_20240101000000.log")
+	line := `{"level":"info","message":"unnamed","service_name":"","instance_id":"","facility_id":"TEST","instance_type":"unit"}` + "\n"
+	assert.NoError(t, os.WriteFile(path, []byte(line), 0644))
+
+	log.RecoverFallbackLogs()
+
+	if keys := mr.Keys(); assert.Equal(t, 1, len(keys)) {
+		logs, _ := mr.List(keys[0])
+		assert.Equal(t, 1, len(logs), "The entry is recovered")
+	}
+	_, err := os.Stat(path + ".corrupt")
+	assert.True(t, os.IsNotExist(err), "The file is not set aside")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
+
+// A corrupt file whose valid entries could not be pushed is kept for the
+// next pass, and only set aside once they are delivered
+func TestCorruptFallbackKeptUntilRecovered(t *testing.T) {
+	setIdentity(t, "instance-a")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+
+	path := writeFallbackFile(t, fallbackPath, "fallback_instance-a_100_20240101000000.log", "instance-a", "valid")
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString("not json\n")
+	file.Close()
+
+	log.RecoverFallbackLogs()
+	_, err := os.Stat(path + ".corrupt")
+	assert.True(t, os.IsNotExist(err), "The undelivered entry is not set aside")
+	assert.FileExists(t, path, "The file is kept for the next pass")
+
+	mr2, client2 := setupMockRedis(t)
+	defer mr2.Close()
+	log.SetRedisClient(client2)
+	log.RecoverFallbackLogs()
+
+	logs, _ := mr2.List("applogs:TEST:unit:test-service:instance-a")
+	assert.Equal(t, 1, len(logs), "The valid entry is recovered")
+	corrupt, err := os.ReadFile(path + ".corrupt")
+	if assert.NoError(t, err, "The file is set aside once delivered") {
+		assert.Contains(t, string(corrupt), "not json")
+	}
+}
+
+// Loggers sharing an instance ID and a fallback directory, here the empty
+// one, leave alone the file another appends to
+func TestRecoverySkipsFilesLockedByAnotherWriter(t *testing.T) {
+	setIdentity(t, "")
+	downRedis, downClient := setupMockRedis(t)
+	downRedis.Close()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	writer := applogs.NewTestLogger()
+	defer writer.StopLogger()
+	writer.SetFallbackPath(fallbackPath)
+	writer.SetRedisClient(downClient)
+	writer.Info("Still being written", nil)
+
+	recoverer := applogs.NewTestLogger()
+	defer recoverer.StopLogger()
+	recoverer.SetFallbackPath(fallbackPath)
+	recoverer.SetRedisClient(client)
+	recoverer.RecoverFallbackLogs()
+	assert.False(t, mr.Exists("applogs:TEST:unit:test-service:"), "The open file is not recovered")
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+
+	// Once the writer closes it, the file is anyone's to recover
+	writer.StopLogger()
+	recoverer.RecoverFallbackLogs()
+	logs, _ := mr.List("applogs:TEST:unit:test-service:")
+	assert.Equal(t, 1, len(logs))
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
@@ -0,0 +1,95 @@
+package applogs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactKeysInFields(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.RedactKeys("card_number")
+
+	fields := map[string]interface{}{
+		"user":     "alice",
+		"Password": "hunter2",
+		"payment":  map[string]interface{}{"card_number": "4111111111111111", "amount": 12},
+		"attempts": []interface{}{map[string]interface{}{"token": "abc"}},
+	}
+	log.Info("Login", fields)
+
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "alice", metadata["user"])
+	assert.Equal(t, "***", metadata["Password"], "Keys are matched case-insensitively")
+	payment := metadata["payment"].(map[string]interface{})
+	assert.Equal(t, "***", payment["card_number"], "Nested maps are redacted")
+	assert.Equal(t, float64(12), payment["amount"])
+	attempt := metadata["attempts"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "***", attempt["token"], "Maps in slices are redacted")
+
+	assert.Equal(t, "hunter2", fields["Password"], "The caller's map is left untouched")
+	assert.Equal(t, "4111111111111111", fields["payment"].(map[string]interface{})["card_number"])
+	assert.Contains(t, log.EffectiveConfig().RedactKeys, "card_number")
+}
+
+// Entries pushed without the queue are redacted as well
+func TestRedactKeysInLogToRedis(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	logger.LogToRedis("info", "Direct login", map[string]interface{}{
+		"user":    "alice",
+		"token":   "abc",
+		"payment": map[string]interface{}{"secret": "s3cr3t"},
+	})
+
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "alice", metadata["user"])
+	assert.Equal(t, "***", metadata["token"])
+	assert.Equal(t, "***", metadata["payment"].(map[string]interface{})["secret"], "Nested maps are redacted")
+}
+
+func TestRedactKeysInRequestHeaders(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_HEADER_FORMAT", "prefixed")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	log.LogRequest("GET", "/orders", "127.0.0.1", map[string][]string{
+		"Authorization": {"Bearer secret-token"},
+		"Cookie":        {"session=1"},
+		"Accept":        {"application/json"},
+	})
+
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, "***", metadata["header_authorization"])
+	assert.Equal(t, "***", metadata["header_cookie"])
+	assert.Equal(t, "application/json", metadata["header_accept"])
+
+	// The console and syslog file never see the secret either
+	files, _ := filepath.Glob(filepath.Join("logs", "syslogs", "*.log"))
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		assert.False(t, strings.Contains(string(data), "secret-token"), file)
+	}
+}
@@ -0,0 +1,85 @@
+package applogs
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bashx3r0/scala-applogs-client/config"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAuthRedis starts a mock Redis accepting only the applogs ACL user
+func setupAuthRedis(t *testing.T) *miniredis.Miniredis {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	mr.RequireUserAuth("applogs", "s3cret")
+	return mr
+}
+
+func TestRedisAuthFromEnvironment(t *testing.T) {
+	setIdentity(t, "1")
+	mr := setupAuthRedis(t)
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+	t.Setenv("APPLG_CORE_REDIS_USERNAME", "applogs")
+	t.Setenv("APPLG_CORE_REDIS_PASSWORD", "s3cret")
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.Info("Authenticated", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The entry is pushed with the configured credentials")
+
+	cfg := log.EffectiveConfig()
+	assert.Equal(t, "applogs", cfg.RedisUsername)
+	assert.Equal(t, config.RedactedValue, cfg.RedisPassword, "The password is never exposed")
+}
+
+func TestRedisAuthFromURL(t *testing.T) {
+	setIdentity(t, "1")
+	mr := setupAuthRedis(t)
+	t.Setenv("APPLG_CORE_REDIS", "redis://applogs:s3cret@"+mr.Addr()+"/0")
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.Info("Authenticated", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "Credentials are read from the URL")
+	assert.NotContains(t, log.EffectiveConfig().RedisAddr, "s3cret")
+}
+
+func TestRedisAuthPasswordOverridesURL(t *testing.T) {
+	setIdentity(t, "1")
+	mr := setupAuthRedis(t)
+	t.Setenv("APPLG_CORE_REDIS", "redis://applogs:stale@"+mr.Addr()+"/0")
+	t.Setenv("APPLG_CORE_REDIS_PASSWORD", "s3cret")
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.Info("Authenticated", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The password variable takes precedence over the URL")
+}
+
+func TestRedisAuthWrongPasswordFallsBack(t *testing.T) {
+	setIdentity(t, "1")
+	mr := setupAuthRedis(t)
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+	t.Setenv("APPLG_CORE_REDIS_USERNAME", "applogs")
+	t.Setenv("APPLG_CORE_REDIS_PASSWORD", "wrong")
+	fallbackPath := createMockFallbackDir()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.Info("Rejected", nil)
+
+	assert.False(t, mr.Exists("applogs:TEST:unit:test-service:1"))
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "Entries refused for bad credentials are kept, not dropped")
+}
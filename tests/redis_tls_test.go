@@ -0,0 +1,107 @@
+package applogs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTLSRedis starts a mock Redis serving TLS with a self-signed
+// certificate for 127.0.0.1, and returns it with the certificate's PEM file
+func setupTLSRedis(t *testing.T) (*miniredis.Miniredis, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "applogs-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	mr, err := miniredis.RunTLS(&tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}})
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	return mr, caFile
+}
+
+func TestRedisTLSWithCAFile(t *testing.T) {
+	setIdentity(t, "1")
+	mr, caFile := setupTLSRedis(t)
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+	t.Setenv("APPLG_CORE_REDIS_TLS", "true")
+	t.Setenv("APPLG_CORE_REDIS_CA_FILE", caFile)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.Info("Encrypted", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The entry is pushed over TLS")
+
+	cfg := log.EffectiveConfig()
+	assert.True(t, cfg.RedisTLS)
+	assert.Equal(t, caFile, cfg.RedisCAFile)
+}
+
+func TestRedisTLSRejectsUnknownCertificate(t *testing.T) {
+	setIdentity(t, "1")
+	mr, _ := setupTLSRedis(t)
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+	t.Setenv("APPLG_CORE_REDIS_TLS", "true")
+	fallbackPath := createMockFallbackDir()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.Info("Unverified", nil)
+
+	assert.False(t, mr.Exists("applogs:TEST:unit:test-service:1"), "A certificate outside the system pool is refused")
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)))
+}
+
+func TestRedisTLSInsecure(t *testing.T) {
+	setIdentity(t, "1")
+	mr, _ := setupTLSRedis(t)
+	t.Setenv("APPLG_CORE_REDIS", "rediss://"+mr.Addr()+"/0")
+	t.Setenv("APPLG_CORE_REDIS_TLS_INSECURE", "true")
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.Info("Unverified", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "Verification is skipped on request, for rediss:// addresses too")
+	assert.True(t, log.EffectiveConfig().RedisTLS)
+}
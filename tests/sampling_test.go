@@ -0,0 +1,59 @@
+package applogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// logFor logs info entries every interval (as fast as possible when 0) for
+// duration, returning how many were logged and how many were kept
+func logFor(log *applogs.Applogs, duration, interval time.Duration) (logged, kept int) {
+	before := log.LogsSampledTotal()
+	for end := time.Now().Add(duration); time.Now().Before(end); logged++ {
+		log.Info("Request served", nil)
+		if interval > 0 {
+			time.Sleep(interval)
+		}
+	}
+	return logged, logged - int(log.LogsSampledTotal()-before)
+}
+
+func TestAdaptiveSamplingStaysWithinBudget(t *testing.T) {
+	setIdentity(t, "1")
+	_, client := setupMockRedis(t)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetSampleTarget(200)
+	t.Cleanup(func() { log.SetSampleTarget(0) })
+
+	// Below the budget, everything is logged
+	logged, kept := logFor(log, 300*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, logged, kept, "Low volume is not sampled")
+	assert.Equal(t, 1.0, log.SampleRatio())
+
+	// A burst far above the budget is brought down to it
+	logged, kept = logFor(log, time.Second, 0)
+	assert.Greater(t, logged, 2000, "The burst must exceed the budget tenfold")
+	assert.InDelta(t, 200, kept, 50, "About a second's budget is kept during the burst")
+	assert.Less(t, log.SampleRatio(), 0.2, "The ratio follows the volume")
+
+	// Warnings are never sampled, even at the peak of a burst
+	before := log.LogsSampledTotal()
+	for i := 0; i < 100; i++ {
+		log.Warn("Slow query", nil)
+	}
+	assert.Equal(t, before, log.LogsSampledTotal())
+
+	// Once the volume drops back, everything is logged again
+	time.Sleep(300 * time.Millisecond)
+	logFor(log, 500*time.Millisecond, 10*time.Millisecond)
+	logged, kept = logFor(log, 300*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, logged, kept, "Sampling recovers when traffic falls")
+	assert.Equal(t, 1.0, log.SampleRatio())
+	assert.Equal(t, 200, log.EffectiveConfig().SampleTarget)
+}
@@ -0,0 +1,75 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaFieldRecordsVersion(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_SCHEMA_FIELD", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	defer log.SetSchemaField(false)
+	assert.True(t, log.EffectiveConfig().SchemaField)
+
+	log.Info("Versioned", nil)
+	log.LogSummary("info", "Versioned summary", nil)
+
+	for _, key := range []string{"applogs:TEST:unit:test-service:1", "applogs:TEST:unit:test-service:1:summary"} {
+		logs, _ := mr.List(key)
+		assert.NotEmpty(t, logs, key)
+		for _, raw := range logs {
+			var logData map[string]interface{}
+			json.Unmarshal([]byte(raw), &logData)
+			assert.Equal(t, float64(applogs.SchemaVersion), logData[applogs.SchemaField], "Entry %q", logData["message"])
+		}
+	}
+	assert.Equal(t, 1, applogs.SchemaVersion, "Bump the version, and the README table, when the format changes")
+}
+
+func TestSchemaFieldOffByDefault(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	log.Info("Unversioned", nil)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if assert.Equal(t, 1, len(logs)) {
+		assert.NotContains(t, logs[0], applogs.SchemaField)
+	}
+}
+
+// An entry keeps the version it was written with when replayed from fallback
+func TestSchemaFieldKeptOnRecovery(t *testing.T) {
+	setIdentity(t, "1")
+	down, downClient := setupMockRedis(t)
+	down.Close()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(downClient)
+	log.Warn("Written unversioned", nil)
+
+	log.SetSchemaField(true)
+	defer log.SetSchemaField(false)
+	log.SetRedisClient(client)
+	log.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if assert.Equal(t, 1, len(logs)) {
+		assert.NotContains(t, logs[0], applogs.SchemaField, "Recovery does not claim a version the entry was not written with")
+	}
+}
@@ -0,0 +1,86 @@
+package applogs
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run with -race: the fields map is mutated while the worker processes the
+// entry, which is only safe when entries are serialized at enqueue time
+func TestSerializeOnEnqueueIgnoresLaterMutation(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_SERIALIZE_ON_ENQUEUE", "true")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	assert.True(t, log.EffectiveConfig().SerializeOnEnqueue)
+
+	// The first entry blocks the worker while the second is mutated
+	log.Info("Blocking log", nil)
+	fields := map[string]interface{}{"counter": 0, "user": "alice"}
+	log.Info("Mutated log", fields)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 1000; i++ {
+			fields["counter"] = i
+			fields["user"] = "mallory"
+		}
+		delete(fields, "user")
+	}()
+	close(blocking.release)
+	wg.Wait()
+	log.StopLogger()
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if !assert.Equal(t, 2, len(logs)) {
+		return
+	}
+	var logData struct {
+		Message  string                 `json:"message"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	assert.Equal(t, "Mutated log", logData.Message)
+	assert.Equal(t, map[string]interface{}{"counter": float64(0), "user": "alice"}, logData.Metadata)
+}
+
+func TestSerializeOnEnqueueFallbackKeepsPayload(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_SERIALIZE_ON_ENQUEUE", "true")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath) // Later tests must not recover the entry
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+
+	log.Named("db").Warn("Serialized fallback", map[string]interface{}{"table": "users"})
+	var logs []string
+	if !assert.Eventually(t, func() bool {
+		logs = readFallbackLogs(fallbackPath)
+		return len(logs) == 1
+	}, 2*time.Second, 10*time.Millisecond) {
+		return
+	}
+	var logData map[string]interface{}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	assert.Equal(t, "Serialized fallback", logData["message"])
+	assert.Equal(t, "db", logData["component"])
+	assert.Equal(t, map[string]interface{}{"table": "users"}, logData["metadata"])
+}
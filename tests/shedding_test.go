@@ -0,0 +1,65 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSheddingDropsLowLevelsFirst(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	log.SetLoadShedding(50) // Debug shed from 5 queued entries, info from 7.5
+	defer log.SetLoadShedding(0)
+	shedBefore := log.LogsShedTotal()
+
+	// The worker blocks on the first entry, leaving the others queued
+	log.Warn("Blocker", nil)
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		log.Warn("Filler", nil)
+	}
+
+	log.Debug("Shed debug", nil) // 5 queued: debug is shed
+	log.Info("Kept info", nil)   // info is still admitted
+	log.Warn("Filler", nil)
+	log.Warn("Filler", nil)
+	log.Info("Shed info", nil)   // 8 queued: info is shed too
+	log.Error("Kept error", nil) // errors are always admitted
+	log.Debug("Shed debug", nil)
+
+	assert.Equal(t, shedBefore+3, log.LogsShedTotal())
+
+	close(blocking.release)
+	time.Sleep(300 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	var messages []string
+	for _, raw := range logs {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(raw), &logData)
+		messages = append(messages, logData["message"].(string))
+	}
+	assert.Equal(t, 10, len(messages))
+	assert.Contains(t, messages, "Kept info")
+	assert.Contains(t, messages, "Kept error")
+	assert.NotContains(t, messages, "Shed debug")
+	assert.NotContains(t, messages, "Shed info")
+}
+
+func TestLoadSheddingFromEnvironment(t *testing.T) {
+	setSettingEnv(t, "APPLG_SHED_HIGH_WATER", "80")
+	log := applogs.NewLogger(10)
+	defer log.SetLoadShedding(0)
+
+	assert.Equal(t, 80, log.EffectiveConfig().LoadShedding)
+}
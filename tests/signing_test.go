@@ -0,0 +1,90 @@
+package applogs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedEntryVerifies(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_SIGNING_KEY", "audit-secret")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	defer log.SetSigningKey(nil)
+	assert.True(t, log.EffectiveConfig().Signing)
+
+	log.Warn("Role granted", map[string]interface{}{"user": "alice", "role": "admin", "zone": 1})
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	entry := []byte(logs[0])
+
+	assert.True(t, applogs.VerifySignature([]byte("audit-secret"), entry))
+	assert.False(t, applogs.VerifySignature([]byte("wrong-secret"), entry))
+
+	// The documented procedure: HMAC the entry without its leading _sig member
+	var logData map[string]interface{}
+	assert.NoError(t, json.Unmarshal(entry, &logData))
+	sig := logData[applogs.SigField].(string)
+	signed := append([]byte("{"), bytes.TrimPrefix(entry, []byte(`{"_sig":"`+sig+`",`))...)
+	mac := hmac.New(sha256.New, []byte("audit-secret"))
+	mac.Write(signed)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), sig)
+
+	// The signed form has sorted keys, so consumers may also re-serialize canonically
+	delete(logData, applogs.SigField)
+	var canonical map[string]interface{}
+	assert.NoError(t, json.Unmarshal(signed, &canonical))
+	assert.Equal(t, logData, canonical)
+}
+
+func TestTamperedEntryFailsVerification(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	log.SetSigningKey([]byte("audit-secret"))
+	defer log.SetSigningKey(nil)
+
+	log.Warn("Role granted", map[string]interface{}{"role": "viewer"})
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+
+	tampered := bytes.Replace([]byte(logs[0]), []byte(`"viewer"`), []byte(`"admin"`), 1)
+	assert.False(t, applogs.VerifySignature([]byte("audit-secret"), tampered))
+	assert.False(t, applogs.VerifySignature([]byte("audit-secret"), []byte(`{"message":"unsigned"}`)))
+}
+
+func TestRecoveredEntryIsSignedOnce(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	down, downClient := setupMockRedis(t)
+	down.Close()
+
+	fallbackPath := createMockFallbackDir()
+	log := applogs.NewTestLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(downClient)
+	log.SetSigningKey([]byte("audit-secret"))
+	defer log.SetSigningKey(nil)
+
+	log.Error("Saved to fallback", nil)
+	log.SetRedisClient(client)
+	logger.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, 1, bytes.Count([]byte(logs[0]), []byte(`"_sig"`)))
+	assert.True(t, applogs.VerifySignature([]byte("audit-secret"), []byte(logs[0])))
+}
@@ -0,0 +1,123 @@
+package applogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingSink rejects every push
+type failingSink struct{}
+
+func (failingSink) Push(ctx context.Context, key string, entries [][]byte) error {
+	return errors.New("collector unavailable")
+}
+
+func TestRedisSinkPushesAndTrims(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetSink(applogs.NewRedisSink(client))
+	log.SetMaxListLength(2)
+	t.Cleanup(func() { log.SetMaxListLength(0) })
+
+	for _, message := range []string{"First", "Second", "Third"} {
+		log.Info(message, nil)
+	}
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	entries := listEntries(t, logs)
+	if assert.Equal(t, 2, len(entries), "The list is trimmed") {
+		assert.Equal(t, "Second", entries[0]["message"])
+		assert.Equal(t, "Third", entries[1]["message"])
+	}
+
+	assert.Error(t, applogs.NewRedisSink(nil).Push(context.Background(), "key", [][]byte{[]byte("{}")}))
+}
+
+func TestRedisSinkFailedTrimKeepsEntries(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	client.AddHook(failingTrimHook{})
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetSink(applogs.NewRedisSink(client))
+	log.SetMaxListLength(10)
+	t.Cleanup(func() { log.SetMaxListLength(0) })
+
+	assert.NoError(t, applogs.NewRedisSink(client).Push(context.Background(), "direct", [][]byte{[]byte("{}")}))
+	log.Info("Kept", nil)
+	assert.Empty(t, readFallbackLogs(fallbackPath), "The entry was pushed")
+	log.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "Nothing is pushed again")
+}
+
+func TestNewLoggerWithSinkSkipsRedis(t *testing.T) {
+	setIdentity(t, "1")
+	t.Setenv("APPLG_CORE_REDIS", "redis-host:6379:0")
+	sink := &applogs.MemorySink{}
+
+	log := applogs.NewLoggerWithSink(10, sink)
+	assert.NoError(t, log.InitError(), "The Redis address is not used")
+	assert.True(t, log.IsHealthy())
+
+	log.Info("Through the sink", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, log.Flush(ctx))
+	log.StopLogger()
+	assert.Contains(t, sink.String(), "Through the sink")
+}
+
+func TestSetSinkWhileLogging(t *testing.T) {
+	setIdentity(t, "1")
+	first, second := &applogs.MemorySink{}, &applogs.MemorySink{}
+	log := applogs.NewLoggerWithSink(100, first)
+
+	for i := 0; i < 50; i++ {
+		if i == 25 {
+			log.SetSink(second) // While the worker pushes the first entries
+		}
+		log.Info("Swapped", map[string]interface{}{"seq": i})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, log.Flush(ctx))
+	log.StopLogger()
+	assert.Equal(t, 50, len(first.Logs())+len(second.Logs()), "Every entry reaches one of the sinks")
+	assert.NotEmpty(t, second.Logs())
+}
+
+func TestAnySinkErrorFallsBack(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetSink(failingSink{})
+
+	log.Warn("Collector is down", nil)
+	logs := readFallbackLogs(fallbackPath)
+	if assert.Equal(t, 1, len(logs)) {
+		assert.Contains(t, logs[0], "Collector is down")
+	}
+
+	sink := &applogs.MemorySink{}
+	log.SetSink(sink)
+	log.RecoverFallbackLogs()
+	assert.Contains(t, sink.String(), "Collector is down", "Recovery goes through the new sink")
+	assert.Empty(t, readFallbackLogs(fallbackPath))
+}
@@ -0,0 +1,41 @@
+package applogs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackTraceStartsAtCallSite(t *testing.T) {
+	setIdentity(t, "1")
+	log, sink := applogs.NewCaptureLogger()
+	defer log.StopLogger()
+	log.SetFatalMode(applogs.FatalNone)
+	t.Cleanup(func() { log.SetFatalMode(applogs.FatalExit) })
+	assert.False(t, log.EffectiveConfig().StackTraceOnError, "Off by default for errors")
+	assert.True(t, log.EffectiveConfig().StackTraceOnFatal, "On by default for fatal entries")
+
+	log.Error("Without trace", nil)
+	log.Fatal("With trace", nil)
+	log.SetStackTraceOnError(true)
+	t.Cleanup(func() { log.SetStackTraceOnError(false) })
+	log.Sugar().Errorw("Sugared", "order_id", "o-1")
+	log.Error("Own trace", map[string]interface{}{"stacktrace": "kept"})
+	log.Warn("Never traced", nil)
+
+	logs := sink.Logs()
+	if !assert.Equal(t, 5, len(logs)) {
+		return
+	}
+	assert.NotContains(t, logs[0].Fields, "stacktrace")
+	for _, entry := range logs[1:3] {
+		trace, _ := entry.Fields["stacktrace"].(string)
+		assert.True(t, strings.HasPrefix(trace, "github.com/bashx3r0/scala-applogs-client/tests.TestStackTraceStartsAtCallSite\n\t"), "%s starts at the caller: %s", entry.Message, trace)
+		assert.Contains(t, trace, "stacktrace_test.go:")
+	}
+	assert.Equal(t, "o-1", logs[2].Fields["order_id"])
+	assert.Equal(t, "kept", logs[3].Fields["stacktrace"], "A trace in the fields wins")
+	assert.NotContains(t, logs[4].Fields, "stacktrace")
+}
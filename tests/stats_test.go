@@ -0,0 +1,85 @@
+package applogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsCountDeliveries(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	before := log.Stats()
+
+	log.Info("Pushed", nil)
+	log.Info("Pushed too", nil)
+	log.SetSink(failingSink{})
+	log.Warn("Saved to fallback", nil)
+	log.RecoverFallbackLogs() // Fails through the same sink
+	log.SetSink(nil)
+	log.RecoverFallbackLogs()
+
+	after := log.Stats()
+	assert.Equal(t, uint64(2), after.Pushed-before.Pushed, "Recovered entries are counted apart")
+	assert.Equal(t, uint64(1), after.FallbackWritten-before.FallbackWritten)
+	assert.Equal(t, uint64(1), after.Recovered-before.Recovered)
+	assert.Equal(t, uint64(1), after.RecoveryFailed-before.RecoveryFailed)
+	assert.Equal(t, 0, after.QueueLength, "The test logger has no queue")
+}
+
+func TestStatsCountEachLoggerApart(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	billing := applogs.NewTestLogger()
+	defer billing.StopLogger()
+	billing.SetRedisClient(client)
+	shipping := applogs.NewTestLogger()
+	defer shipping.StopLogger()
+	shipping.SetRedisClient(client)
+
+	billing.Info("Billed", nil)
+	billing.Info("Billed again", nil)
+	shipping.Info("Shipped", nil)
+
+	assert.Equal(t, uint64(2), billing.Stats().Pushed)
+	assert.Equal(t, uint64(1), shipping.Stats().Pushed)
+}
+
+func TestStatsReportQueueDepth(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(5)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	defer func() {
+		close(blocking.release)
+		log.StopLogger()
+	}()
+
+	// The worker blocks on the first entry, the others wait in the queue
+	log.Info("Blocks the worker", nil)
+	time.Sleep(10 * time.Millisecond)
+	before := log.Stats()
+	for i := 0; i < 7; i++ {
+		log.Info("Burst", nil)
+	}
+
+	stats := log.Stats()
+	assert.Equal(t, 5, stats.QueueLength)
+	assert.Equal(t, 5, stats.QueueCapacity)
+	assert.Equal(t, uint64(2), stats.Dropped-before.Dropped)
+}
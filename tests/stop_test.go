@@ -0,0 +1,110 @@
+package applogs
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run with -race: logging goroutines race StopLogger, which must neither
+// panic with a send on the closed queue nor report a data race
+func TestStopLoggerWhileLogging(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(100)
+	log.SetRedisClient(client)
+	log.SetStopPolicy(applogs.StopDrop)
+	defer log.SetStopPolicy(applogs.StopFallback)
+	child := log.Named("worker") // Views share the stop state of their parent
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 200; j++ {
+				if i%2 == 0 {
+					log.Info("Handling request", nil)
+				} else {
+					child.Warn("Handling request", nil)
+				}
+			}
+		}(i)
+	}
+	close(start)
+	log.StopLogger()
+	child.StopLogger() // A second stop is a no-op
+	wg.Wait()
+}
+
+func TestLogAfterStopGoesToFallback(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath) // Recovery loops of earlier tests must not replay the entry into later ones
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	log.StopLogger()
+
+	log.Error("Shutdown interrupted", map[string]interface{}{"step": "drain"})
+
+	// Only this entry is counted, as workers of earlier tests may still be draining
+	saved := 0
+	for _, entry := range readFallbackLogs(fallbackPath) {
+		if strings.Contains(entry, "Shutdown interrupted") {
+			saved++
+		}
+	}
+	assert.Equal(t, 1, saved, "The entry is saved for the next run to recover")
+}
+
+func TestStopLoggerDrainsQueue(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(1000)
+	log.SetRedisClient(client)
+	for i := 0; i < 500; i++ {
+		log.Info("Queued before shutdown", map[string]interface{}{"i": i})
+	}
+	log.StopLogger()
+
+	// No sleep: StopLogger only returns once the worker has flushed everything
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 500, len(logs), "Every queued entry is pushed before StopLogger returns")
+}
+
+func TestStopLoggerWithTimeout(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	blocking := &blockingRedisClient{Client: client, release: make(chan struct{})}
+	logger.SetRedisClient(blocking)
+	log.Info("Stuck in the worker", nil)
+	log.Info("Waiting in the queue", nil)
+
+	err := log.StopLoggerWithTimeout(50 * time.Millisecond)
+	assert.ErrorIs(t, err, applogs.ErrStopTimeout, "A stalled push exceeds the timeout")
+
+	// The worker keeps draining, and a later stop waits for it to finish
+	close(blocking.release)
+	assert.NoError(t, log.StopLoggerWithTimeout(time.Second))
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 2, len(logs))
+}
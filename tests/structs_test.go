@@ -0,0 +1,130 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+type structAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type structAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type structUser struct {
+	structAudit
+	ID       int           `json:"id"`
+	Name     string        // Untagged fields keep their Go name
+	Password string        `json:"password" log:"sensitive"`
+	Internal string        `log:"-"`
+	Ignored  string        `json:"-"`
+	Address  structAddress `json:"address"`
+	Manager  *structUser   `json:"manager"`
+	Joined   time.Time     `json:"joined"`
+	secret   string
+}
+
+type structNode struct {
+	Name string      `json:"name"`
+	Next *structNode `json:"next"`
+}
+
+// logStructMetadata logs v with InfoStruct and returns the pushed metadata
+func logStructMetadata(t *testing.T, v interface{}) map[string]interface{} {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+
+	log.InfoStruct("Struct log", v)
+	time.Sleep(200 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if !assert.Equal(t, 1, len(logs)) {
+		return nil
+	}
+	var logData struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	return logData.Metadata
+}
+
+func TestInfoStructHonorsTags(t *testing.T) {
+	joined := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	metadata := logStructMetadata(t, &structUser{
+		structAudit: structAudit{CreatedBy: "admin"},
+		ID:          7,
+		Name:        "alice",
+		Password:    "hunter2",
+		Internal:    "internal",
+		Ignored:     "ignored",
+		Address:     structAddress{City: "Lisbon"},
+		Joined:      joined,
+		secret:      "secret",
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"id":       float64(7),
+		"Name":     "alice",
+		"password": applogs.RedactedValue,
+		"address":  map[string]interface{}{"city": "Lisbon"},
+		"manager":  nil,
+		"joined":   "2024-01-02T03:04:05Z",
+	}, metadata, "Unexported and embedded-only fields are neither expanded nor leaked")
+}
+
+func TestInfoStructFlattensExportedEmbedded(t *testing.T) {
+	type Audit struct {
+		CreatedBy string `json:"created_by"`
+	}
+	type Order struct {
+		Audit
+		ID int `json:"id"`
+	}
+
+	metadata := logStructMetadata(t, Order{Audit: Audit{CreatedBy: "admin"}, ID: 1})
+
+	assert.Equal(t, map[string]interface{}{"created_by": "admin", "id": float64(1)}, metadata)
+}
+
+func TestInfoStructNested(t *testing.T) {
+	metadata := logStructMetadata(t, structUser{
+		Name:    "bob",
+		Manager: &structUser{Name: "carol", Password: "secret"},
+	})
+
+	manager, ok := metadata["manager"].(map[string]interface{})
+	if assert.True(t, ok, "Nested struct pointers become nested objects") {
+		assert.Equal(t, "carol", manager["Name"])
+		assert.Equal(t, applogs.RedactedValue, manager["password"])
+	}
+}
+
+func TestInfoStructStopsAtCycles(t *testing.T) {
+	a := &structNode{Name: "a"}
+	b := &structNode{Name: "b", Next: a}
+	a.Next = b
+
+	metadata := logStructMetadata(t, a)
+
+	assert.Equal(t, map[string]interface{}{
+		"name": "a",
+		"next": map[string]interface{}{"name": "b", "next": "[cycle]"},
+	}, metadata)
+}
+
+func TestInfoStructNonStruct(t *testing.T) {
+	metadata := logStructMetadata(t, 42)
+
+	assert.Equal(t, map[string]interface{}{"value": float64(42)}, metadata)
+}
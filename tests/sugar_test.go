@@ -0,0 +1,129 @@
+package applogs
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupSugaredLogger returns a synchronous sugared logger writing to a mock Redis
+func setupSugaredLogger(t *testing.T) (*miniredis.Miniredis, *applogs.SugaredApplogs) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	t.Cleanup(mr.Close)
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	t.Cleanup(log.StopLogger)
+	return mr, log.Sugar()
+}
+
+// lastEntry returns the newest entry of the test list
+func lastEntry(t *testing.T, mr *miniredis.Miniredis) map[string]interface{} {
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	if !assert.NotEmpty(t, logs) {
+		return nil
+	}
+	var logData map[string]interface{}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	return logData
+}
+
+func TestSugaredSprintVariant(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+
+	// Like fmt.Sprint, spaces are added between operands when neither is a string
+	sugar.Info("retries: ", 3, 4)
+	entry := lastEntry(t, mr)
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "retries: 3 4", entry["message"])
+
+	sugar.Error(errors.New("boom"))
+	entry = lastEntry(t, mr)
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "boom", entry["message"])
+}
+
+func TestSugaredFormattedVariant(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+
+	sugar.Warnf("disk %s at %d%%", "/data", 91)
+	entry := lastEntry(t, mr)
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "disk /data at 91%", entry["message"])
+
+	// Without args the template is logged as is, like zap
+	sugar.Infof("100%")
+	assert.Equal(t, "100%", lastEntry(t, mr)["message"])
+}
+
+func TestSugaredPrintlnVariant(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+
+	sugar.Infoln("user", "logged", "in")
+	entry := lastEntry(t, mr)
+	assert.Equal(t, "user logged in", entry["message"], "Operands are space separated without a trailing newline")
+}
+
+func TestSugaredStructuredVariant(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+
+	sugar.Infow("Order placed", "order_id", "A-17", "amount", 42)
+	entry := lastEntry(t, mr)
+	assert.Equal(t, "Order placed", entry["message"])
+	assert.Equal(t, map[string]interface{}{"order_id": "A-17", "amount": float64(42)}, entry["metadata"])
+}
+
+func TestSugaredStructuredOddArguments(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+
+	sugar.Errorw("Payment failed", "order_id", "A-17", 7, "retry", "dangling")
+	entry := lastEntry(t, mr)
+	assert.Equal(t, map[string]interface{}{
+		"order_id":           "A-17",
+		"7":                  "retry",
+		applogs.IgnoredField: "dangling",
+	}, entry["metadata"], "Non-string keys are formatted and a key without value is kept")
+}
+
+func TestSugaredRespectsLevels(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+	assert.NoError(t, sugar.Desugar().SetLogSpec("*=warn"))
+	defer sugar.Desugar().SetLogSpec("")
+
+	sugar.Debugw("Hidden", "k", "v")
+	sugar.Infof("Hidden %d", 1)
+	sugar.Warnln("Shown")
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, "Shown", lastEntry(t, mr)["message"])
+}
+
+func TestFormattedMethods(t *testing.T) {
+	setIdentity(t, "1")
+	log, sink := applogs.NewCaptureLogger()
+	defer log.StopLogger()
+	log.SetFatalMode(applogs.FatalNone)
+	t.Cleanup(func() { log.SetFatalMode(applogs.FatalExit) })
+	log.SetStackTraceOnFatal(false)
+	t.Cleanup(func() { log.SetStackTraceOnFatal(true) })
+
+	log.Debugf("Cache has %d keys", 1200)
+	log.Infof("User %s logged in", "alice")
+	log.Warnf("Disk at 95%")
+	log.Errorf("Payment %q failed: %v", "p-1", errors.New("card declined"))
+	log.Fatalf("Shard %d lost", 3)
+
+	assert.Equal(t, []applogs.LogEntry{
+		{Level: "debug", Message: "Cache has 1200 keys", Fields: map[string]interface{}{}},
+		{Level: "info", Message: "User alice logged in", Fields: map[string]interface{}{}},
+		{Level: "warn", Message: "Disk at 95%", Fields: map[string]interface{}{}},
+		{Level: "error", Message: `Payment "p-1" failed: card declined`, Fields: map[string]interface{}{}},
+		{Level: "fatal", Message: "Shard 3 lost", Fields: map[string]interface{}{}},
+	}, sink.Logs(), "Without args the format is logged as it is")
+}
@@ -0,0 +1,68 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// readSingleEntry returns the only entry of a Redis list
+func readSingleEntry(t *testing.T, logs []string) map[string]interface{} {
+	if !assert.Equal(t, 1, len(logs)) {
+		return nil
+	}
+	var logData map[string]interface{}
+	json.Unmarshal([]byte(logs[0]), &logData)
+	return logData
+}
+
+func TestLogSummaryWritesSummaryAndDetail(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+
+	log.LogSummary("info", "Report generated", map[string]interface{}{
+		"status_code": 200,
+		"duration_ms": 1530,
+		"rows":        12000,
+		"query":       "SELECT ...",
+	})
+	time.Sleep(200 * time.Millisecond)
+
+	main, _ := mr.List("applogs:TEST:unit:test-service:1")
+	detail := readSingleEntry(t, main)
+	assert.Equal(t, "Report generated", detail["message"])
+	assert.Len(t, detail["metadata"], 4, "The main key holds the full entry")
+	assert.NotContains(t, detail, "summary")
+
+	summaries, _ := mr.List("applogs:TEST:unit:test-service:1:summary")
+	summary := readSingleEntry(t, summaries)
+	assert.Equal(t, "Report generated", summary["message"])
+	assert.Equal(t, true, summary["summary"])
+	assert.Equal(t, map[string]interface{}{"status_code": float64(200), "duration_ms": float64(1530)}, summary["metadata"])
+}
+
+func TestLogSummaryFieldSelectionConfigurable(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_SUMMARY_FIELDS", "rows, tenant")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	assert.Equal(t, []string{"rows", "tenant"}, log.EffectiveConfig().SummaryFields)
+
+	log.LogSummary("warn", "Slow export", map[string]interface{}{"rows": 5, "status_code": 200})
+	time.Sleep(200 * time.Millisecond)
+
+	summaries, _ := mr.List("applogs:TEST:unit:test-service:1:summary")
+	summary := readSingleEntry(t, summaries)
+	assert.Equal(t, "warn", summary["level"])
+	assert.Equal(t, map[string]interface{}{"rows": float64(5)}, summary["metadata"])
+}
@@ -0,0 +1,49 @@
+package applogs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSyncWritesBeforeReturning(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(10)
+	log.SetRedisClient(client)
+	defer log.StopLogger()
+
+	critical := log.WithSync()
+	critical.Info("Committing transaction", map[string]interface{}{"tx": 42})
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The entry should be in Redis without waiting for the worker")
+
+	// The parent keeps logging asynchronously through its queue
+	log.Info("Back to normal", nil)
+	time.Sleep(200 * time.Millisecond)
+	logs, _ = mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 2, len(logs))
+}
+
+func TestWithSyncWritesFallbackBeforeReturning(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	mr.Close()
+
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+	log := applogs.NewLogger(10)
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	defer log.StopLogger()
+
+	log.Named("shutdown").WithSync().Error("Flushing state", nil)
+
+	assert.Equal(t, 1, len(readFallbackLogs(fallbackPath)), "The entry should be on disk without waiting for the worker")
+}
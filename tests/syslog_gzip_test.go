@@ -0,0 +1,87 @@
+package applogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// readSyslogMessages returns the messages of the entries of a syslog file,
+// and whether the file ended cleanly
+func readSyslogMessages(t *testing.T, path string) ([]string, bool) {
+	file, err := applogs.OpenSyslogFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var messages []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry struct {
+			Msg string `json:"msg"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid entry in %s: %v", path, err)
+		}
+		messages = append(messages, entry.Msg)
+	}
+	return messages, scanner.Err() == nil
+}
+
+func TestSyslogGzipSegments(t *testing.T) {
+	setIdentity(t, "1")
+	_, client := setupMockRedis(t)
+	setSettingEnv(t, "APPLG_SYSLOG_GZIP", "true")
+	setSettingEnv(t, "APPLG_SYSLOG_ROTATE_BYTES", "2000")
+	segments := filepath.Join("logs", "syslogs", "*.log.gz")
+	t.Cleanup(func() {
+		paths, _ := filepath.Glob(segments)
+		for _, path := range paths {
+			_ = os.Remove(path)
+		}
+	})
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	for i := 0; i < 50; i++ {
+		log.Info("Compressed entry", map[string]interface{}{"i": i})
+	}
+	log.StopLogger() // Flushes the segment being written
+
+	cfg := log.EffectiveConfig()
+	assert.True(t, cfg.SyslogGzip)
+	assert.Equal(t, int64(2000), cfg.SyslogRotateBytes)
+
+	paths, _ := filepath.Glob(segments)
+	assert.Greater(t, len(paths), 1, "Segments are rotated once full")
+
+	count, complete := 0, 0
+	for _, path := range paths {
+		messages, ended := readSyslogMessages(t, path)
+		if ended {
+			complete++
+		}
+		for _, message := range messages {
+			if message == "Compressed entry" {
+				count++
+			}
+		}
+	}
+	assert.Equal(t, 50, count, "Every entry is in a segment")
+	assert.Equal(t, len(paths)-1, complete, "Rotated segments are complete gzip streams")
+
+	// Reinitializing closes the segment being written, completing it too
+	setSettingEnv(t, "APPLG_SYSLOG_GZIP", "false")
+	logger.InitApplogsForTesting()
+	for _, path := range paths {
+		_, ended := readSyslogMessages(t, path)
+		assert.True(t, ended, "%s is a complete gzip stream", path)
+	}
+}
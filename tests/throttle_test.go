@@ -0,0 +1,52 @@
+package applogs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarnThrottledRollsUpPerWindow(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(100)
+	log.SetRedisClient(client)
+	log.SetThrottleWindow(100 * time.Millisecond)
+
+	// First window: 5 occurrences
+	for i := 0; i < 5; i++ {
+		log.WarnThrottled("cache-miss", "Cache miss", map[string]interface{}{"item": i})
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	// Second window: 3 occurrences
+	for i := 0; i < 3; i++ {
+		log.WarnThrottled("cache-miss", "Cache miss", map[string]interface{}{"item": i})
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	var details, rollups []map[string]interface{}
+	for i := len(logs) - 1; i >= 0; i-- {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(logs[i]), &logData)
+		metadata := logData["metadata"].(map[string]interface{})
+		if _, ok := metadata["occurrences"]; ok {
+			rollups = append(rollups, metadata)
+		} else {
+			details = append(details, logData)
+		}
+	}
+
+	assert.Equal(t, 2, len(details), "Only the first occurrence per window should be logged in detail")
+	if assert.Equal(t, 2, len(rollups), "Each window should end with a rollup") {
+		assert.Equal(t, float64(5), rollups[0]["occurrences"])
+		assert.Equal(t, float64(3), rollups[1]["occurrences"])
+		assert.Equal(t, "cache-miss", rollups[0]["throttle_key"])
+	}
+}
@@ -0,0 +1,54 @@
+package applogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimerLogsElapsedDuration(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+
+	fields := map[string]interface{}{"table": "users"}
+	timer := log.StartTimer("db.query", fields)
+	time.Sleep(50 * time.Millisecond)
+	elapsed := timer.Stop()
+
+	entry := lastEntry(t, mr)
+	assert.Equal(t, "db.query", entry["message"])
+	assert.Equal(t, "info", entry["level"])
+	metadata := entry["metadata"].(map[string]interface{})
+	assert.Equal(t, "users", metadata["table"])
+	assert.InDelta(t, 50, metadata[applogs.DurationField], 40, "The duration should be close to the slept time")
+	assert.InDelta(t, float64(elapsed.Microseconds())/1000, metadata[applogs.DurationField], 0.001)
+	assert.Len(t, fields, 1, "The caller's fields are not modified")
+}
+
+func TestTimerLevelConfigurable(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_TIMER_LEVEL", "debug")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	defer log.SetTimerLevel("info")
+	assert.Equal(t, "debug", log.EffectiveConfig().TimerLevel)
+
+	log.StartTimer("cache.lookup", nil).Stop()
+	assert.Equal(t, "debug", lastEntry(t, mr)["level"])
+
+	// Timers below the minimum level log nothing
+	assert.NoError(t, log.SetLogSpec("*=info"))
+	defer log.SetLogSpec("")
+	log.StartTimer("cache.lookup", nil).Stop()
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs))
+}
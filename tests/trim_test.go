@@ -0,0 +1,114 @@
+package applogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingTrimHook makes Redis reject every LTRIM, as an ACL without the
+// command would, by giving it an invalid start index
+type failingTrimHook struct{}
+
+func (failingTrimHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (failingTrimHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (failingTrimHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		if cmd.Name() == "ltrim" {
+			cmd.Args()[2] = "not-an-index"
+		}
+	}
+	return ctx, nil
+}
+
+func (failingTrimHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestMaxListLengthKeepsNewestEntries(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_MAX_LIST_LENGTH", "5")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewLogger(100)
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	t.Cleanup(func() { log.SetMaxListLength(0) })
+	assert.Equal(t, 5, log.EffectiveConfig().MaxListLength)
+	assert.Contains(t, log.RedisCommands(), "ltrim")
+
+	for i := 1; i <= 20; i++ {
+		log.Info(fmt.Sprintf("Entry %d", i), nil)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, log.Flush(ctx))
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	var messages []string
+	for _, raw := range logs {
+		var logData map[string]interface{}
+		json.Unmarshal([]byte(raw), &logData)
+		messages = append(messages, logData["message"].(string))
+	}
+	assert.Equal(t, []string{"Entry 20", "Entry 19", "Entry 18", "Entry 17", "Entry 16"}, messages, "The oldest entries are trimmed")
+}
+
+func TestListsUnboundedByDefault(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	for i := 0; i < 20; i++ {
+		log.Info("Kept", nil)
+	}
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 20, len(logs))
+	assert.NotContains(t, log.RedisCommands(), "ltrim")
+}
+
+func TestFailedTrimKeepsEntriesOutOfFallback(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	client.AddHook(failingTrimHook{})
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetRedisClient(client)
+	log.SetMaxListLength(10)
+	t.Cleanup(func() { log.SetMaxListLength(0) })
+	log.SetClassifyError(func(err error) applogs.ErrorClass {
+		return applogs.ErrorUnavailable // A failed push would go to fallback
+	})
+	defer log.SetClassifyError(nil)
+
+	pushed := log.Stats().Pushed
+	log.Info("First", nil)
+	log.Info("Second", nil)
+	assert.Equal(t, pushed+2, log.Stats().Pushed, "The failed trim does not fail the push")
+	assert.Empty(t, readFallbackLogs(fallbackPath), "The entries were pushed")
+	log.RecoverFallbackLogs()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 2, len(logs), "Nothing is pushed again")
+}
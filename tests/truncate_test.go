@@ -0,0 +1,57 @@
+package applogs
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateStringKeepsRunesWhole(t *testing.T) {
+	s := "日志记录系统" // 3 bytes per rune
+
+	for max := 1; max <= len(s); max++ {
+		truncated := logger.TruncateString(s, max)
+		assert.True(t, utf8.ValidString(truncated), "Cut at %d bytes must be valid UTF-8: %q", max, truncated)
+		assert.LessOrEqual(t, len(truncated), max)
+	}
+	assert.Equal(t, "日志记…", logger.TruncateString(s, 12), "12 bytes hold three runes and the ellipsis")
+	assert.Equal(t, "日志…", logger.TruncateString(s, 11), "The cut moves back to the start of a rune")
+	assert.Equal(t, s, logger.TruncateString(s, len(s)))
+}
+
+func TestMaxStringBytesTruncatesPayload(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	log.SetRedisClient(client)
+	defer log.StopLogger()
+	log.SetMaxStringBytes(16)
+	defer log.SetMaxStringBytes(0)
+
+	fields := map[string]interface{}{
+		"note": "Pembayaran berjaya diterima",
+		"tags": []interface{}{"支付成功，谢谢您的惠顾"},
+		"code": 42,
+	}
+	log.Info("订单已经成功处理完毕", fields)
+
+	entry := lastEntry(t, mr)
+	message := entry["message"].(string)
+	assert.True(t, utf8.ValidString(message))
+	assert.True(t, strings.HasSuffix(message, logger.Ellipsis))
+	assert.Equal(t, "订单已经…", message)
+
+	metadata := entry["metadata"].(map[string]interface{})
+	assert.Equal(t, "Pembayaran be…", metadata["note"])
+	tag := metadata["tags"].([]interface{})[0].(string)
+	assert.True(t, utf8.ValidString(tag))
+	assert.LessOrEqual(t, len(tag), 16)
+	assert.Equal(t, float64(42), metadata["code"])
+	assert.Equal(t, "Pembayaran berjaya diterima", fields["note"], "The caller's fields are not changed")
+}
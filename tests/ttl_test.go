@@ -0,0 +1,71 @@
+package applogs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyTTLRefreshedOnEachPush(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_KEY_TTL", "60")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	t.Cleanup(func() { log.SetKeyTTL(0) })
+	assert.Equal(t, time.Minute, log.EffectiveConfig().KeyTTL)
+	assert.Contains(t, log.RedisCommands(), "expire")
+
+	key := "applogs:TEST:unit:test-service:1"
+	log.Info("First", nil)
+	assert.Equal(t, time.Minute, mr.TTL(key))
+
+	mr.FastForward(40 * time.Second)
+	log.Info("Second", nil)
+	assert.Equal(t, time.Minute, mr.TTL(key), "Each push refreshes the expiry")
+
+	mr.FastForward(61 * time.Second)
+	assert.False(t, mr.Exists(key), "An idle list expires")
+}
+
+func TestKeyTTLAppliedOnRecovery(t *testing.T) {
+	setIdentity(t, "1")
+	setSettingEnv(t, "APPLG_KEY_TTL", "60")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.SetFallbackPath(fallbackPath)
+	t.Cleanup(func() { log.SetKeyTTL(0) })
+
+	writeFallbackFile(t, fallbackPath, "fallback_1_100_20240101120000.log", "1", "Recovered")
+	log.RecoverFallbackLogs()
+
+	key := "applogs:TEST:unit:test-service:1"
+	logs, _ := mr.List(key)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, time.Minute, mr.TTL(key), "Recovered pushes set the expiry too")
+}
+
+func TestKeysNeverExpireByDefault(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+	log.Info("Kept", nil)
+
+	assert.Equal(t, time.Duration(0), mr.TTL("applogs:TEST:unit:test-service:1"))
+}
@@ -0,0 +1,55 @@
+package applogs
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/config"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValuesFields(t *testing.T) {
+	form := url.Values{
+		"user":         {"aisyah"},
+		"tag":          {"malay", "chinese"},
+		"password":     {"hunter2"},
+		"access_token": {"abc", "def"},
+		"X-Api-Key":    {"k"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"user":         "aisyah",
+		"tag":          []string{"malay", "chinese"},
+		"password":     config.RedactedValue,
+		"access_token": config.RedactedValue,
+		"X-Api-Key":    config.RedactedValue,
+	}, applogs.ValuesFields(form))
+}
+
+func TestLogRequestQueryParams(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+	log := sugar.Desugar()
+	log.SetLogQueryParams(true)
+	defer log.SetLogQueryParams(false)
+
+	log.LogRequest("GET", "/api/search?q=kopi&page=2&page=3&token=s3cret", "10.0.0.1", nil)
+
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"q":     "kopi",
+		"page":  []interface{}{"2", "3"},
+		"token": config.RedactedValue,
+	}, metadata[applogs.QueryField])
+	assert.Equal(t, "/api/search?page=2&page=3&q=kopi&token=%2A%2A%2A", metadata["url"], "The secret is redacted in the URL too")
+}
+
+func TestLogRequestWithoutQueryParams(t *testing.T) {
+	mr, sugar := setupSugaredLogger(t)
+
+	sugar.Desugar().LogRequest("GET", "/api/search?q=kopi", "10.0.0.1", nil)
+
+	metadata := lastEntry(t, mr)["metadata"].(map[string]interface{})
+	assert.NotContains(t, metadata, applogs.QueryField, "Query logging is off by default")
+	assert.Equal(t, "/api/search?q=kopi", metadata["url"])
+}
@@ -0,0 +1,79 @@
+package applogs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForRedisAtStartup(t *testing.T) {
+	setIdentity(t, "1")
+	mr := miniredis.RunT(t)
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+	mr.Close() // Redis is still starting when the logger initializes
+	t.Setenv("APPLG_WAIT_FOR_REDIS", "5")
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = mr.Restart()
+	}()
+	start := time.Now()
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond, "Initialization waits for Redis")
+	assert.Less(t, time.Since(start), 5*time.Second, "Initialization returns once Redis answers")
+	assert.Equal(t, 5*time.Second, log.EffectiveConfig().WaitForRedis)
+
+	log.SetFallbackPath(fallbackPath)
+	log.Info("Ready", nil)
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 1, len(logs), "The first entry reaches Redis")
+	assert.Empty(t, readFallbackLogs(fallbackPath), "Nothing goes to fallback")
+}
+
+func TestWaitForRedisTimeout(t *testing.T) {
+	setIdentity(t, "1")
+	mr := miniredis.RunT(t)
+	t.Setenv("APPLG_CORE_REDIS", mr.Addr())
+	mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+
+	start := time.Now()
+	err := log.WaitForRedis(300 * time.Millisecond)
+	assert.ErrorIs(t, err, applogs.ErrRedisUnavailable)
+	assert.InDelta(t, 300*time.Millisecond, time.Since(start), float64(200*time.Millisecond), "The wait ends at the timeout")
+}
+
+func TestRequireRedisFailsInitialization(t *testing.T) {
+	setIdentity(t, "1")
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	mr.Close()
+
+	log, err := applogs.NewLoggerWithConfig(applogs.Config{
+		RedisAddr:    addr,
+		WaitForRedis: 300 * time.Millisecond,
+		RequireRedis: true,
+	})
+	assert.ErrorIs(t, err, applogs.ErrRedisUnavailable)
+	if assert.NotNil(t, log) {
+		defer log.StopLogger()
+		assert.ErrorIs(t, log.InitError(), applogs.ErrRedisUnavailable)
+		assert.True(t, log.EffectiveConfig().RequireRedis)
+	}
+
+	optional, err := applogs.NewLoggerWithConfig(applogs.Config{
+		RedisAddr:    addr,
+		WaitForRedis: 300 * time.Millisecond,
+	})
+	assert.NoError(t, err, "Without RequireRedis the logger carries on in fallback")
+	defer optional.StopLogger()
+}
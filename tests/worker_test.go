@@ -0,0 +1,60 @@
+package applogs
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// panickingSink panics on its first push and records the following ones
+type panickingSink struct {
+	applogs.MemorySink
+	pushes atomic.Int32
+}
+
+func (s *panickingSink) Push(ctx context.Context, key string, entries [][]byte) error {
+	if s.pushes.Add(1) == 1 {
+		panic("backend exploded")
+	}
+	return s.MemorySink.Push(ctx, key, entries)
+}
+
+func TestWorkerRestartsAfterPanic(t *testing.T) {
+	setIdentity(t, "1")
+	fallbackPath := createMockFallbackDir()
+	defer os.RemoveAll(fallbackPath)
+
+	output := &syncBuffer{}
+	logger.SetLostLogOutput(output)
+	defer logger.SetLostLogOutput(os.Stderr)
+
+	sink := &panickingSink{}
+	log := applogs.NewLogger(10)
+	defer log.StopLogger()
+	log.SetFallbackPath(fallbackPath)
+	log.SetSink(sink)
+	t.Cleanup(func() { log.SetSink(nil) })
+	before := log.WorkerPanicsTotal()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	log.Info("first", nil)
+	assert.NoError(t, log.Flush(ctx), "Flush returns although the worker panicked")
+	log.Info("second", nil)
+	assert.NoError(t, log.Flush(ctx))
+
+	assert.Equal(t, before+1, log.WorkerPanicsTotal())
+	assert.Contains(t, sink.String(), "second", "Logging resumes after the panic")
+	assert.Contains(t, output.String(), "backend exploded", "The panic is reported on stderr")
+
+	fallback := strings.Join(readFallbackLogs(fallbackPath), "\n")
+	assert.Contains(t, fallback, `"message":"first"`, "The batch in flight is saved to fallback")
+	assert.Contains(t, fallback, logger.WorkerPanicMessage)
+}
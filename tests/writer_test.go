@@ -0,0 +1,83 @@
+package applogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+)
+
+// listEntries decodes the entries of the test key, oldest first
+func listEntries(t *testing.T, logs []string) []map[string]interface{} {
+	entries := make([]map[string]interface{}, len(logs))
+	for i, raw := range logs {
+		assert.NoError(t, json.Unmarshal([]byte(raw), &entries[len(logs)-1-i]))
+	}
+	return entries
+}
+
+func TestWriterLogsEachLine(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	logger := applogs.NewTestLogger()
+	defer logger.StopLogger()
+	logger.SetRedisClient(client)
+
+	stdlog := log.New(logger.Writer("warn"), "http: ", 0)
+	stdlog.Print("TLS handshake error")
+
+	w := logger.Writer("error")
+	fmt.Fprint(w, "first\nsecond\r\n\nthi")
+	fmt.Fprint(w, "rd")
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 3, len(logs), "A partial line waits for its newline")
+	fmt.Fprint(w, "\n")
+	fmt.Fprint(logger.Writer("verbose"), "Unknown level\n")
+
+	logs, _ = mr.List("applogs:TEST:unit:test-service:1")
+	entries := listEntries(t, logs)
+	if assert.Equal(t, 5, len(entries)) {
+		assert.Equal(t, "http: TLS handshake error", entries[0]["message"])
+		assert.Equal(t, "warn", entries[0]["level"])
+		for i, message := range []string{"first", "second", "third"} {
+			assert.Equal(t, message, entries[i+1]["message"])
+			assert.Equal(t, "error", entries[i+1]["level"])
+		}
+		assert.Equal(t, "info", entries[4]["level"], "Other levels log as info")
+	}
+}
+
+func TestWriterIsSafeForConcurrentWrites(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	logger := applogs.NewTestLogger()
+	defer logger.StopLogger()
+	logger.SetRedisClient(client)
+	w := logger.Writer("info")
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				fmt.Fprintf(w, "goroutine %d line %d\n", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	logs, _ := mr.List("applogs:TEST:unit:test-service:1")
+	assert.Equal(t, 200, len(logs))
+	for _, entry := range listEntries(t, logs) {
+		assert.Regexp(t, `^goroutine \d line \d+$`, entry["message"], "Lines are never interleaved")
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !applogs_minimal
+
+package applogs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bashx3r0/scala-applogs-client/pkg/applogs"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestZapBypassesThePipeline(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	log.Zap().With(zap.String("component", "middleware")).Info("Written by Zap only", zap.Int("status", 200))
+	assert.Contains(t, syslogLine(t, "Written by Zap only"), `"status":200`)
+	assert.False(t, mr.Exists("applogs:TEST:unit:test-service:1"), "Nothing reaches Redis")
+
+	assert.NotPanics(t, func() { applogs.NewNopLogger().Zap().Info("Discarded") })
+}
+
+func TestZapCoreGoesThroughThePipeline(t *testing.T) {
+	setIdentity(t, "1")
+	mr, client := setupMockRedis(t)
+	defer mr.Close()
+
+	log := applogs.NewTestLogger()
+	defer log.StopLogger()
+	log.SetRedisClient(client)
+
+	zlog := zap.New(log.ZapCore()).With(zap.String("request_id", "req-1"))
+	zlog.Warn("Slow upstream", zap.Int("ms", 850), zap.Error(errors.New("timeout")))
+
+	entry := lastEntry(t, mr)
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "Slow upstream", entry["message"])
+	assert.Equal(t, map[string]interface{}{"request_id": "req-1", "ms": float64(850), "error": "timeout"}, entry["metadata"])
+
+	assert.NoError(t, log.SetLevel("error"))
+	t.Cleanup(func() { log.SetLevel("debug") })
+	zlog.Info("Below the level")
+	assert.Equal(t, "Slow upstream", lastEntry(t, mr)["message"], "The level of the logger applies")
+}
@@ -5,8 +5,8 @@ import (
 	"time"
 
 	"github.com/bashx3r0/scala-applogs-client/internal/logger"
+	"github.com/bashx3r0/scala-applogs-client/internal/zlog"
 	"github.com/go-redis/redis/v8" // Importing redis package
-	"go.uber.org/zap"
 )
 
 // logEntry represents a single log entry for asynchronous processing
@@ -19,14 +19,16 @@ type logEntry struct {
 // Applogs client structure
 type Applogs struct {
 	logQueue chan logEntry // Buffered channel for asynchronous logging
+	done     chan struct{} // Closed once processLogs has consumed every queued entry
 }
 
 // NewLogger initializes the logger and sets up the log queue
 func NewLogger(queueSize int) *Applogs {
 
-	logger.InitApplogs()
+	_ = logger.InitApplogs() // The failures are logged, the logger carrying on with what could be initialized
 	applogs := &Applogs{
 		logQueue: make(chan logEntry, queueSize), // Buffered log queue
+		done:     make(chan struct{}),
 	}
 	go applogs.processLogs() // Start log processing in a separate goroutine
 	return applogs
@@ -44,39 +46,43 @@ func (a *Applogs) SetRedisClient(mockClient *redis.Client) {
 
 // logAsync queues a log entry for asynchronous processing
 func (a *Applogs) logAsync(level, message string, fields map[string]interface{}) {
-	entry := logEntry{level: level, message: message, fields: fields}
+	entry := logEntry{level: level, message: message, fields: logger.NormalizeFields(fields)}
 	select {
 	case a.logQueue <- entry:
 		fmt.Println("Log successfully added to the queue")
 	default:
 		// Log queue is full; optionally drop the log or handle the overflow
-		logger.Logger().Warn("Log queue is full, dropping log", zap.String("level", level), zap.String("message", message))
+		logger.Logger().Warn("Log queue is full, dropping log", zlog.String("level", level), zlog.String("message", message))
 	}
 }
 
 // processLogs handles asynchronous processing of logs from the queue
 func (a *Applogs) processLogs() {
+	defer close(a.done)
 	for entry := range a.logQueue {
 		// Log to Redis and Uber Zap
 		logger.LogToRedis(entry.level, entry.message, entry.fields)
 		switch entry.level {
 		case "info":
-			logger.Logger().Info(entry.message, zap.Any("metadata", entry.fields))
+			logger.Logger().Info(entry.message, zlog.Any("metadata", entry.fields))
 		case "debug":
-			logger.Logger().Debug(entry.message, zap.Any("metadata", entry.fields))
+			logger.Logger().Debug(entry.message, zlog.Any("metadata", entry.fields))
 		case "warn":
-			logger.Logger().Warn(entry.message, zap.Any("metadata", entry.fields))
+			logger.Logger().Warn(entry.message, zlog.Any("metadata", entry.fields))
 		case "error":
-			logger.Logger().Error(entry.message, zap.Any("metadata", entry.fields))
+			logger.Logger().Error(entry.message, zlog.Any("metadata", entry.fields))
 		case "fatal":
-			logger.Logger().Fatal(entry.message, zap.Any("metadata", entry.fields))
+			logger.Logger().Fatal(entry.message, zlog.Any("metadata", entry.fields))
 		}
 	}
 }
 
-// StopLogger gracefully shuts down the logger, ensuring all logs are processed
+// StopLogger gracefully shuts down the logger, returning once all queued logs
+// are processed and the log files are synced
 func (a *Applogs) StopLogger() {
 	close(a.logQueue) // Close the log queue to stop processing
+	<-a.done
+	_ = logger.Logger().Sync()
 	logger.Logger().Info("Logger stopped gracefully")
 }
 
@@ -112,7 +118,7 @@ func (a *Applogs) LogRequest(method, url, clientIP string, headers map[string][]
 		"url":       url,
 		"client_ip": clientIP,
 		"headers":   headers,
-		"timestamp": time.Now().UTC(),
+		"timestamp": logger.Now().UTC(),
 	}
 	a.logAsync("info", "Incoming request", fields)
 }
@@ -122,7 +128,7 @@ func (a *Applogs) LogResponse(statusCode int, duration time.Duration) {
 	fields := map[string]interface{}{
 		"status_code": statusCode,
 		"duration_ms": duration.Milliseconds(),
-		"timestamp":   time.Now().UTC(),
+		"timestamp":   logger.Now().UTC(),
 	}
 	a.logAsync("info", "Outgoing response", fields)
 }
@@ -134,7 +140,7 @@ func (a *Applogs) LogPanic(panicData interface{}, method, url, clientIP string)
 		"method":    method,
 		"url":       url,
 		"client_ip": clientIP,
-		"timestamp": time.Now().UTC(),
+		"timestamp": logger.Now().UTC(),
 	}
 	a.logAsync("error", "Recovered from panic", fields)
 }